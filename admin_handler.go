@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// CheckStorageConsistency verifica a consistência entre banco e arquivos XML
+// @Summary Consistência do armazenamento
+// @Description Lista NFes cujo XML não existe em disco e arquivos XML sem NFe cadastrada
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.StorageConsistencyReport
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/storage/consistency [get]
+func (h *NFeHandler) CheckStorageConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.CheckStorageConsistency()
+	if err != nil {
+		h.logger.Error("Erro ao verificar consistência do armazenamento", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao verificar consistência do armazenamento", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// RepairStorage baixa novamente os XMLs ausentes em disco
+// @Summary Reparo do armazenamento
+// @Description Baixa novamente da SEFAZ os XMLs ausentes ou marca as NFes como inválidas
+// @Tags Admin
+// @Produce json
+// @Param dry_run query bool false "Apenas lista as NFes que seriam reparadas" default(false)
+// @Success 200 {object} domain.StorageRepairReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/storage/repair [post]
+func (h *NFeHandler) RepairStorage(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Valor inválido para dry_run", err)
+		return
+	}
+
+	h.logger.Info("Requisição de reparo do armazenamento recebida", "dry_run", dryRun)
+
+	report, err := h.service.RepairStorage(dryRun)
+	if err != nil {
+		h.logger.Error("Erro ao reparar armazenamento", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao reparar armazenamento", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// CleanupStorage remove os XMLs que já cumpriram o prazo de retenção
+// @Summary Limpeza do armazenamento
+// @Description Arquiva ou exclui os XMLs fora do prazo de retenção que possuem cópia no backup
+// @Tags Admin
+// @Produce json
+// @Param dry_run query bool false "Apenas lista os XMLs que seriam removidos" default(false)
+// @Success 200 {object} domain.StorageCleanupReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/storage/cleanup [post]
+func (h *NFeHandler) CleanupStorage(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Valor inválido para dry_run", err)
+		return
+	}
+
+	h.logger.Info("Requisição de limpeza do armazenamento recebida", "dry_run", dryRun)
+
+	report, err := h.service.CleanupStorage(dryRun)
+	if err != nil {
+		if errors.Is(err, domain.ErrRetentionDisabled) {
+			h.sendError(w, r, http.StatusBadRequest, "Prazo de retenção de XMLs não configurado", err)
+			return
+		}
+		h.logger.Error("Erro ao limpar armazenamento", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao limpar armazenamento", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// ReloadCertificates recarrega os certificados digitais sem reiniciar a aplicação
+// @Summary Recarga dos certificados
+// @Description Lê novamente os arquivos dos certificados A1 e passa a usá-los nas chamadas à SEFAZ. Nenhum certificado é trocado se algum estiver ilegível, vencido ou pertencer a outro CNPJ.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.CertificateReloadReport
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/certificate/reload [post]
+func (h *NFeHandler) ReloadCertificates(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Requisição de recarga dos certificados recebida")
+
+	report, err := h.service.ReloadCertificates()
+	if err != nil {
+		if errors.Is(err, domain.ErrCertificateExpired) {
+			h.sendError(w, r, http.StatusUnprocessableEntity, "Certificado vencido ou ainda não válido", err)
+			return
+		}
+		if errors.Is(err, domain.ErrCertificateCNPJMismatch) {
+			h.sendError(w, r, http.StatusUnprocessableEntity, "Certificado não pertence ao CNPJ configurado", err)
+			return
+		}
+		h.logger.Error("Erro ao recarregar certificados", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao recarregar certificados", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// GetSyncJobRetention retorna o prazo de guarda do histórico de sincronizações
+// @Summary Retenção do histórico de sincronizações
+// @Description Retorna por quantos dias os jobs de sincronização são mantidos e a data de corte atual. Com retention_days 0 o histórico é mantido para sempre.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.SyncJobRetention
+// @Router /api/v1/admin/sync/retention [get]
+func (h *NFeHandler) GetSyncJobRetention(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, h.service.GetSyncJobRetention())
+}
+
+// GetVersion retorna a versão da aplicação e do schema do banco
+// @Summary Versão da aplicação
+// @Description Retorna a versão, o commit e a data de compilação do binário e a última migration aplicada ao banco. schema é nulo quando nenhuma migration foi aplicada; schema.dirty indica uma migration interrompida.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.VersionInfo
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/version [get]
+func (h *NFeHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	info, err := h.service.GetVersionInfo()
+	if err != nil {
+		h.logger.Error("Erro ao consultar versão", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao consultar versão", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, info)
+}
+
+// GetSelfCheck retorna o resultado da verificação de inicialização
+// @Summary Verificação de inicialização
+// @Description Retorna o resultado da verificação executada na inicialização: banco, migrations, certificados, armazenamento e SEFAZ. Itens com status fail impedem a inicialização; itens com warn apenas geram aviso no log.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.SelfCheckReport
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/admin/selfcheck [get]
+func (h *NFeHandler) GetSelfCheck(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetSelfCheck()
+	if err != nil {
+		h.sendError(w, r, http.StatusNotFound, "Verificação de inicialização não executada", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// StartReprocess inicia o reprocessamento dos XMLs armazenados
+// @Summary Reprocessamento dos XMLs
+// @Description Percorre os XMLs armazenados e preenche nas NFes já cadastradas os campos derivados informados em fields, sem baixar novamente da SEFAZ. O progresso é acompanhado pelo job retornado.
+// @Tags Admin
+// @Produce json
+// @Param fields query string true "Campos a preencher, separados por vírgula (protocolo, uf, crt, tipo_operacao, itens, referencias)"
+// @Success 202 {object} domain.ReprocessJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/reprocess [post]
+func (h *NFeHandler) StartReprocess(w http.ResponseWriter, r *http.Request) {
+	fields, err := domain.ParseReprocessFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Campos de reprocessamento inválidos", err)
+		return
+	}
+
+	h.logger.Info("Requisição de reprocessamento recebida", "fields", fields)
+
+	job, err := h.service.StartReprocess(fields)
+	if err != nil {
+		if errors.Is(err, domain.ErrReprocessRunning) {
+			h.sendError(w, r, http.StatusConflict, "Reprocessamento já em andamento", err)
+			return
+		}
+		h.logger.Error("Erro ao iniciar reprocessamento", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao iniciar reprocessamento", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusAccepted, job)
+}
+
+// GetReprocessJob retorna o progresso de um reprocessamento
+// @Summary Progresso do reprocessamento
+// @Description Retorna o status e os contadores de um reprocessamento dos XMLs armazenados
+// @Tags Admin
+// @Produce json
+// @Param id path string true "ID do reprocessamento"
+// @Success 200 {object} domain.ReprocessJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/reprocess/{id} [get]
+func (h *NFeHandler) GetReprocessJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "ID de reprocessamento inválido", err)
+		return
+	}
+
+	job, err := h.service.GetReprocessJob(jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrReprocessJobNotFound) {
+			h.sendError(w, r, http.StatusNotFound, "Reprocessamento não encontrado", err)
+			return
+		}
+		h.logger.Error("Erro ao buscar reprocessamento", "job_id", jobID, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar reprocessamento", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, job)
+}
+
+// parseDryRun lê o parâmetro dry_run da query (padrão false)
+func parseDryRun(r *http.Request) (bool, error) {
+	dryRunStr := r.URL.Query().Get("dry_run")
+	if dryRunStr == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(dryRunStr)
+}
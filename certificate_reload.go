@@ -0,0 +1,57 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/pkg/certificate"
+)
+
+// ReloadCertificates lê novamente os certificados digitais de todos os CNPJs e
+// os troca nos clientes SEFAZ. Os certificados são carregados e validados antes
+// da troca: se algum estiver ilegível, vencido ou pertencer a outro CNPJ, nenhum cliente é
+// alterado.
+func (s *nfeService) ReloadCertificates() (*domain.CertificateReloadReport, error) {
+	now := time.Now()
+
+	certs := make([]tls.Certificate, len(s.companies))
+	report := &domain.CertificateReloadReport{
+		Certificates: make([]domain.CertificateInfo, 0, len(s.companies)),
+	}
+	for i, company := range s.companies {
+		cert, err := certificate.LoadCertificate(company.CertPath, company.CertPassword)
+		if err != nil {
+			return nil, fmt.Errorf("cnpj %s: %w", company.CNPJ, err)
+		}
+
+		leaf := cert.Leaf
+		if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+			return nil, fmt.Errorf("cnpj %s: %w (valid from %s to %s)", company.CNPJ, domain.ErrCertificateExpired,
+				leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+		}
+
+		if err := certificate.CheckCNPJ(leaf, company.CNPJ); err != nil {
+			return nil, fmt.Errorf("cnpj %s: %w: %v", company.CNPJ, domain.ErrCertificateCNPJMismatch, err)
+		}
+
+		certs[i] = cert
+		report.Certificates = append(report.Certificates, domain.CertificateInfo{
+			CNPJ:      company.CNPJ,
+			Subject:   leaf.Subject.CommonName,
+			NotBefore: leaf.NotBefore,
+			NotAfter:  leaf.NotAfter,
+		})
+	}
+
+	for i, company := range s.companies {
+		if err := company.Client.SetCertificate(certs[i]); err != nil {
+			return nil, fmt.Errorf("cnpj %s: failed to set certificate: %w", company.CNPJ, err)
+		}
+		s.logger.Info("Certificado recarregado", "cnpj", company.CNPJ, "not_after", report.Certificates[i].NotAfter)
+	}
+
+	report.ReloadedAt = time.Now()
+	return report, nil
+}
@@ -0,0 +1,622 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config agrupa todas as configurações da aplicação
+type Config struct {
+	Server   ServerConfig
+	Database DatabaseConfig
+	Sefaz    SefazConfig
+	Storage  StorageConfig
+	Sync     SyncConfig
+	Alert    AlertConfig
+}
+
+// ServerConfig contém as configurações do servidor HTTP
+type ServerConfig struct {
+	Port         string
+	Host         string
+	Env          string
+	MaxBodyBytes int64
+
+	// EnableCompression comprime com gzip as respostas JSON quando o cliente aceita
+	EnableCompression bool
+
+	// MaxExportRows é o número máximo de NFes de uma exportação; zero desativa o limite
+	MaxExportRows int
+
+	// IdempotencyTTL é por quanto tempo as respostas das requisições com
+	// Idempotency-Key são repetidas; zero desativa as chaves
+	IdempotencyTTL time.Duration
+
+	// DefaultExcludeStatuses são omitidos da listagem de NFes quando nenhum status é filtrado
+	DefaultExcludeStatuses []string
+
+	// AccessLogFile recebe o log de acesso HTTP, separado do log da aplicação;
+	// vazio grava na saída padrão. AccessLogFormat é text ou json.
+	AccessLogFile   string
+	AccessLogFormat string
+}
+
+// DatabaseConfig contém as configurações de conexão com o PostgreSQL
+type DatabaseConfig struct {
+	Host               string
+	Port               string
+	User               string
+	Password           string
+	Name               string
+	SSLMode            string
+	Schema             string
+	MaxConnections     int
+	MaxIdleConnections int
+	// ApplicationName identifica as conexões da aplicação em pg_stat_activity
+	ApplicationName string
+	// SlowQueryThreshold é a duração a partir da qual uma consulta é registrada
+	// no log; zero desativa o registro
+	SlowQueryThreshold time.Duration
+	// TimestampSource define o relógio de created_at e updated_at das NFes:
+	// app (aplicação) ou database (banco)
+	TimestampSource string
+}
+
+// CompanyConfig identifica um CNPJ sincronizado e o certificado usado por ele
+type CompanyConfig struct {
+	CNPJ         string
+	CertPath     string
+	CertPassword string
+}
+
+// SefazConfig contém as configurações de integração com a SEFAZ
+type SefazConfig struct {
+	Ambiente      string
+	UF            string
+	CNPJ          string
+	CertPath      string
+	CertPassword  string
+	Timeout       time.Duration
+	ProxyURL      string
+	MinTLSVersion string
+	// UserAgent é enviado no cabeçalho User-Agent das chamadas à SEFAZ
+	UserAgent string
+
+	MaxIdleConnsPerHost int
+	KeepAlive           time.Duration
+	IdleConnTimeout     time.Duration
+
+	DFeBatchSize     int
+	DFeMaxDocsPerRun int
+
+	// Timeouts por operação; quando zero vale Timeout
+	StatusTimeout   time.Duration
+	DownloadTimeout time.Duration
+	ConsultaTimeout time.Duration
+
+	// EndpointOverrides substitui endereços de web services (UF:SERVICO -> URL)
+	EndpointOverrides map[string]string
+
+	// ServiceVersions e ServiceNamespaces substituem a versão do leiaute e o
+	// namespace do WSDL por serviço (SERVICO -> valor)
+	ServiceVersions   map[string]string
+	ServiceNamespaces map[string]string
+
+	// MaintenanceWindows são as janelas de manutenção programada da SEFAZ
+	// ("HH:MM-HH:MM" ou "dia HH:MM-HH:MM"), durante as quais a sincronização
+	// agendada não é executada
+	MaintenanceWindows string
+	// MaintenanceTimezone é o fuso IANA em que as janelas são avaliadas
+	MaintenanceTimezone string
+
+	// Companies são os CNPJs sincronizados, cada um com o próprio certificado
+	// A1. Sem SEFAZ_COMPANIES, contém apenas SEFAZ_CNPJ/SEFAZ_CERT_PATH.
+	Companies []CompanyConfig
+
+	// BreakerThreshold falhas consecutivas abrem o circuit breaker por BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// RequestDelay é o intervalo mínimo entre chamadas consecutivas à SEFAZ,
+	// somando todos os CNPJs e workers; zero não limita as chamadas
+	RequestDelay time.Duration
+
+	// MaxRequestsPerMinute limita o total de chamadas à SEFAZ por minuto,
+	// somando todos os CNPJs e workers; zero não limita
+	MaxRequestsPerMinute int
+
+	// XSDPath é o diretório dos schemas XSD da NFe (pacote de liberação do
+	// Portal da NFe); vazio desativa a validação pelos schemas
+	XSDPath string
+}
+
+// StorageConfig contém as configurações de armazenamento de XMLs
+type StorageConfig struct {
+	// Backend define onde os XMLs são guardados: disk (XMLPath) ou database
+	Backend string
+	XMLPath string
+	// ShardBy define a data usada nos diretórios ano/mês dos XMLs: emissao ou recebimento
+	ShardBy string
+
+	RetentionYears  int
+	BackupPath      string
+	ArchivePath     string
+	CleanupSchedule string
+}
+
+// SyncConfig contém as configurações do agendamento de sincronização
+type SyncConfig struct {
+	CronSchedule string
+	Enabled      bool
+	OnConflict   string
+
+	// ItemsOnConflict define como os itens de uma NFe baixada novamente são
+	// regravados: replace ou upsert
+	ItemsOnConflict string
+
+	// CancelledWithoutXML define o tratamento das NFes já canceladas no primeiro
+	// download, quando a SEFAZ entrega só o evento: store ou resumo
+	CancelledWithoutXML string
+
+	// ValueTolerance é a diferença máxima aceita entre o vNF e o valor
+	// recomposto a partir dos itens antes de marcar a NFe como suspeita
+	ValueTolerance float64
+
+	// ParseConcurrency é o número de workers que baixam e interpretam os XMLs
+	// em paralelo durante a sincronização
+	ParseConcurrency int
+
+	// MaxConcurrentJobs é o número máximo de sincronizações simultâneas,
+	// somando as agendadas e as manuais; as demais são recusadas
+	MaxConcurrentJobs int
+
+	// MaxTotalRetries é o total de novas tentativas de chamadas à SEFAZ com
+	// falha repetível em uma sincronização; esgotado, a execução é encerrada e
+	// as NFes restantes ficam como erro do job. Zero desativa as novas tentativas.
+	MaxTotalRetries int
+
+	// JobRetentionDays é por quantos dias o histórico de sincronizações é
+	// mantido; JobCleanupSchedule é quando os jobs mais antigos são removidos.
+	// Zero mantém o histórico para sempre.
+	JobRetentionDays   int
+	JobCleanupSchedule string
+}
+
+// AlertConfig contém as configurações dos alertas de falha de sincronização
+type AlertConfig struct {
+	// WebhookURL recebe os alertas (payload compatível com Slack); vazio desativa
+	WebhookURL string
+	// ErrorThreshold é o número de NFes com erro em um job que gera alerta (0 desativa)
+	ErrorThreshold int
+	Timeout        time.Duration
+}
+
+// identifierPattern valida nomes de schema do PostgreSQL
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LoadConfig carrega as configurações do arquivo .env e das variáveis de ambiente
+func LoadConfig() (*Config, error) {
+	viper.SetConfigFile(".env")
+	viper.SetConfigType("env")
+	viper.AutomaticEnv()
+
+	setDefaults()
+
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	cfg := &Config{
+		Server: ServerConfig{
+			Port: viper.GetString("SERVER_PORT"),
+			Host: viper.GetString("SERVER_HOST"),
+			Env:  viper.GetString("ENV"),
+
+			MaxBodyBytes:           viper.GetInt64("SERVER_MAX_BODY_BYTES"),
+			DefaultExcludeStatuses: splitList(viper.GetString("SERVER_DEFAULT_EXCLUDE_STATUSES")),
+			EnableCompression:      viper.GetBool("SERVER_ENABLE_COMPRESSION"),
+			MaxExportRows:          viper.GetInt("SERVER_MAX_EXPORT_ROWS"),
+			IdempotencyTTL:         viper.GetDuration("SERVER_IDEMPOTENCY_TTL"),
+			AccessLogFile:          viper.GetString("SERVER_ACCESS_LOG_FILE"),
+			AccessLogFormat:        viper.GetString("SERVER_ACCESS_LOG_FORMAT"),
+		},
+		Database: DatabaseConfig{
+			Host:               viper.GetString("DB_HOST"),
+			Port:               viper.GetString("DB_PORT"),
+			User:               viper.GetString("DB_USER"),
+			Password:           viper.GetString("DB_PASSWORD"),
+			Name:               viper.GetString("DB_NAME"),
+			SSLMode:            viper.GetString("DB_SSLMODE"),
+			Schema:             viper.GetString("DB_SCHEMA"),
+			MaxConnections:     viper.GetInt("DB_MAX_CONNECTIONS"),
+			MaxIdleConnections: viper.GetInt("DB_MAX_IDLE_CONNECTIONS"),
+			ApplicationName:    viper.GetString("DB_APPLICATION_NAME"),
+			SlowQueryThreshold: viper.GetDuration("DB_SLOW_QUERY_THRESHOLD"),
+			TimestampSource:    viper.GetString("DB_TIMESTAMP_SOURCE"),
+		},
+		Sefaz: SefazConfig{
+			Ambiente:      viper.GetString("SEFAZ_AMBIENTE"),
+			UF:            viper.GetString("SEFAZ_UF"),
+			CNPJ:          viper.GetString("SEFAZ_CNPJ"),
+			CertPath:      viper.GetString("SEFAZ_CERT_PATH"),
+			CertPassword:  viper.GetString("SEFAZ_CERT_PASSWORD"),
+			Timeout:       viper.GetDuration("SEFAZ_TIMEOUT"),
+			ProxyURL:      viper.GetString("SEFAZ_PROXY_URL"),
+			MinTLSVersion: viper.GetString("SEFAZ_MIN_TLS_VERSION"),
+			UserAgent:     viper.GetString("SEFAZ_USER_AGENT"),
+
+			MaxIdleConnsPerHost: viper.GetInt("SEFAZ_MAX_IDLE_CONNS_PER_HOST"),
+			KeepAlive:           viper.GetDuration("SEFAZ_KEEP_ALIVE"),
+			IdleConnTimeout:     viper.GetDuration("SEFAZ_IDLE_CONN_TIMEOUT"),
+
+			DFeBatchSize:     viper.GetInt("SEFAZ_DFE_BATCH_SIZE"),
+			DFeMaxDocsPerRun: viper.GetInt("SEFAZ_DFE_MAX_DOCS_PER_RUN"),
+
+			StatusTimeout:   viper.GetDuration("SEFAZ_STATUS_TIMEOUT"),
+			DownloadTimeout: viper.GetDuration("SEFAZ_DOWNLOAD_TIMEOUT"),
+			ConsultaTimeout: viper.GetDuration("SEFAZ_CONSULTA_TIMEOUT"),
+
+			MaintenanceWindows:  viper.GetString("SEFAZ_MAINTENANCE_WINDOWS"),
+			MaintenanceTimezone: viper.GetString("SEFAZ_MAINTENANCE_TIMEZONE"),
+
+			BreakerThreshold: viper.GetInt("SEFAZ_BREAKER_THRESHOLD"),
+			BreakerCooldown:  viper.GetDuration("SEFAZ_BREAKER_COOLDOWN"),
+
+			RequestDelay:         viper.GetDuration("SEFAZ_REQUEST_DELAY"),
+			MaxRequestsPerMinute: viper.GetInt("SEFAZ_MAX_REQUESTS_PER_MINUTE"),
+
+			XSDPath: viper.GetString("SEFAZ_XSD_PATH"),
+		},
+		Storage: StorageConfig{
+			Backend: viper.GetString("XML_STORAGE_BACKEND"),
+			XMLPath: viper.GetString("XML_STORAGE_PATH"),
+			ShardBy: viper.GetString("XML_SHARD_BY"),
+
+			RetentionYears:  viper.GetInt("XML_RETENTION_YEARS"),
+			BackupPath:      viper.GetString("XML_BACKUP_PATH"),
+			ArchivePath:     viper.GetString("XML_ARCHIVE_PATH"),
+			CleanupSchedule: viper.GetString("XML_CLEANUP_CRON_SCHEDULE"),
+		},
+		Sync: SyncConfig{
+			CronSchedule: viper.GetString("SYNC_CRON_SCHEDULE"),
+			Enabled:      viper.GetBool("SYNC_ENABLED"),
+			OnConflict:   viper.GetString("SYNC_ON_CONFLICT"),
+
+			ItemsOnConflict: viper.GetString("SYNC_ITEMS_ON_CONFLICT"),
+
+			CancelledWithoutXML: viper.GetString("SYNC_CANCELLED_WITHOUT_XML"),
+
+			ValueTolerance:   viper.GetFloat64("SYNC_VALUE_TOLERANCE"),
+			ParseConcurrency: viper.GetInt("SYNC_PARSE_CONCURRENCY"),
+
+			MaxConcurrentJobs: viper.GetInt("SYNC_MAX_CONCURRENT_JOBS"),
+			MaxTotalRetries:   viper.GetInt("SYNC_MAX_TOTAL_RETRIES"),
+
+			JobRetentionDays:   viper.GetInt("SYNC_JOB_RETENTION_DAYS"),
+			JobCleanupSchedule: viper.GetString("SYNC_JOB_CLEANUP_CRON_SCHEDULE"),
+		},
+		Alert: AlertConfig{
+			WebhookURL:     viper.GetString("ALERT_WEBHOOK_URL"),
+			ErrorThreshold: viper.GetInt("ALERT_ERROR_THRESHOLD"),
+			Timeout:        viper.GetDuration("ALERT_TIMEOUT"),
+		},
+	}
+
+	overrides, err := parseOverrides("SEFAZ_ENDPOINT_OVERRIDES", "UF:SERVICE=URL")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Sefaz.EndpointOverrides = overrides
+
+	if cfg.Sefaz.ServiceVersions, err = parseOverrides("SEFAZ_SERVICE_VERSIONS", "SERVICE=VERSION"); err != nil {
+		return nil, err
+	}
+	if cfg.Sefaz.ServiceNamespaces, err = parseOverrides("SEFAZ_SERVICE_NAMESPACES", "SERVICE=NAMESPACE"); err != nil {
+		return nil, err
+	}
+
+	companies, err := parseCompanies(viper.GetString("SEFAZ_COMPANIES"))
+	if err != nil {
+		return nil, err
+	}
+	if len(companies) == 0 {
+		companies = []CompanyConfig{{
+			CNPJ:         cfg.Sefaz.CNPJ,
+			CertPath:     cfg.Sefaz.CertPath,
+			CertPassword: cfg.Sefaz.CertPassword,
+		}}
+	}
+	cfg.Sefaz.Companies = companies
+
+	return cfg, nil
+}
+
+// parseCompanies interpreta a lista CNPJ=CAMINHO_DO_CERTIFICADO separada por
+// vírgulas. A senha de cada certificado vem de SEFAZ_CERT_PASSWORD_<CNPJ>, para
+// não exigir que as senhas fiquem juntas na lista.
+func parseCompanies(raw string) ([]CompanyConfig, error) {
+	companies := []CompanyConfig{}
+	for _, entry := range splitList(raw) {
+		cnpj, certPath, ok := strings.Cut(entry, "=")
+		cnpj, certPath = strings.TrimSpace(cnpj), strings.TrimSpace(certPath)
+		if !ok || cnpj == "" || certPath == "" {
+			return nil, fmt.Errorf("invalid SEFAZ_COMPANIES entry %q, expected CNPJ=CERT_PATH", entry)
+		}
+		companies = append(companies, CompanyConfig{
+			CNPJ:         cnpj,
+			CertPath:     certPath,
+			CertPassword: viper.GetString("SEFAZ_CERT_PASSWORD_" + cnpj),
+		})
+	}
+	return companies, nil
+}
+
+// splitList separa uma lista de valores separados por vírgula, ignorando vazios
+func splitList(raw string) []string {
+	values := []string{}
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseOverrides interpreta a lista CHAVE=VALOR separada por vírgulas da
+// variável env (ex: UF:SERVICO=URL em SEFAZ_ENDPOINT_OVERRIDES). format
+// descreve a entrada esperada nas mensagens de erro.
+func parseOverrides(env, format string) (map[string]string, error) {
+	overrides := map[string]string{}
+	for _, entry := range strings.Split(viper.GetString(env), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected %s", env, entry, format)
+		}
+		overrides[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return overrides, nil
+}
+
+// setDefaults define os valores padrão das configurações
+func setDefaults() {
+	viper.SetDefault("SERVER_PORT", "8080")
+	viper.SetDefault("SERVER_HOST", "localhost")
+	viper.SetDefault("ENV", "development")
+	viper.SetDefault("SERVER_MAX_BODY_BYTES", 1<<20)
+	viper.SetDefault("SERVER_ENABLE_COMPRESSION", false)
+	viper.SetDefault("SERVER_MAX_EXPORT_ROWS", 50000)
+	viper.SetDefault("SERVER_IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("SERVER_ACCESS_LOG_FORMAT", "text")
+
+	viper.SetDefault("DB_HOST", "localhost")
+	viper.SetDefault("DB_PORT", "5432")
+	viper.SetDefault("DB_USER", "postgres")
+	viper.SetDefault("DB_NAME", "nfe_sefaz")
+	viper.SetDefault("DB_SSLMODE", "disable")
+	viper.SetDefault("DB_SCHEMA", "public")
+	viper.SetDefault("DB_MAX_CONNECTIONS", 25)
+	viper.SetDefault("DB_MAX_IDLE_CONNECTIONS", 5)
+	viper.SetDefault("DB_APPLICATION_NAME", "nfe-sefaz-sync")
+	viper.SetDefault("DB_SLOW_QUERY_THRESHOLD", "0s")
+	viper.SetDefault("DB_TIMESTAMP_SOURCE", "app")
+
+	viper.SetDefault("SEFAZ_AMBIENTE", "homologacao")
+	viper.SetDefault("SEFAZ_TIMEOUT", "30s")
+	viper.SetDefault("SEFAZ_MIN_TLS_VERSION", "1.2")
+	viper.SetDefault("SEFAZ_USER_AGENT", "nfe-sefaz-sync")
+	viper.SetDefault("SEFAZ_MAINTENANCE_TIMEZONE", "America/Sao_Paulo")
+	viper.SetDefault("SEFAZ_MAX_IDLE_CONNS_PER_HOST", 10)
+	viper.SetDefault("SEFAZ_KEEP_ALIVE", "30s")
+	viper.SetDefault("SEFAZ_IDLE_CONN_TIMEOUT", "90s")
+	viper.SetDefault("SEFAZ_DFE_BATCH_SIZE", 50)
+	viper.SetDefault("SEFAZ_DFE_MAX_DOCS_PER_RUN", 2000)
+	viper.SetDefault("SEFAZ_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("SEFAZ_BREAKER_COOLDOWN", "1m")
+	viper.SetDefault("SEFAZ_REQUEST_DELAY", "0s")
+	viper.SetDefault("SEFAZ_MAX_REQUESTS_PER_MINUTE", 0)
+
+	viper.SetDefault("XML_STORAGE_BACKEND", "disk")
+	viper.SetDefault("XML_STORAGE_PATH", "./storage/xmls")
+	viper.SetDefault("XML_SHARD_BY", "emissao")
+	viper.SetDefault("XML_RETENTION_YEARS", 0)
+	viper.SetDefault("XML_CLEANUP_CRON_SCHEDULE", "0 3 * * 0")
+
+	viper.SetDefault("SYNC_CRON_SCHEDULE", "0 */6 * * *")
+	viper.SetDefault("SYNC_ENABLED", true)
+	viper.SetDefault("SYNC_ON_CONFLICT", "skip")
+	viper.SetDefault("SYNC_ITEMS_ON_CONFLICT", "replace")
+	viper.SetDefault("SYNC_CANCELLED_WITHOUT_XML", "store")
+	viper.SetDefault("SYNC_VALUE_TOLERANCE", 0.01)
+	viper.SetDefault("SYNC_PARSE_CONCURRENCY", runtime.NumCPU())
+	viper.SetDefault("SYNC_MAX_CONCURRENT_JOBS", 1)
+	viper.SetDefault("SYNC_MAX_TOTAL_RETRIES", 100)
+	viper.SetDefault("SYNC_JOB_RETENTION_DAYS", 90)
+	viper.SetDefault("SYNC_JOB_CLEANUP_CRON_SCHEDULE", "30 3 * * *")
+
+	viper.SetDefault("ALERT_ERROR_THRESHOLD", 1)
+	viper.SetDefault("ALERT_TIMEOUT", "10s")
+}
+
+// Validate verifica se as configurações obrigatórias estão presentes e válidas
+func (c *Config) Validate() error {
+	if c.Server.Port == "" {
+		return errors.New("SERVER_PORT is required")
+	}
+	if c.Server.MaxBodyBytes < 1 {
+		return errors.New("SERVER_MAX_BODY_BYTES must be greater than zero")
+	}
+	if c.Server.MaxExportRows < 0 {
+		return fmt.Errorf("SERVER_MAX_EXPORT_ROWS must not be negative, got %d", c.Server.MaxExportRows)
+	}
+	if c.Server.IdempotencyTTL < 0 {
+		return fmt.Errorf("SERVER_IDEMPOTENCY_TTL must not be negative, got %s", c.Server.IdempotencyTTL)
+	}
+	if c.Server.AccessLogFormat != "text" && c.Server.AccessLogFormat != "json" {
+		return fmt.Errorf("SERVER_ACCESS_LOG_FORMAT must be text or json, got %q", c.Server.AccessLogFormat)
+	}
+	if c.Database.Host == "" || c.Database.Name == "" {
+		return errors.New("DB_HOST and DB_NAME are required")
+	}
+	if c.Database.MaxConnections < 1 {
+		return errors.New("DB_MAX_CONNECTIONS must be greater than zero")
+	}
+	if !identifierPattern.MatchString(c.Database.Schema) {
+		return fmt.Errorf("DB_SCHEMA %q is not a valid schema name", c.Database.Schema)
+	}
+	// O PostgreSQL trunca application_name em 63 bytes
+	if len(c.Database.ApplicationName) > 63 {
+		return errors.New("DB_APPLICATION_NAME must have at most 63 characters")
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		return errors.New("DB_SLOW_QUERY_THRESHOLD must not be negative")
+	}
+	if c.Database.TimestampSource != "app" && c.Database.TimestampSource != "database" {
+		return fmt.Errorf("DB_TIMESTAMP_SOURCE must be app or database, got %q", c.Database.TimestampSource)
+	}
+	if c.Sefaz.Ambiente != "homologacao" && c.Sefaz.Ambiente != "producao" {
+		return fmt.Errorf("SEFAZ_AMBIENTE must be homologacao or producao, got %q", c.Sefaz.Ambiente)
+	}
+	if len(c.Sefaz.UF) != 2 {
+		return fmt.Errorf("SEFAZ_UF must have 2 letters, got %q", c.Sefaz.UF)
+	}
+	if len(c.Sefaz.Companies) == 0 {
+		return errors.New("SEFAZ_CNPJ or SEFAZ_COMPANIES is required")
+	}
+	seen := map[string]bool{}
+	for _, company := range c.Sefaz.Companies {
+		if len(company.CNPJ) != 14 {
+			return fmt.Errorf("SEFAZ_CNPJ must have 14 digits, got %q", company.CNPJ)
+		}
+		if company.CertPath == "" {
+			return fmt.Errorf("SEFAZ_CERT_PATH is required for CNPJ %s", company.CNPJ)
+		}
+		if seen[company.CNPJ] {
+			return fmt.Errorf("SEFAZ_COMPANIES lists CNPJ %s more than once", company.CNPJ)
+		}
+		seen[company.CNPJ] = true
+	}
+	if c.Sefaz.Timeout <= 0 {
+		return errors.New("SEFAZ_TIMEOUT must be greater than zero")
+	}
+	if c.Sefaz.StatusTimeout < 0 || c.Sefaz.DownloadTimeout < 0 || c.Sefaz.ConsultaTimeout < 0 {
+		return errors.New("SEFAZ_STATUS_TIMEOUT, SEFAZ_DOWNLOAD_TIMEOUT and SEFAZ_CONSULTA_TIMEOUT must not be negative")
+	}
+	switch c.Sefaz.MinTLSVersion {
+	case "1.0", "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("SEFAZ_MIN_TLS_VERSION must be 1.0, 1.1, 1.2 or 1.3, got %q", c.Sefaz.MinTLSVersion)
+	}
+	if strings.ContainsAny(c.Sefaz.UserAgent, "\r\n") {
+		return errors.New("SEFAZ_USER_AGENT must not contain line breaks")
+	}
+	if c.Sefaz.MaxIdleConnsPerHost < 1 {
+		return errors.New("SEFAZ_MAX_IDLE_CONNS_PER_HOST must be greater than zero")
+	}
+	if c.Sefaz.DFeBatchSize < 1 || c.Sefaz.DFeBatchSize > 50 {
+		return fmt.Errorf("SEFAZ_DFE_BATCH_SIZE must be between 1 and 50, got %d", c.Sefaz.DFeBatchSize)
+	}
+	if c.Sefaz.BreakerThreshold < 1 {
+		return errors.New("SEFAZ_BREAKER_THRESHOLD must be greater than zero")
+	}
+	if c.Sefaz.BreakerCooldown <= 0 {
+		return errors.New("SEFAZ_BREAKER_COOLDOWN must be greater than zero")
+	}
+	if c.Sefaz.RequestDelay < 0 {
+		return errors.New("SEFAZ_REQUEST_DELAY must not be negative")
+	}
+	if c.Sefaz.MaxRequestsPerMinute < 0 {
+		return errors.New("SEFAZ_MAX_REQUESTS_PER_MINUTE must not be negative")
+	}
+	if c.Sefaz.DFeMaxDocsPerRun < c.Sefaz.DFeBatchSize {
+		return errors.New("SEFAZ_DFE_MAX_DOCS_PER_RUN must be greater than or equal to SEFAZ_DFE_BATCH_SIZE")
+	}
+	if c.Sefaz.ProxyURL != "" {
+		if u, err := url.Parse(c.Sefaz.ProxyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("SEFAZ_PROXY_URL %q is not a valid url", c.Sefaz.ProxyURL)
+		}
+	}
+	if c.Storage.Backend != "disk" && c.Storage.Backend != "database" {
+		return fmt.Errorf("XML_STORAGE_BACKEND must be disk or database, got %q", c.Storage.Backend)
+	}
+	if c.Storage.Backend == "disk" && c.Storage.XMLPath == "" {
+		return errors.New("XML_STORAGE_PATH is required")
+	}
+	if c.Storage.ShardBy != "emissao" && c.Storage.ShardBy != "recebimento" {
+		return fmt.Errorf("XML_SHARD_BY must be emissao or recebimento, got %q", c.Storage.ShardBy)
+	}
+	if c.Storage.RetentionYears != 0 {
+		if c.Storage.RetentionYears < 5 {
+			return fmt.Errorf("XML_RETENTION_YEARS must be at least 5 (fiscal minimum), got %d", c.Storage.RetentionYears)
+		}
+		if c.Storage.BackupPath == "" {
+			return errors.New("XML_BACKUP_PATH is required when XML_RETENTION_YEARS is set")
+		}
+		if c.Storage.Backend != "disk" {
+			return errors.New("XML_RETENTION_YEARS requires XML_STORAGE_BACKEND=disk")
+		}
+	}
+	if c.Sync.Enabled && c.Sync.CronSchedule == "" {
+		return errors.New("SYNC_CRON_SCHEDULE is required when SYNC_ENABLED is true")
+	}
+	if c.Sync.OnConflict != "skip" && c.Sync.OnConflict != "update" {
+		return fmt.Errorf("SYNC_ON_CONFLICT must be skip or update, got %q", c.Sync.OnConflict)
+	}
+	if c.Sync.ItemsOnConflict != "replace" && c.Sync.ItemsOnConflict != "upsert" {
+		return fmt.Errorf("SYNC_ITEMS_ON_CONFLICT must be replace or upsert, got %q", c.Sync.ItemsOnConflict)
+	}
+	if c.Sync.CancelledWithoutXML != "store" && c.Sync.CancelledWithoutXML != "resumo" {
+		return fmt.Errorf("SYNC_CANCELLED_WITHOUT_XML must be store or resumo, got %q", c.Sync.CancelledWithoutXML)
+	}
+	if c.Sync.ValueTolerance < 0 {
+		return fmt.Errorf("SYNC_VALUE_TOLERANCE must not be negative, got %v", c.Sync.ValueTolerance)
+	}
+	if c.Sync.ParseConcurrency < 1 {
+		return fmt.Errorf("SYNC_PARSE_CONCURRENCY must be at least 1, got %d", c.Sync.ParseConcurrency)
+	}
+	if c.Sync.MaxConcurrentJobs < 1 {
+		return fmt.Errorf("SYNC_MAX_CONCURRENT_JOBS must be at least 1, got %d", c.Sync.MaxConcurrentJobs)
+	}
+	if c.Sync.MaxTotalRetries < 0 {
+		return errors.New("SYNC_MAX_TOTAL_RETRIES must not be negative")
+	}
+	if c.Sync.JobRetentionDays < 0 {
+		return fmt.Errorf("SYNC_JOB_RETENTION_DAYS must not be negative, got %d", c.Sync.JobRetentionDays)
+	}
+	if c.Sync.JobRetentionDays > 0 && c.Sync.JobCleanupSchedule == "" {
+		return errors.New("SYNC_JOB_CLEANUP_CRON_SCHEDULE is required when SYNC_JOB_RETENTION_DAYS is set")
+	}
+	if c.Alert.WebhookURL != "" {
+		if u, err := url.Parse(c.Alert.WebhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("ALERT_WEBHOOK_URL %q is not a valid url", c.Alert.WebhookURL)
+		}
+	}
+	if c.Alert.ErrorThreshold < 0 {
+		return fmt.Errorf("ALERT_ERROR_THRESHOLD must not be negative, got %d", c.Alert.ErrorThreshold)
+	}
+	if c.Alert.Timeout <= 0 {
+		return errors.New("ALERT_TIMEOUT must be greater than zero")
+	}
+	return nil
+}
+
+// GetDSN monta a string de conexão com o PostgreSQL
+func (d DatabaseConfig) GetDSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
+}
@@ -0,0 +1,97 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrNFeNotFound é retornado quando a NFe não existe no banco
+	ErrNFeNotFound = errors.New("nfe not found")
+
+	// ErrNFeAmbiguous é retornado quando mais de uma NFe atende à busca por emitente, número e série
+	ErrNFeAmbiguous = errors.New("more than one nfe matches the lookup")
+
+	// ErrNFeAlreadyExists é retornado quando a chave de acesso já foi cadastrada
+	ErrNFeAlreadyExists = errors.New("nfe already exists")
+
+	// ErrInvalidStatus é retornado quando o status informado não é válido
+	ErrInvalidStatus = errors.New("invalid nfe status")
+
+	// ErrInvalidOrigem é retornado quando a origem informada não é emitida, recebida nem importada
+	ErrInvalidOrigem = errors.New("invalid nfe origem")
+
+	// ErrXMLUnavailable é retornado quando a SEFAZ não disponibiliza mais o XML da NFe
+	ErrXMLUnavailable = errors.New("nfe xml no longer available at sefaz")
+
+	// ErrNFeNotFoundAtSefaz é retornado quando a consulta protocolo não encontra a chave na SEFAZ autorizadora
+	ErrNFeNotFoundAtSefaz = errors.New("nfe not found at sefaz")
+
+	// ErrFullXMLUnavailable é retornado quando a NFe tem armazenado apenas o evento de cancelamento, sem o XML autorizado
+	ErrFullXMLUnavailable = errors.New("only the nfe cancellation event is stored")
+
+	// ErrXMLNotStored é retornado quando a NFe não possui XML armazenado (ex: rejeitada)
+	ErrXMLNotStored = errors.New("nfe xml not stored")
+
+	// ErrProtocoloUnavailable é retornado quando o XML armazenado não traz o protNFe e a NFe não tem autorização cadastrada para remontá-lo
+	ErrProtocoloUnavailable = errors.New("nfe has no authorization protocol to build nfeProc")
+
+	// ErrInvalidXML é retornado quando o XML da NFe não pode ser interpretado
+	ErrInvalidXML = errors.New("invalid nfe xml")
+
+	// ErrXMLRejected é retornado quando o XML importado não passa na validação
+	ErrXMLRejected = errors.New("nfe xml failed validation")
+
+	// ErrSyncJobNotFound é retornado quando ainda não há sincronização registrada
+	ErrSyncJobNotFound = errors.New("sync job not found")
+
+	// ErrSyncLimitReached é retornado quando o limite de sincronizações simultâneas já foi atingido
+	ErrSyncLimitReached = errors.New("maximum number of concurrent sync jobs reached")
+
+	// ErrRetryBudgetExhausted é retornado quando a sincronização esgota o total de novas tentativas permitido
+	ErrRetryBudgetExhausted = errors.New("sync retry budget exhausted")
+
+	// ErrCertificateExpired é retornado quando o certificado digital está vencido ou ainda não é válido
+	ErrCertificateExpired = errors.New("certificate is expired or not yet valid")
+
+	// ErrCertificateCNPJMismatch é retornado quando o certificado digital pertence a outro CNPJ
+	ErrCertificateCNPJMismatch = errors.New("certificate does not belong to the configured cnpj")
+
+	// ErrInutilizacaoRejeitada é retornado quando a SEFAZ recusa o pedido de inutilização
+	ErrInutilizacaoRejeitada = errors.New("inutilizacao rejected by sefaz")
+
+	// ErrCompanyNotConfigured é retornado quando o CNPJ informado não está entre os sincronizados
+	ErrCompanyNotConfigured = errors.New("cnpj is not configured")
+
+	// ErrExportTooLarge é retornado quando o filtro de uma exportação excede o limite de NFes
+	ErrExportTooLarge = errors.New("export exceeds the maximum number of nfes")
+
+	// ErrRetentionDisabled é retornado quando a limpeza é solicitada sem prazo de retenção configurado
+	ErrRetentionDisabled = errors.New("xml retention is not configured")
+
+	// ErrReprocessJobNotFound é retornado quando o job de reprocessamento não existe
+	ErrReprocessJobNotFound = errors.New("reprocess job not found")
+
+	// ErrReprocessRunning é retornado quando um reprocessamento já está em andamento
+	ErrReprocessRunning = errors.New("a reprocess job is already running")
+
+	// ErrJobRetentionDisabled é retornado quando a limpeza do histórico de sincronizações é solicitada sem prazo configurado
+	ErrJobRetentionDisabled = errors.New("sync job retention is not configured")
+
+	// ErrSelfCheckNotRun é retornado quando a verificação de inicialização ainda não foi executada
+	ErrSelfCheckNotRun = errors.New("startup self-check has not run")
+)
+
+// CancelledXMLError é retornado pelo download quando a NFe foi cancelada antes
+// de o XML autorizado ser baixado e a SEFAZ entrega apenas o evento de
+// cancelamento (procEventoNFe). Continua identificável por
+// errors.Is(err, ErrXMLUnavailable).
+type CancelledXMLError struct {
+	EventXML []byte
+}
+
+func (e *CancelledXMLError) Error() string {
+	return "only the nfe cancellation event is available at sefaz"
+}
+
+// Unwrap permite tratar o erro como ErrXMLUnavailable
+func (e *CancelledXMLError) Unwrap() error {
+	return ErrXMLUnavailable
+}
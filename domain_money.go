@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney é retornado quando um valor monetário não pode ser interpretado
+var ErrInvalidMoney = errors.New("invalid monetary value")
+
+// Money representa um valor monetário em centavos, sem os arredondamentos de
+// float64. É serializado em JSON como string com duas casas decimais
+// ("1500.50") e gravado no banco como NUMERIC(15, 2).
+type Money int64
+
+// ParseMoney interpreta um valor decimal ("1500.5", "-3.10", "42"). Casas além
+// dos centavos são arredondadas para o centavo mais próximo (metade para longe do zero).
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty value", ErrInvalidMoney)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+			}
+		}
+	}
+
+	var reais int64
+	if intPart != "" {
+		var err error
+		if reais, err = strconv.ParseInt(intPart, 10, 64); err != nil || reais > math.MaxInt64/100-1 {
+			return 0, fmt.Errorf("%w: %q out of range", ErrInvalidMoney, s)
+		}
+	}
+
+	// Centavos a partir das duas primeiras casas; a terceira decide o arredondamento
+	frac := fracPart + "000"
+	centavos := int64(frac[0]-'0')*10 + int64(frac[1]-'0')
+	if frac[2] >= '5' {
+		centavos++
+	}
+
+	m := Money(reais*100 + centavos)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// MoneyFromFloat converte um float64 arredondando para o centavo mais próximo
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * 100))
+}
+
+// Add retorna a soma exata de m e o
+func (m Money) Add(o Money) Money {
+	return m + o
+}
+
+// Sub retorna a diferença exata entre m e o
+func (m Money) Sub(o Money) Money {
+	return m - o
+}
+
+// Abs retorna o valor absoluto
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// SumMoney soma os valores informados sem perda de precisão
+func SumMoney(values ...Money) Money {
+	var total Money
+	for _, v := range values {
+		total = total.Add(v)
+	}
+	return total
+}
+
+// Float64 retorna o valor como float64, para cálculos que não exigem exatidão
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String formata o valor com duas casas decimais (ex: 1500.50)
+func (m Money) String() string {
+	sign := ""
+	abs := int64(m)
+	if abs < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// MarshalJSON serializa o valor como string com duas casas decimais
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON aceita o valor como string ("1500.50") ou número (1500.50)
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		*m = 0
+		return nil
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implementa sql.Scanner para colunas NUMERIC
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		*m = MoneyFromFloat(v)
+		return nil
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidMoney, src)
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implementa driver.Valuer, enviando o valor como texto decimal exato
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"1500.50":  150050,
+		"1500.5":   150050,
+		"42":       4200,
+		"0.01":     1,
+		"-3.10":    -310,
+		"10.005":   1001,
+		"10.0049":  1000,
+		".99":      99,
+		"  7.25  ": 725,
+	}
+	for input, expected := range cases {
+		m, err := ParseMoney(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, m, input)
+	}
+
+	for _, input := range []string{"", "abc", "1,50", "1e3", "-"} {
+		_, err := ParseMoney(input)
+		assert.ErrorIs(t, err, ErrInvalidMoney, input)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Valor Money `json:"valor"`
+	}{Valor: 150050})
+	require.NoError(t, err)
+	assert.Equal(t, `{"valor":"1500.50"}`, string(data))
+
+	var decoded struct {
+		A Money `json:"a"`
+		B Money `json:"b"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"-0.05","b":1500.499999}`), &decoded))
+	assert.Equal(t, Money(-5), decoded.A)
+	assert.Equal(t, Money(150050), decoded.B)
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 em float64 resulta em 0.30000000000000004
+	assert.Equal(t, "0.30", Money(10).Add(20).String())
+	assert.Equal(t, Money(5), Money(10).Sub(15).Abs())
+
+	values := make([]Money, 10000)
+	for i := range values {
+		values[i] = 1 // R$ 0,01
+	}
+	assert.Equal(t, "100.00", SumMoney(values...).String())
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m Money
+	require.NoError(t, m.Scan([]byte("1500.50")))
+	assert.Equal(t, Money(150050), m)
+
+	require.NoError(t, m.Scan(1500.499999))
+	assert.Equal(t, Money(150050), m)
+
+	value, err := m.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "1500.50", value)
+}
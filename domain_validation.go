@@ -0,0 +1,49 @@
+package domain
+
+import "strings"
+
+// FieldError descreve um campo inválido de uma requisição
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+// ValidationError agrega todos os erros de validação de uma requisição, para
+// que o cliente corrija todos os campos de uma vez
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add registra um campo inválido. err é o erro sentinela correspondente, se houver.
+func (e *ValidationError) Add(field, message string, err error) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message, Err: err})
+}
+
+// Err retorna o próprio erro quando há campos inválidos ou nil caso contrário
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error implementa a interface error
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap permite errors.Is com os erros sentinela dos campos (ex: ErrInvalidStatus)
+func (e *ValidationError) Unwrap() []error {
+	errs := []error{}
+	for _, f := range e.Fields {
+		if f.Err != nil {
+			errs = append(errs, f.Err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ListEmitentes lista os emitentes distintos das NFes armazenadas
+// @Summary Listar emitentes
+// @Description Lista os emitentes distintos com a quantidade e o valor total das suas NFes, ordenados por nome
+// @Tags Emitentes
+// @Produce json
+// @Param search query string false "Parte do nome ou início do CNPJ do emitente"
+// @Param page query int false "Número da página" default(1)
+// @Param limit query int false "Itens por página" default(20)
+// @Success 200 {object} domain.PaginatedResponse[domain.Emitente]
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/emitentes [get]
+func (h *NFeHandler) ListEmitentes(w http.ResponseWriter, r *http.Request) {
+	filter := domain.EmitenteFilter{
+		Search: r.URL.Query().Get("search"),
+	}
+	if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+		filter.Page = page
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	response, err := h.service.ListEmitentes(filter)
+	if err != nil {
+		h.logger.Error("Erro ao listar emitentes", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao listar emitentes", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// GetNumeroGaps lista as lacunas na numeração das NFes de um emitente
+// @Summary Lacunas de numeração
+// @Description Lista as faixas de números ausentes entre as NFes armazenadas do emitente na série, que podem ser NFes não sincronizadas. Faixas cobertas por inutilizações homologadas vêm com inutilizada=true.
+// @Tags Emitentes
+// @Produce json
+// @Param cnpj path string true "CNPJ do emitente"
+// @Param serie query string true "Série das NFes"
+// @Success 200 {object} domain.NumeroGaps
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/emitentes/{cnpj}/gaps [get]
+func (h *NFeHandler) GetNumeroGaps(w http.ResponseWriter, r *http.Request) {
+	filter := domain.NumeroGapFilter{
+		CNPJ:  chi.URLParam(r, "cnpj"),
+		Serie: r.URL.Query().Get("serie"),
+	}
+
+	gaps, err := h.service.GetNumeroGaps(filter)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Filtro inválido", err)
+			return
+		}
+		h.logger.Error("Erro ao buscar lacunas de numeração", "cnpj", filter.CNPJ, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar lacunas de numeração", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, gaps)
+}
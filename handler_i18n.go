@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+const (
+	langPtBR = "pt-BR"
+	langEn   = "en"
+
+	// defaultLanguage é usado quando o Accept-Language está ausente ou não é suportado
+	defaultLanguage = langPtBR
+)
+
+// messagesEn traduz as mensagens de erro da API (escritas em pt-BR) para inglês.
+// Mensagens no formato "prefixo: valor" são traduzidas pelo prefixo.
+var messagesEn = map[string]string{
+	"Arquivo XML obrigatório no campo file":                       "XML file is required in the file field",
+	"Busca inválida":                                              "Invalid lookup",
+	"Campo desconhecido":                                          "Unknown field",
+	"Campos de reprocessamento inválidos":                         "Invalid reprocess fields",
+	"Certificado não pertence ao CNPJ configurado":                "Certificate does not belong to the configured CNPJ",
+	"Certificado vencido ou ainda não válido":                     "Certificate is expired or not yet valid",
+	"Chave de acesso inválida":                                    "Invalid access key",
+	"Corpo da requisição é obrigatório":                           "Request body is required",
+	"Corpo da requisição excede o tamanho máximo permitido":       "Request body exceeds the maximum allowed size",
+	"Correção inválida":                                           "Invalid correction",
+	"Erro ao buscar NFe":                                          "Failed to fetch NFe",
+	"Erro ao buscar XML":                                          "Failed to fetch XML",
+	"Erro ao buscar erros da sincronização":                       "Failed to fetch sync errors",
+	"Erro ao buscar estatísticas":                                 "Failed to fetch statistics",
+	"Erro ao buscar lacunas de numeração":                         "Failed to fetch numbering gaps",
+	"Erro ao buscar referências da NFe":                           "Failed to fetch NFe references",
+	"Erro ao buscar reprocessamento":                              "Failed to fetch reprocess job",
+	"Erro ao consultar protocolo da NFe":                          "Failed to query the NFe protocol",
+	"Erro ao consultar saúde da sincronização":                    "Failed to fetch sync health",
+	"Erro ao consultar versão":                                    "Failed to fetch version",
+	"Erro ao contar NFes":                                         "Failed to count NFes",
+	"Erro ao corrigir NFe":                                        "Failed to correct NFe",
+	"Erro ao exportar XMLs":                                       "Failed to export XMLs",
+	"Erro ao exportar movimentações de estoque":                   "Failed to export inventory movements",
+	"Erro ao manifestar NFes":                                     "Failed to send NFe manifestations",
+	"Erro ao gerar relatório de estatísticas":                     "Failed to generate statistics report",
+	"Erro ao importar XML":                                        "Failed to import XML",
+	"Erro ao iniciar reprocessamento":                             "Failed to start reprocess job",
+	"Erro ao inutilizar numeração":                                "Failed to inutilize numbers",
+	"Erro ao ler corpo da requisição":                             "Failed to read request body",
+	"Erro ao limpar armazenamento":                                "Failed to clean up storage",
+	"Erro ao listar emitentes":                                    "Failed to list emitentes",
+	"Erro ao listar NFes":                                         "Failed to list NFes",
+	"Erro ao listar NFes em quarentena":                           "Failed to list quarantined NFes",
+	"Erro ao listar NFes incompletas":                             "Failed to list incomplete NFes",
+	"Erro ao montar nfeProc":                                      "Failed to build nfeProc",
+	"Erro ao recarregar certificados":                             "Failed to reload certificates",
+	"Erro ao reparar armazenamento":                               "Failed to repair storage",
+	"Erro ao registrar chave de idempotência":                     "Failed to register idempotency key",
+	"Erro ao sincronizar NFes":                                    "Failed to sync NFes",
+	"Erro ao sincronizar status das NFes":                         "Failed to sync NFe statuses",
+	"Erro ao validar XML":                                         "Failed to validate XML",
+	"Erro ao verificar NFe":                                       "Failed to verify NFe",
+	"Erro ao verificar consistência do armazenamento":             "Failed to check storage consistency",
+	"Erro interno ao processar a requisição":                      "Internal error while processing the request",
+	"Exportação excede o limite de NFes, reduza o período":        "Export exceeds the NFe limit, narrow the period",
+	"Filtro inválido":                                             "Invalid filter",
+	"Formato de data inválido para end_date":                      "Invalid date format for end_date",
+	"Formato de data inválido para start_date":                    "Invalid date format for start_date",
+	"ID de reprocessamento inválido":                              "Invalid reprocess ID",
+	"ID de sincronização inválido":                                "Invalid sync ID",
+	"Idempotency-Key deve ter até 255 caracteres":                 "Idempotency-Key must have up to 255 characters",
+	"Idempotency-Key já usado em outra requisição":                "Idempotency-Key already used for a different request",
+	"Inutilização rejeitada pela SEFAZ":                           "Inutilização rejected by SEFAZ",
+	"JSON inválido no corpo da requisição":                        "Invalid JSON in request body",
+	"Limite de sincronizações simultâneas atingido":               "Maximum number of concurrent syncs reached",
+	"Mais de uma NFe encontrada para o número e série":            "More than one NFe matches the number and series",
+	"NFe já cadastrada":                                           "NFe already exists",
+	"NFe não encontrada":                                          "NFe not found",
+	"NFe não encontrada na SEFAZ":                                 "NFe not found at SEFAZ",
+	"NFe não possui XML armazenado":                               "NFe has no stored XML",
+	"NFe possui apenas o evento de cancelamento":                  "NFe only has the cancellation event",
+	"NFe sem protocolo de autorização para montar o nfeProc":      "NFe has no authorization protocol to build nfeProc",
+	"Pedido de inutilização inválido":                             "Invalid inutilização request",
+	"Pedido de manifestação inválido":                             "Invalid manifestation request",
+	"Prazo de retenção de XMLs não configurado":                   "XML retention period is not configured",
+	"Requisição com este Idempotency-Key ainda em processamento":  "A request with this Idempotency-Key is still being processed",
+	"Reprocessamento já em andamento":                             "A reprocess job is already running",
+	"Reprocessamento não encontrado":                              "Reprocess job not found",
+	"Sincronização não encontrada":                                "Sync not found",
+	"Valor inválido para dry_run":                                 "Invalid value for dry_run",
+	"Valor inválido para max_age":                                 "Invalid value for max_age",
+	"Verificação de inicialização não executada":                  "Startup self-check has not run",
+	"XML inválido":                                                "Invalid XML",
+	"XML não passou na validação":                                 "XML failed validation",
+	"end_date obrigatório no formato YYYY-MM-DD":                  "end_date is required in YYYY-MM-DD format",
+	"start_date e end_date são obrigatórios":                      "start_date and end_date are required",
+	"start_date obrigatório no formato YYYY-MM-DD":                "start_date is required in YYYY-MM-DD format",
+	"campo de reprocessamento inválido":                           "invalid reprocess field",
+	"chave de acesso inválida":                                    "invalid access key",
+	"cnpj deve ter 14 dígitos":                                    "cnpj must have 14 digits",
+	"cnpj não configurado":                                        "cnpj is not configured",
+	"crt inválido":                                                "invalid crt",
+	"informe ao menos um campo de reprocessamento":                "provide at least one reprocess field",
+	"informe ao menos um campo para corrigir":                     "provide at least one field to correct",
+	"informe entre 1 e 500 chaves de acesso":                      "provide between 1 and 500 access keys",
+	"justificativa deve ter entre 15 e 255 caracteres":            "justificativa must have between 15 and 255 characters",
+	"modelo deve ser 55 ou 65":                                    "modelo must be 55 or 65",
+	"nome_emitente deve ter entre 1 e 255 caracteres":             "nome_emitente must have between 1 and 255 characters",
+	"numero deve ter até 9 dígitos":                               "numero must have up to 9 digits",
+	"numero obrigatório com até 9 dígitos":                        "numero is required with up to 9 digits",
+	"numero_final deve estar entre 1 e 999999999":                 "numero_final must be between 1 and 999999999",
+	"numero_final deve ser igual ou maior que numero_inicial":     "numero_final must be equal to or greater than numero_inicial",
+	"numero_inicial deve estar entre 1 e 999999999":               "numero_inicial must be between 1 and 999999999",
+	"projection deve ser full ou summary":                         "projection must be full or summary",
+	"serie deve estar entre 0 e 999":                              "serie must be between 0 and 999",
+	"serie deve ter até 3 dígitos":                                "serie must have up to 3 digits",
+	"serie obrigatória com até 3 dígitos":                         "serie is required with up to 3 digits",
+	"status inválido":                                             "invalid status",
+	"tipo de manifestação inválido":                               "invalid manifestation type",
+	"tipo_operacao deve ser entrada ou saida":                     "tipo_operacao must be entrada or saida",
+	"uf_emitente deve ser a sigla da UF (ex: SP)":                 "uf_emitente must be the UF abbreviation (e.g. SP)",
+	"origem deve ser emitida, recebida ou importada":              "origem must be emitida, recebida or importada",
+	"end_date deve ser igual ou posterior a start_date":           "end_date must be equal to or after start_date",
+	"auth_end_date deve ser igual ou posterior a auth_start_date": "auth_end_date must be equal to or after auth_start_date",
+}
+
+// catalogs mapeia cada idioma suportado além do padrão para suas traduções
+var catalogs = map[string]map[string]string{
+	langEn: messagesEn,
+}
+
+// preferredLanguage escolhe o idioma da resposta a partir do Accept-Language,
+// respeitando os pesos q. Retorna pt-BR quando nenhum idioma suportado é aceito.
+func preferredLanguage(r *http.Request) string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	candidates := []weighted{}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if lang := supportedLanguage(tag); lang != "" && q > 0 {
+			candidates = append(candidates, weighted{lang: lang, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return defaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// supportedLanguage normaliza uma tag de idioma (ex: en-US, pt) para um
+// idioma do catálogo ou retorna vazio se não for suportada
+func supportedLanguage(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case "pt":
+		return langPtBR
+	case "en":
+		return langEn
+	}
+	return ""
+}
+
+// translate retorna a mensagem no idioma informado, mantendo o texto original
+// quando não há tradução
+func translate(lang, message string) string {
+	catalog, ok := catalogs[lang]
+	if !ok {
+		return message
+	}
+	if translated, ok := catalog[message]; ok {
+		return translated
+	}
+	if prefix, value, ok := strings.Cut(message, ": "); ok {
+		if translated, ok := catalog[prefix]; ok {
+			return translated + ": " + value
+		}
+	}
+	return message
+}
+
+// translateFields traduz as mensagens dos campos inválidos de uma validação
+func translateFields(lang string, fields []domain.FieldError) []domain.FieldError {
+	translated := make([]domain.FieldError, len(fields))
+	for i, f := range fields {
+		f.Message = translate(lang, f.Message)
+		translated[i] = f
+	}
+	return translated
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+const (
+	// idempotencyKeyHeader é o cabeçalho que identifica repetições de uma requisição
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	// idempotentReplayedHeader marca as respostas devolvidas a partir de uma requisição anterior
+	idempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength é o tamanho máximo aceito para a chave
+	maxIdempotencyKeyLength = 255
+)
+
+// responseRecorder repassa a resposta ao cliente e guarda uma cópia para o
+// registro de idempotência
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader guarda o status antes de enviá-lo
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write guarda uma cópia do corpo antes de enviá-lo
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotent faz com que repetições de uma requisição com o mesmo
+// Idempotency-Key recebam a resposta original em vez de executá-la de novo.
+// Requisições sem o cabeçalho, ou com as chaves desativadas, seguem direto.
+// Toda resposta é gravada, inclusive 5xx: uma falha pode ocorrer depois do
+// envio à SEFAZ, então a repetição recebe a mesma falha e uma nova tentativa
+// exige outra chave. Um pânico no handler grava um 500 antes de seguir para o
+// middleware de recuperação, para que a chave não fique em processamento.
+func (h *NFeHandler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || h.idempotency == nil {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			h.sendError(w, r, http.StatusBadRequest, "Idempotency-Key deve ter até 255 caracteres", nil)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				h.sendError(w, r, http.StatusRequestEntityTooLarge, "Corpo da requisição excede o tamanho máximo permitido", err)
+				return
+			}
+			h.sendError(w, r, http.StatusBadRequest, "Erro ao ler corpo da requisição", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &domain.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: requestFingerprint(r, body),
+			CreatedAt:   now,
+		}
+
+		existing, err := h.idempotency.Reserve(record, now.Add(-h.idempotencyTTL))
+		if err != nil {
+			h.logger.Error("Erro ao registrar chave de idempotência", "key", key, "error", err)
+			h.sendError(w, r, http.StatusInternalServerError, "Erro ao registrar chave de idempotência", err)
+			return
+		}
+		if existing != nil {
+			h.replay(w, r, record, existing)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p != nil {
+				h.logger.Error("Pânico ao processar requisição com chave de idempotência", "key", key, "panic", p)
+				record.StatusCode = http.StatusInternalServerError
+				record.ContentType = "application/json"
+				record.Body = panicResponse(r)
+			} else {
+				record.StatusCode = recorder.status
+				if record.StatusCode == 0 {
+					record.StatusCode = http.StatusOK
+				}
+				record.ContentType = recorder.Header().Get("Content-Type")
+				record.Body = recorder.body.Bytes()
+			}
+
+			completedAt := time.Now()
+			record.CompletedAt = &completedAt
+			if err := h.idempotency.Complete(record); err != nil {
+				h.logger.Warn("Erro ao gravar resposta da chave de idempotência", "key", key, "error", err)
+			}
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next(recorder, r)
+	}
+}
+
+// panicResponse monta o corpo gravado para as repetições de uma requisição
+// interrompida por pânico
+func panicResponse(r *http.Request) []byte {
+	body, _ := json.Marshal(ErrorResponse{
+		Error:   http.StatusText(http.StatusInternalServerError),
+		Message: translate(preferredLanguage(r), "Erro interno ao processar a requisição"),
+	})
+	return body
+}
+
+// replay responde a uma repetição com a resposta da requisição original
+func (h *NFeHandler) replay(w http.ResponseWriter, r *http.Request, record, existing *domain.IdempotencyRecord) {
+	switch {
+	case existing.Fingerprint != record.Fingerprint:
+		h.sendError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key já usado em outra requisição", nil)
+	case !existing.Completed():
+		h.sendError(w, r, http.StatusConflict, "Requisição com este Idempotency-Key ainda em processamento", nil)
+	default:
+		h.logger.Info("Resposta repetida por chave de idempotência", "key", record.Key, "path", r.URL.Path)
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		w.Header().Set(idempotentReplayedHeader, "true")
+		w.WriteHeader(existing.StatusCode)
+		w.Write(existing.Body)
+	}
+}
+
+// requestFingerprint identifica o método, o caminho e o corpo da requisição
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
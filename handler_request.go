@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LimitBody limita o tamanho do corpo de todas as requisições. Leituras além
+// do limite falham com *http.MaxBytesError.
+func LimitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeJSON decodifica o corpo JSON da requisição em dst, rejeitando campos
+// desconhecidos (ex: erros de digitação) e conteúdo após o objeto. Em caso de
+// falha a resposta de erro já é enviada e o retorno é false.
+func (h *NFeHandler) decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == nil && decoder.More() {
+		err = errors.New("body must contain a single JSON object")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		h.sendError(w, r, http.StatusRequestEntityTooLarge, "Corpo da requisição excede o tamanho máximo permitido", err)
+	case errors.Is(err, io.EOF):
+		h.sendError(w, r, http.StatusBadRequest, "Corpo da requisição é obrigatório", nil)
+	case unknownField(err) != "":
+		h.sendError(w, r, http.StatusBadRequest, fmt.Sprintf("Campo desconhecido: %s", unknownField(err)), err)
+	default:
+		h.sendError(w, r, http.StatusBadRequest, "JSON inválido no corpo da requisição", err)
+	}
+	return false
+}
+
+// unknownField extrai o nome do campo de um erro de campo desconhecido do
+// encoding/json, que não possui tipo próprio (json: unknown field "x")
+func unknownField(err error) string {
+	const prefix = "json: unknown field "
+	msg := err.Error()
+	if !strings.HasPrefix(msg, prefix) {
+		return ""
+	}
+	return strings.Trim(strings.TrimPrefix(msg, prefix), `"`)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// InutilizarNumeracao inutiliza na SEFAZ uma faixa de números não utilizados
+// e grava o resultado. Pedidos rejeitados também são gravados e retornam o
+// registro junto com domain.ErrInutilizacaoRejeitada.
+func (s *nfeService) InutilizarNumeracao(req domain.InutilizacaoRequest) (*domain.Inutilizacao, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	company, err := s.companyByCNPJ(req.CNPJ)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Enviando pedido de inutilização à SEFAZ",
+		"cnpj", company.CNPJ,
+		"serie", req.Serie,
+		"numero_inicial", req.NumeroInicial,
+		"numero_final", req.NumeroFinal,
+	)
+
+	inutilizacao, err := company.Client.InutilizarNumeracao(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inutilize numbers: %w", err)
+	}
+
+	inutilizacao.ID = uuid.New()
+	inutilizacao.CreatedAt = time.Now()
+	if err := s.inutRepo.Save(inutilizacao); err != nil {
+		// A inutilização já foi processada pela SEFAZ; o protocolo fica no log
+		s.logger.Error("Erro ao gravar inutilização",
+			"cnpj", inutilizacao.CNPJ,
+			"status", inutilizacao.Status,
+			"protocolo", inutilizacao.Protocolo,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	if inutilizacao.Status != domain.InutilizacaoHomologada {
+		s.logger.Warn("Inutilização rejeitada pela SEFAZ",
+			"cnpj", inutilizacao.CNPJ,
+			"cstat", inutilizacao.CStat,
+			"motivo", inutilizacao.Motivo,
+		)
+		return inutilizacao, fmt.Errorf("%w: cStat %d: %s", domain.ErrInutilizacaoRejeitada, inutilizacao.CStat, inutilizacao.Motivo)
+	}
+
+	s.logger.Info("Inutilização homologada", "cnpj", inutilizacao.CNPJ, "protocolo", inutilizacao.Protocolo)
+	return inutilizacao, nil
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// InutilizarNumeracao inutiliza uma faixa de números de NFe não utilizados
+// @Summary Inutilizar numeração
+// @Description Envia à SEFAZ o pedido de inutilização de uma faixa de números da série (modelo 55) e grava o resultado
+// @Tags NFe
+// @Accept json
+// @Produce json
+// @Param request body domain.InutilizacaoRequest true "Série, faixa de números e justificativa"
+// @Success 201 {object} domain.Inutilizacao
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/nfe/inutilizar [post]
+func (h *NFeHandler) InutilizarNumeracao(w http.ResponseWriter, r *http.Request) {
+	var req domain.InutilizacaoRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	inutilizacao, err := h.service.InutilizarNumeracao(req)
+	if err != nil {
+		switch {
+		case isValidationError(err):
+			h.sendError(w, r, http.StatusBadRequest, "Pedido de inutilização inválido", err)
+		case errors.Is(err, domain.ErrInutilizacaoRejeitada):
+			h.sendError(w, r, http.StatusUnprocessableEntity, "Inutilização rejeitada pela SEFAZ", err)
+		default:
+			h.logger.Error("Erro ao inutilizar numeração", "error", err)
+			h.sendError(w, r, sefazErrorStatus(err), "Erro ao inutilizar numeração", err)
+		}
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, inutilizacao)
+}
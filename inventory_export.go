@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// inventoryPageSize é o tamanho da página usada para percorrer as NFes na exportação
+const inventoryPageSize = 100
+
+// ExportInventoryMovements gera as movimentações de estoque dos itens das NFes
+// autorizadas que atendem ao filtro, lendo os itens dos XMLs armazenados
+func (s *nfeService) ExportInventoryMovements(filter domain.NFeFilter) ([]domain.InventoryMovement, error) {
+	// NFes canceladas, denegadas ou rejeitadas não movimentam estoque
+	filter.Status = domain.NFeStatusAutorizada
+	filter.Limit = inventoryPageSize
+	filter.Page = 1
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	movements := []domain.InventoryMovement{}
+	for {
+		nfes, total, err := s.repo.FindByFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		// O limite é verificado na primeira página, antes de ler qualquer XML
+		if filter.Page == 1 && s.maxExportRows > 0 && total > int64(s.maxExportRows) {
+			return nil, fmt.Errorf("%w: %d nfes, limit %d", domain.ErrExportTooLarge, total, s.maxExportRows)
+		}
+
+		for i := range nfes {
+			nfeMovements, err := s.inventoryMovements(&nfes[i])
+			if err != nil {
+				return nil, fmt.Errorf("nfe %s: %w", nfes[i].ChaveAcesso, err)
+			}
+			movements = append(movements, nfeMovements...)
+		}
+
+		if int64(filter.Page*filter.Limit) >= total || len(nfes) == 0 {
+			break
+		}
+		filter.Page++
+	}
+
+	s.logger.Info("Exportação de movimentações de estoque concluída", "movimentacoes", len(movements))
+
+	return movements, nil
+}
+
+// inventoryMovements lê os itens do XML da NFe e os converte em movimentações
+func (s *nfeService) inventoryMovements(nfe *domain.NFe) ([]domain.InventoryMovement, error) {
+	data, err := s.readStoredXML(nfe)
+	if err != nil {
+		return nil, err
+	}
+
+	var proc nfeProcXML
+	if err := xml.Unmarshal(data, &proc); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+	}
+
+	movements := make([]domain.InventoryMovement, 0, len(proc.NFe.InfNFe.Det))
+	for _, det := range proc.NFe.InfNFe.Det {
+		direcao, ok := movementDirection(det.Prod.CFOP, nfe.Origem)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid CFOP %q", domain.ErrInvalidXML, det.Prod.CFOP)
+		}
+
+		item, _ := strconv.Atoi(det.NItem)
+		quantidade, err := strconv.ParseFloat(det.Prod.QCom, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid qCom %q", domain.ErrInvalidXML, det.Prod.QCom)
+		}
+		valor, _ := domain.ParseMoney(det.Prod.VProd)
+
+		movements = append(movements, domain.InventoryMovement{
+			ChaveAcesso:   nfe.ChaveAcesso,
+			Numero:        nfe.Numero,
+			DataEmissao:   nfe.DataEmissao,
+			Origem:        nfe.Origem,
+			Item:          item,
+			CodigoProduto: det.Prod.CProd,
+			Descricao:     det.Prod.XProd,
+			NCM:           det.Prod.NCM,
+			CFOP:          det.Prod.CFOP,
+			Unidade:       det.Prod.UCom,
+			Quantidade:    quantidade,
+			Valor:         valor,
+			Direcao:       direcao,
+		})
+	}
+
+	return movements, nil
+}
+
+// movementDirection determina a direção da movimentação para o CNPJ configurado.
+// O CFOP descreve a operação do ponto de vista do emitente (1, 2 e 3 entradas;
+// 5, 6 e 7 saídas), então a direção é invertida nas NFes recebidas de terceiros.
+func movementDirection(cfop string, origem domain.NFeOrigem) (domain.MovementDirection, bool) {
+	if len(cfop) != 4 {
+		return "", false
+	}
+
+	var emitente domain.MovementDirection
+	switch cfop[0] {
+	case '1', '2', '3':
+		emitente = domain.MovementEntrada
+	case '5', '6', '7':
+		emitente = domain.MovementSaida
+	default:
+		return "", false
+	}
+
+	if origem == domain.NFeOrigemEmitida {
+		return emitente, true
+	}
+	if emitente == domain.MovementEntrada {
+		return domain.MovementSaida, true
+	}
+	return domain.MovementEntrada, true
+}
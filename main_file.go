@@ -2,12 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	// Embute a base de fusos horários, usada pelas janelas de manutenção em
+	// imagens sem tzdata
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -15,18 +20,33 @@ import (
 	"github.com/robfig/cron/v3"
 
 	"nfe-sefaz-sync/configs"
+	"nfe-sefaz-sync/internal/domain"
 	"nfe-sefaz-sync/internal/handler"
 	"nfe-sefaz-sync/internal/repository"
+	"nfe-sefaz-sync/internal/sefaz"
 	"nfe-sefaz-sync/internal/service"
 	"nfe-sefaz-sync/pkg/certificate"
 	"nfe-sefaz-sync/pkg/database"
 	"nfe-sefaz-sync/pkg/logger"
+	"nfe-sefaz-sync/pkg/xsdvalidator"
+)
+
+// Identificação do binário, gravada na compilação:
+// go build -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
 )
 
 func main() {
 	// Inicializa o logger
 	log := logger.New("info")
-	log.Info("Iniciando aplicação NFe SEFAZ Sync")
+	log.Info("Iniciando aplicação NFe SEFAZ Sync",
+		"version", version,
+		"commit", commit,
+		"build_time", buildTime,
+	)
 
 	// Carrega as configurações
 	cfg, err := configs.LoadConfig()
@@ -45,7 +65,7 @@ func main() {
 	)
 
 	// Conecta ao banco de dados
-	db, err := database.NewPostgresConnection(cfg.Database.GetDSN(), cfg.Database.MaxConnections)
+	db, err := database.NewPostgresConnection(cfg.Database.GetDSN(), cfg.Database.MaxConnections, cfg.Database.ApplicationName)
 	if err != nil {
 		log.Fatal("Erro ao conectar ao banco de dados", "error", err)
 	}
@@ -53,52 +73,260 @@ func main() {
 
 	log.Info("Conectado ao banco de dados com sucesso")
 
-	// Carrega o certificado digital
-	cert, err := certificate.LoadCertificate(cfg.Sefaz.CertPath, cfg.Sefaz.CertPassword)
+	// Verifica se os índices esperados existem
+	missingIndexes, err := repository.MissingIndexes(db, cfg.Database.Schema)
 	if err != nil {
-		log.Fatal("Erro ao carregar certificado", "error", err)
+		log.Warn("Não foi possível verificar os índices da tabela nfes", "error", err)
+	} else if len(missingIndexes) > 0 {
+		log.Warn("Índices ausentes na tabela nfes, consultas de listagem podem ficar lentas",
+			"create_statements", missingIndexes,
+		)
 	}
 
-	log.Info("Certificado carregado com sucesso")
-
-	// Cria o diretório de armazenamento de XMLs se não existir
-	if err := os.MkdirAll(cfg.Storage.XMLPath, 0755); err != nil {
-		log.Fatal("Erro ao criar diretório de armazenamento", "error", err)
+	// Os XMLs ficam em disco ou, sem sistema de arquivos persistente, no banco
+	var xmlStorage domain.XMLStorage
+	if domain.XMLStorageBackend(cfg.Storage.Backend) == domain.XMLStorageDatabase {
+		xmlStorage = repository.NewXMLStorageRepository(db, cfg.Database.Schema)
+	} else {
+		// Cria o diretório de armazenamento de XMLs se não existir
+		if err := os.MkdirAll(cfg.Storage.XMLPath, 0755); err != nil {
+			log.Fatal("Erro ao criar diretório de armazenamento", "error", err)
+		}
+		xmlStorage = service.NewDiskXMLStorage(cfg.Storage.XMLPath, domain.StorageShardBy(cfg.Storage.ShardBy))
 	}
+	log.Info("Armazenamento de XMLs configurado", "backend", xmlStorage.Backend())
 
 	// Inicializa as camadas da aplicação
-	nfeRepository := repository.NewNFeRepository(db)
-	sefazClient := service.NewSefazClient(
-		cfg.Sefaz.Ambiente,
-		cfg.Sefaz.UF,
-		cfg.Sefaz.CNPJ,
-		cert,
-		cfg.Sefaz.Timeout,
-		log,
+	onConflict := domain.ConflictPolicy(cfg.Sync.OnConflict)
+	slowQueryLogger := repository.NewSlowQueryLogger(log, cfg.Database.SlowQueryThreshold)
+	nfeRepository := repository.NewNFeRepository(
+		db,
+		cfg.Database.Schema,
+		onConflict,
+		domain.TimestampSource(cfg.Database.TimestampSource),
+		slowQueryLogger,
 	)
+	nsuCursorRepository := repository.NewNSUCursorRepository(db, cfg.Database.Schema)
+	syncJobRepository := repository.NewSyncJobRepository(db, cfg.Database.Schema)
+	inutilizacaoRepository := repository.NewInutilizacaoRepository(db, cfg.Database.Schema)
+	downloadFailureRepository := repository.NewDownloadFailureRepository(db, cfg.Database.Schema)
+	quarantineRepository := repository.NewQuarantineRepository(db, cfg.Database.Schema)
+	reprocessJobRepository := repository.NewReprocessJobRepository(db, cfg.Database.Schema)
+	schemaVersionRepository := repository.NewSchemaVersionRepository(db, cfg.Database.Schema)
+	var idempotencyRepository domain.IdempotencyRepository
+	if cfg.Server.IdempotencyTTL > 0 {
+		idempotencyRepository = repository.NewIdempotencyRepository(db, cfg.Database.Schema)
+	}
+
+	// O intervalo e o limite de chamadas valem para todos os clientes, evitando
+	// o bloqueio do CNPJ por consultas em excesso
+	sefazThrottle := sefaz.NewThrottle(cfg.Sefaz.RequestDelay)
+	if cfg.Sefaz.RequestDelay > 0 {
+		log.Info("Intervalo mínimo entre chamadas à SEFAZ configurado", "request_delay", cfg.Sefaz.RequestDelay)
+	}
+	sefazRateLimiter := sefaz.NewRateLimiter(cfg.Sefaz.MaxRequestsPerMinute)
+	if cfg.Sefaz.MaxRequestsPerMinute > 0 {
+		log.Info("Limite de chamadas à SEFAZ por minuto configurado", "max_requests_per_minute", cfg.Sefaz.MaxRequestsPerMinute)
+	}
+
+	// Carrega o certificado digital e cria o cliente SEFAZ de cada CNPJ
+	companies := make([]service.Company, 0, len(cfg.Sefaz.Companies))
+	for _, company := range cfg.Sefaz.Companies {
+		cert, err := certificate.LoadCertificate(company.CertPath, company.CertPassword)
+		if err != nil {
+			log.Fatal("Erro ao carregar certificado", "cnpj", company.CNPJ, "error", err)
+		}
+		// Um certificado de outra empresa só aparece como rejeições da SEFAZ
+		if err := certificate.CheckCNPJ(cert.Leaf, company.CNPJ); err != nil {
+			log.Fatal("Certificado não pertence ao CNPJ configurado", "cnpj", company.CNPJ, "cert_path", company.CertPath, "error", err)
+		}
+
+		log.Info("Certificado carregado com sucesso", "cnpj", company.CNPJ)
+
+		sefazClient, err := service.NewSefazClient(
+			cfg.Sefaz.Ambiente,
+			cfg.Sefaz.UF,
+			company.CNPJ,
+			cert,
+			cfg.Sefaz.Timeout,
+			log,
+			service.SefazClientOptions{
+				ProxyURL:            cfg.Sefaz.ProxyURL,
+				MinTLSVersion:       cfg.Sefaz.MinTLSVersion,
+				UserAgent:           cfg.Sefaz.UserAgent,
+				MaxIdleConnsPerHost: cfg.Sefaz.MaxIdleConnsPerHost,
+				KeepAlive:           cfg.Sefaz.KeepAlive,
+				IdleConnTimeout:     cfg.Sefaz.IdleConnTimeout,
+				DFeBatchSize:        cfg.Sefaz.DFeBatchSize,
+				DFeMaxDocsPerRun:    cfg.Sefaz.DFeMaxDocsPerRun,
+				EndpointOverrides:   cfg.Sefaz.EndpointOverrides,
+				ServiceVersions:     cfg.Sefaz.ServiceVersions,
+				ServiceNamespaces:   cfg.Sefaz.ServiceNamespaces,
+				StatusTimeout:       cfg.Sefaz.StatusTimeout,
+				DownloadTimeout:     cfg.Sefaz.DownloadTimeout,
+				ConsultaTimeout:     cfg.Sefaz.ConsultaTimeout,
+				BreakerThreshold:    cfg.Sefaz.BreakerThreshold,
+				BreakerCooldown:     cfg.Sefaz.BreakerCooldown,
+				Throttle:            sefazThrottle,
+				RateLimiter:         sefazRateLimiter,
+			},
+		)
+		if err != nil {
+			log.Fatal("Erro ao criar cliente SEFAZ", "cnpj", company.CNPJ, "error", err)
+		}
+		companies = append(companies, service.Company{
+			CNPJ:         company.CNPJ,
+			CertPath:     company.CertPath,
+			CertPassword: company.CertPassword,
+			Client:       sefazClient,
+		})
+	}
+
+	// Alertas de falha de sincronização são opcionais
+	var syncAlerter domain.SyncAlerter
+	if cfg.Alert.WebhookURL != "" {
+		syncAlerter = service.NewWebhookAlerter(cfg.Alert.WebhookURL, cfg.Alert.ErrorThreshold, cfg.Alert.Timeout, log)
+		log.Info("Alertas de sincronização habilitados", "error_threshold", cfg.Alert.ErrorThreshold)
+	}
+
+	// A validação pelos schemas XSD é opcional
+	var xsdValidator *xsdvalidator.Validator
+	if cfg.Sefaz.XSDPath != "" {
+		xsdValidator, err = xsdvalidator.New(cfg.Sefaz.XSDPath)
+		if err != nil {
+			log.Fatal("Erro ao carregar schemas XSD da NFe", "path", cfg.Sefaz.XSDPath, "error", err)
+		}
+		log.Info("Validação pelos schemas XSD habilitada", "path", cfg.Sefaz.XSDPath)
+	}
+
 	nfeService := service.NewNFeService(
 		nfeRepository,
-		sefazClient,
+		nsuCursorRepository,
+		syncJobRepository,
+		inutilizacaoRepository,
+		downloadFailureRepository,
+		quarantineRepository,
+		reprocessJobRepository,
+		schemaVersionRepository,
+		syncAlerter,
+		companies,
+		cfg.Sefaz.Ambiente,
 		cfg.Storage.XMLPath,
+		xmlStorage,
+		xsdValidator,
+		onConflict,
+		domain.ItemConflictStrategy(cfg.Sync.ItemsOnConflict),
+		domain.CancelledWithoutXMLPolicy(cfg.Sync.CancelledWithoutXML),
+		service.StorageRetention{
+			Years:       cfg.Storage.RetentionYears,
+			BackupPath:  cfg.Storage.BackupPath,
+			ArchivePath: cfg.Storage.ArchivePath,
+		},
+		cfg.Sync.JobRetentionDays,
+		domain.MoneyFromFloat(cfg.Sync.ValueTolerance),
+		cfg.Sync.ParseConcurrency,
+		cfg.Sync.MaxConcurrentJobs,
+		cfg.Sync.MaxTotalRetries,
+		cfg.Server.MaxExportRows,
+		domain.BuildInfo{Version: version, Commit: commit, BuildTime: buildTime},
 		log,
 	)
 
-	// Configura o scheduler de sincronização
+	// Verifica as dependências antes de iniciar o scheduler e o servidor; o
+	// resultado fica disponível em /api/v1/admin/selfcheck
+	if selfCheck := nfeService.RunSelfCheck(); selfCheck.Status == domain.SelfCheckFail {
+		log.Fatal("Verificação de inicialização falhou", "failed_checks", selfCheck.Failed())
+	}
+
+	maintenanceLocation, err := time.LoadLocation(cfg.Sefaz.MaintenanceTimezone)
+	if err != nil {
+		log.Fatal("Fuso das janelas de manutenção da SEFAZ inválido", "timezone", cfg.Sefaz.MaintenanceTimezone, "error", err)
+	}
+	maintenanceWindows, err := sefaz.ParseMaintenanceWindows(cfg.Sefaz.MaintenanceWindows, maintenanceLocation)
+	if err != nil {
+		log.Fatal("Janelas de manutenção da SEFAZ inválidas", "error", err)
+	}
+
+	// Configura o scheduler de sincronização e das limpezas do armazenamento e do histórico
+	c := cron.New()
 	if cfg.Sync.Enabled {
-		c := cron.New()
 		_, err := c.AddFunc(cfg.Sync.CronSchedule, func() {
+			if maintenanceWindows.Active(time.Now()) {
+				log.Info("SEFAZ em janela de manutenção programada, sincronização agendada ignorada",
+					"janelas", cfg.Sefaz.MaintenanceWindows,
+				)
+				return
+			}
 			log.Info("Iniciando sincronização agendada")
 			if _, err := nfeService.SyncNFes(); err != nil {
+				if errors.Is(err, domain.ErrSyncLimitReached) {
+					log.Warn("Sincronização em andamento, sincronização agendada ignorada")
+					return
+				}
 				log.Error("Erro na sincronização agendada", "error", err)
 			}
 		})
 		if err != nil {
 			log.Fatal("Erro ao configurar scheduler", "error", err)
 		}
-		c.Start()
-		defer c.Stop()
 		log.Info("Scheduler de sincronização configurado", "schedule", cfg.Sync.CronSchedule)
 	}
+	if cfg.Storage.RetentionYears > 0 {
+		_, err := c.AddFunc(cfg.Storage.CleanupSchedule, func() {
+			log.Info("Iniciando limpeza agendada do armazenamento")
+			if _, err := nfeService.CleanupStorage(false); err != nil {
+				log.Error("Erro na limpeza agendada do armazenamento", "error", err)
+			}
+		})
+		if err != nil {
+			log.Fatal("Erro ao configurar limpeza do armazenamento", "error", err)
+		}
+		log.Info("Limpeza do armazenamento configurada",
+			"schedule", cfg.Storage.CleanupSchedule,
+			"retention_years", cfg.Storage.RetentionYears,
+		)
+	}
+	if cfg.Sync.JobRetentionDays > 0 {
+		_, err := c.AddFunc(cfg.Sync.JobCleanupSchedule, func() {
+			log.Info("Iniciando limpeza agendada do histórico de sincronizações")
+			if _, err := nfeService.CleanupSyncJobs(); err != nil {
+				log.Error("Erro na limpeza agendada do histórico de sincronizações", "error", err)
+			}
+		})
+		if err != nil {
+			log.Fatal("Erro ao configurar limpeza do histórico de sincronizações", "error", err)
+		}
+		log.Info("Limpeza do histórico de sincronizações configurada",
+			"schedule", cfg.Sync.JobCleanupSchedule,
+			"retention_days", cfg.Sync.JobRetentionDays,
+		)
+	}
+	if idempotencyRepository != nil {
+		_, err := c.AddFunc("@hourly", func() {
+			deleted, err := idempotencyRepository.DeleteBefore(time.Now().Add(-cfg.Server.IdempotencyTTL))
+			if err != nil {
+				log.Error("Erro ao remover chaves de idempotência expiradas", "error", err)
+				return
+			}
+			log.Debug("Chaves de idempotência expiradas removidas", "removidas", deleted)
+		})
+		if err != nil {
+			log.Fatal("Erro ao configurar limpeza das chaves de idempotência", "error", err)
+		}
+	}
+	c.Start()
+	defer c.Stop()
+
+	// Log de acesso HTTP, opcionalmente em arquivo próprio
+	var accessLogOut io.Writer = os.Stdout
+	if cfg.Server.AccessLogFile != "" {
+		accessLogFile, err := os.OpenFile(cfg.Server.AccessLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+		if err != nil {
+			log.Fatal("Erro ao abrir arquivo do log de acesso", "path", cfg.Server.AccessLogFile, "error", err)
+		}
+		defer accessLogFile.Close()
+		accessLogOut = accessLogFile
+	}
 
 	// Configura as rotas
 	r := chi.NewRouter()
@@ -106,16 +334,21 @@ func main() {
 	// Middlewares
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(handler.AccessLog(accessLogOut, cfg.Server.AccessLogFormat))
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(handler.LimitBody(cfg.Server.MaxBodyBytes))
+	if cfg.Server.EnableCompression {
+		// Apenas JSON: XMLs e o relatório PDF são servidos sem compressão
+		r.Use(middleware.Compress(5, "application/json"))
+	}
 
 	// CORS
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
@@ -128,7 +361,14 @@ func main() {
 	})
 
 	// Registra as rotas da API
-	nfeHandler := handler.NewNFeHandler(nfeService, log)
+	defaultExcludeStatuses := make([]domain.NFeStatus, 0, len(cfg.Server.DefaultExcludeStatuses))
+	for _, status := range cfg.Server.DefaultExcludeStatuses {
+		if !domain.NFeStatus(status).IsValid() {
+			log.Fatal("Status inválido em SERVER_DEFAULT_EXCLUDE_STATUSES", "status", status)
+		}
+		defaultExcludeStatuses = append(defaultExcludeStatuses, domain.NFeStatus(status))
+	}
+	nfeHandler := handler.NewNFeHandler(nfeService, log, defaultExcludeStatuses, idempotencyRepository, cfg.Server.IdempotencyTTL)
 	nfeHandler.RegisterRoutes(r)
 
 	// Configura o servidor HTTP
@@ -165,4 +405,4 @@ func main() {
 	}
 
 	log.Info("Aplicação encerrada com sucesso")
-}
\ No newline at end of file
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ManifestarBatch manifesta as NFes recebidas com o mesmo evento, enviando as
+// chaves em lotes de até maxEventosLote. Cada lote passa pelo rate limiter e
+// pelo throttle do cliente SEFAZ. Uma falha de comunicação interrompe o envio:
+// quando nenhum lote foi enviado o erro é retornado; caso contrário, as chaves
+// restantes voltam como não enviadas junto com os resultados já obtidos.
+func (s *nfeService) ManifestarBatch(req domain.ManifestacaoBatchRequest) (*domain.ManifestacaoBatch, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	company, err := s.companyByCNPJ(req.CNPJ)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Enviando manifestação em lote à SEFAZ",
+		"cnpj", company.CNPJ,
+		"tipo", req.Tipo,
+		"chaves", len(req.Chaves),
+	)
+
+	batch := &domain.ManifestacaoBatch{
+		Tipo:       req.Tipo,
+		Resultados: make([]domain.ManifestacaoResult, 0, len(req.Chaves)),
+	}
+	for start := 0; start < len(req.Chaves); start += maxEventosLote {
+		chaves := req.Chaves[start:min(start+maxEventosLote, len(req.Chaves))]
+
+		results, err := company.Client.Manifestar(req.Tipo, chaves, req.Justificativa)
+		if err != nil {
+			if start == 0 {
+				return nil, err
+			}
+			s.logger.Error("Manifestação em lote interrompida",
+				"cnpj", company.CNPJ,
+				"enviadas", start,
+				"restantes", len(req.Chaves)-start,
+				"error", err,
+			)
+			for _, chave := range req.Chaves[start:] {
+				batch.Add(domain.ManifestacaoResult{
+					ChaveAcesso: chave,
+					Status:      domain.ManifestacaoNaoEnviada,
+					Motivo:      err.Error(),
+				})
+			}
+			break
+		}
+
+		for _, result := range results {
+			batch.Add(result)
+		}
+	}
+
+	s.logger.Info("Manifestação em lote concluída",
+		"cnpj", company.CNPJ,
+		"tipo", req.Tipo,
+		"registradas", batch.Registradas,
+		"rejeitadas", batch.Rejeitadas,
+		"nao_enviadas", batch.NaoEnviadas,
+	)
+
+	return batch, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ManifestarBatch manifesta várias NFes recebidas com o mesmo evento
+// @Summary Manifestação em lote
+// @Description Envia à SEFAZ o evento de manifestação do destinatário (ciencia, confirmacao, desconhecimento ou nao_realizada) para todas as chaves informadas, em lotes de até 20 eventos e respeitando o limite de chamadas à SEFAZ. Retorna o resultado de cada chave; eventos rejeitados não falham o pedido. Se a comunicação com a SEFAZ falhar no meio do envio, as chaves restantes voltam como nao_enviada.
+// @Tags NFe
+// @Accept json
+// @Produce json
+// @Param request body domain.ManifestacaoBatchRequest true "Tipo da manifestação, chaves de acesso e justificativa (obrigatória para nao_realizada)"
+// @Success 200 {object} domain.ManifestacaoBatch
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/nfe/manifestacao/batch [post]
+func (h *NFeHandler) ManifestarBatch(w http.ResponseWriter, r *http.Request) {
+	var req domain.ManifestacaoBatchRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	batch, err := h.service.ManifestarBatch(req)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Pedido de manifestação inválido", err)
+			return
+		}
+		h.logger.Error("Erro ao manifestar NFes", "error", err)
+		h.sendError(w, r, sefazErrorStatus(err), "Erro ao manifestar NFes", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, batch)
+}
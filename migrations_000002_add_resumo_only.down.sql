@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfes_resumo_only;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS resumo_only;
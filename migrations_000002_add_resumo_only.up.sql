@@ -0,0 +1,6 @@
+-- NFes conhecidas apenas pelo resumo (resNFe) da distribuição DFe
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS resumo_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_nfes_resumo_only ON nfes(resumo_only) WHERE resumo_only;
+
+COMMENT ON COLUMN nfes.resumo_only IS 'Indica NFe registrada apenas com o resumo, sem o XML completo';
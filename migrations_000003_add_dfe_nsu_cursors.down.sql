@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dfe_nsu_cursors;
@@ -0,0 +1,7 @@
+-- Último NSU consumido da distribuição DFe por CNPJ, para que cada
+-- sincronização continue de onde a anterior parou
+CREATE TABLE IF NOT EXISTS dfe_nsu_cursors (
+    cnpj VARCHAR(14) PRIMARY KEY,
+    ult_nsu VARCHAR(15) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfes_origem;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS origem;
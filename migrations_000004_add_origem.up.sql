@@ -0,0 +1,8 @@
+-- Origem da NFe em relação ao CNPJ configurado: emitida por ele ou recebida de terceiros
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS origem VARCHAR(10) NOT NULL DEFAULT 'recebida'
+    CHECK (origem IN ('emitida', 'recebida'));
+
+CREATE INDEX IF NOT EXISTS idx_nfes_origem ON nfes(origem, data_emissao DESC);
+
+-- NFes já cadastradas ficam como recebidas; para corrigir as emitidas execute:
+-- UPDATE nfes SET origem = 'emitida' WHERE cnpj_emitente = '<SEFAZ_CNPJ>';
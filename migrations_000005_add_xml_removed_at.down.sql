@@ -0,0 +1 @@
+ALTER TABLE nfes DROP COLUMN IF EXISTS xml_removed_at;
@@ -0,0 +1,3 @@
+-- Data em que o XML saiu do armazenamento principal pela política de retenção
+-- (arquivado em outro diretório ou excluído)
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS xml_removed_at TIMESTAMP;
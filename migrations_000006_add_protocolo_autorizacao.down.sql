@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_nfes_protocolo_autorizacao;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS data_autorizacao;
+ALTER TABLE nfes DROP COLUMN IF EXISTS protocolo_autorizacao;
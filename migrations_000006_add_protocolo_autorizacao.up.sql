@@ -0,0 +1,5 @@
+-- Protocolo (nProt) e data/hora (dhRecbto) de autorização retornados no protNFe
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS protocolo_autorizacao VARCHAR(20);
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS data_autorizacao TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_nfes_protocolo_autorizacao ON nfes(protocolo_autorizacao);
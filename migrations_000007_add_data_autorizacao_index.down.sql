@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_nfes_data_autorizacao;
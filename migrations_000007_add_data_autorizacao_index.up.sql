@@ -0,0 +1,2 @@
+-- Filtros por período de apuração usam a data de autorização
+CREATE INDEX IF NOT EXISTS idx_nfes_data_autorizacao ON nfes(data_autorizacao DESC);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS nfe_itens;
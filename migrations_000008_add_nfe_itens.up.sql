@@ -0,0 +1,14 @@
+-- Itens das NFes extraídos do XML completo. NFes sincronizadas antes desta
+-- migração não possuem itens cadastrados.
+CREATE TABLE IF NOT EXISTS nfe_itens (
+    nfe_id UUID NOT NULL REFERENCES nfes(id) ON DELETE CASCADE,
+    numero_item INTEGER NOT NULL,
+    codigo_produto VARCHAR(60) NOT NULL,
+    descricao VARCHAR(120) NOT NULL,
+    ncm VARCHAR(8) NOT NULL DEFAULT '',
+    cfop VARCHAR(4) NOT NULL,
+    unidade VARCHAR(6) NOT NULL DEFAULT '',
+    quantidade DECIMAL(15, 4) NOT NULL,
+    valor_total DECIMAL(15, 2) NOT NULL,
+    PRIMARY KEY (nfe_id, numero_item)
+);
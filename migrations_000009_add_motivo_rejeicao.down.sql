@@ -0,0 +1,2 @@
+ALTER TABLE nfes DROP COLUMN IF EXISTS motivo_rejeicao;
+ALTER TABLE nfes DROP COLUMN IF EXISTS codigo_rejeicao;
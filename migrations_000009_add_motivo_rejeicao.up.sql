@@ -0,0 +1,3 @@
+-- cStat e xMotivo do protocolo das NFes rejeitadas pela SEFAZ
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS codigo_rejeicao VARCHAR(3);
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS motivo_rejeicao VARCHAR(255);
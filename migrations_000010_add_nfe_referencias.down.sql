@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfe_referencias_chave_referenciada;
+
+DROP TABLE IF EXISTS nfe_referencias;
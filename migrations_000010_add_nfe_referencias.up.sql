@@ -0,0 +1,9 @@
+-- Documentos referenciados (NFref/refNFe) pelas NFes, ex: a NFe original de
+-- uma devolução. A chave referenciada pode não estar cadastrada em nfes.
+CREATE TABLE IF NOT EXISTS nfe_referencias (
+    nfe_id UUID NOT NULL REFERENCES nfes(id) ON DELETE CASCADE,
+    chave_referenciada VARCHAR(44) NOT NULL,
+    PRIMARY KEY (nfe_id, chave_referenciada)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nfe_referencias_chave_referenciada ON nfe_referencias(chave_referenciada);
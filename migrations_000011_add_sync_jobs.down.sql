@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sync_jobs_status_ended_at;
+DROP INDEX IF EXISTS idx_sync_jobs_started_at;
+
+DROP TABLE IF EXISTS sync_jobs;
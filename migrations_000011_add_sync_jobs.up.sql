@@ -0,0 +1,14 @@
+-- Histórico das execuções de sincronização, usado para monitorar a idade da
+-- última sincronização bem-sucedida
+CREATE TABLE IF NOT EXISTS sync_jobs (
+    id UUID PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    nfes_found INTEGER NOT NULL DEFAULT 0,
+    nfes_error INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_jobs_started_at ON sync_jobs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sync_jobs_status_ended_at ON sync_jobs(status, ended_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_inutilizacoes_cnpj_serie;
+
+DROP TABLE IF EXISTS inutilizacoes;
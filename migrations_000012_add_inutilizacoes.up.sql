@@ -0,0 +1,20 @@
+-- Pedidos de inutilização de numeração enviados à SEFAZ, homologados ou
+-- rejeitados, para comprovação fiscal
+CREATE TABLE IF NOT EXISTS inutilizacoes (
+    id UUID PRIMARY KEY,
+    cnpj VARCHAR(14) NOT NULL,
+    ano CHAR(2) NOT NULL,
+    modelo CHAR(2) NOT NULL,
+    serie INTEGER NOT NULL,
+    numero_inicial INTEGER NOT NULL,
+    numero_final INTEGER NOT NULL,
+    justificativa VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    cstat INTEGER NOT NULL,
+    motivo TEXT NOT NULL,
+    protocolo VARCHAR(20),
+    data_recebimento TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_inutilizacoes_cnpj_serie ON inutilizacoes(cnpj, serie, numero_inicial);
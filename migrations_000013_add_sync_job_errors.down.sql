@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sync_job_errors_job_id;
+
+DROP TABLE IF EXISTS sync_job_errors;
@@ -0,0 +1,12 @@
+-- NFes que falharam em cada sincronização, com o motivo, para que o operador
+-- saiba quais notas precisam de atenção
+CREATE TABLE IF NOT EXISTS sync_job_errors (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES sync_jobs(id) ON DELETE CASCADE,
+    cnpj VARCHAR(14) NOT NULL,
+    chave_acesso VARCHAR(44) NOT NULL,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_job_errors_job_id ON sync_job_errors(job_id);
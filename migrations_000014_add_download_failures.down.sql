@@ -0,0 +1 @@
+DROP TABLE IF EXISTS download_failures;
@@ -0,0 +1,14 @@
+-- Documentos da distribuição DFe que não puderam ser interpretados. O cursor
+-- de NSU avança além deles, então o docZip original é guardado para
+-- reprocessamento.
+CREATE TABLE IF NOT EXISTS download_failures (
+    id UUID PRIMARY KEY,
+    job_id UUID NOT NULL,
+    cnpj VARCHAR(14) NOT NULL,
+    nsu VARCHAR(15) NOT NULL,
+    doc_schema VARCHAR(50) NOT NULL,
+    content TEXT NOT NULL,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_download_failures_cnpj_nsu UNIQUE (cnpj, nsu)
+);
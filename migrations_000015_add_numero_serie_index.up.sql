@@ -0,0 +1,2 @@
+-- Busca pelo número e série impressos no DANFE, sem a chave de acesso
+CREATE INDEX IF NOT EXISTS idx_nfes_numero_serie ON nfes(numero, serie);
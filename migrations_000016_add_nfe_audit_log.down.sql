@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfe_audit_log_nfe_id;
+
+DROP TABLE IF EXISTS nfe_audit_log;
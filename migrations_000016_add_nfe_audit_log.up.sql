@@ -0,0 +1,14 @@
+-- Correções manuais de campos das NFes (PATCH /api/v1/nfe/{chave}), um
+-- registro por campo alterado
+CREATE TABLE IF NOT EXISTS nfe_audit_log (
+    id UUID PRIMARY KEY,
+    nfe_id UUID NOT NULL REFERENCES nfes(id) ON DELETE CASCADE,
+    chave_acesso VARCHAR(44) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    request_id VARCHAR(100),
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_nfe_audit_log_nfe_id ON nfe_audit_log(nfe_id, changed_at DESC);
@@ -0,0 +1,14 @@
+-- Respostas das requisições enviadas com Idempotency-Key, devolvidas nas
+-- repetições dentro de SERVER_IDEMPOTENCY_TTL. status_code e body ficam nulos
+-- enquanto a requisição original está em processamento.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(100),
+    body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE sync_jobs DROP COLUMN IF EXISTS nfes_quarantined;
+
+DROP TABLE IF EXISTS nfe_quarantine;
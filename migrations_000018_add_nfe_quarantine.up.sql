@@ -0,0 +1,19 @@
+-- NFes baixadas cujo tpAmb difere do ambiente configurado (ex: notas de teste
+-- em homologação recebidas em produção). Não são cadastradas em nfes; o XML
+-- original é guardado para conferência.
+CREATE TABLE IF NOT EXISTS nfe_quarantine (
+    id UUID PRIMARY KEY,
+    job_id UUID NOT NULL,
+    cnpj VARCHAR(14) NOT NULL,
+    chave_acesso VARCHAR(44) NOT NULL,
+    cnpj_emitente VARCHAR(14) NOT NULL,
+    tp_amb CHAR(1) NOT NULL,
+    expected_tp_amb CHAR(1) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_nfe_quarantine_chave_acesso UNIQUE (chave_acesso)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nfe_quarantine_created_at ON nfe_quarantine(created_at);
+
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS nfes_quarantined INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfes_uf_emitente;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS uf_emitente;
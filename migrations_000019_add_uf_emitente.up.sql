@@ -0,0 +1,16 @@
+-- Sigla da UF do emitente, extraída do cUF (dois primeiros dígitos) da chave
+-- de acesso, para filtrar as NFes por estado sem recalcular a cada consulta
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS uf_emitente CHAR(2);
+
+UPDATE nfes SET uf_emitente = CASE SUBSTRING(chave_acesso FROM 1 FOR 2)
+    WHEN '11' THEN 'RO' WHEN '12' THEN 'AC' WHEN '13' THEN 'AM' WHEN '14' THEN 'RR'
+    WHEN '15' THEN 'PA' WHEN '16' THEN 'AP' WHEN '17' THEN 'TO' WHEN '21' THEN 'MA'
+    WHEN '22' THEN 'PI' WHEN '23' THEN 'CE' WHEN '24' THEN 'RN' WHEN '25' THEN 'PB'
+    WHEN '26' THEN 'PE' WHEN '27' THEN 'AL' WHEN '28' THEN 'SE' WHEN '29' THEN 'BA'
+    WHEN '31' THEN 'MG' WHEN '32' THEN 'ES' WHEN '33' THEN 'RJ' WHEN '35' THEN 'SP'
+    WHEN '41' THEN 'PR' WHEN '42' THEN 'SC' WHEN '43' THEN 'RS' WHEN '50' THEN 'MS'
+    WHEN '51' THEN 'MT' WHEN '52' THEN 'GO' WHEN '53' THEN 'DF'
+END
+WHERE uf_emitente IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_nfes_uf_emitente ON nfes(uf_emitente);
@@ -0,0 +1,14 @@
+-- Reprocessamento dos XMLs armazenados para preencher campos derivados nas
+-- NFes cadastradas antes de eles existirem (POST /api/v1/admin/reprocess)
+CREATE TABLE IF NOT EXISTS reprocess_jobs (
+    id UUID PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    fields TEXT[] NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    total INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
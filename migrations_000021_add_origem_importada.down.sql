@@ -0,0 +1,5 @@
+UPDATE nfes SET origem = 'recebida' WHERE origem = 'importada';
+
+ALTER TABLE nfes DROP CONSTRAINT IF EXISTS nfes_origem_check;
+ALTER TABLE nfes ADD CONSTRAINT nfes_origem_check
+    CHECK (origem IN ('emitida', 'recebida'));
@@ -0,0 +1,5 @@
+-- NFes cadastradas a partir de XMLs enviados diretamente por parceiros
+-- (POST /api/v1/nfe/import)
+ALTER TABLE nfes DROP CONSTRAINT IF EXISTS nfes_origem_check;
+ALTER TABLE nfes ADD CONSTRAINT nfes_origem_check
+    CHECK (origem IN ('emitida', 'recebida', 'importada'));
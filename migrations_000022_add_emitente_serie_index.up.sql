@@ -0,0 +1,2 @@
+-- Busca das lacunas na numeração de um emitente e série
+CREATE INDEX IF NOT EXISTS idx_nfes_emitente_serie_numero ON nfes(cnpj_emitente, serie, numero);
@@ -0,0 +1,7 @@
+-- XMLs das NFes guardados no banco (XML_STORAGE_BACKEND=database), compactados
+-- com gzip, para implantações sem sistema de arquivos persistente
+CREATE TABLE IF NOT EXISTS nfe_xmls (
+    chave_acesso VARCHAR(44) PRIMARY KEY,
+    xml_gzip BYTEA NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfes_crt;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS crt;
@@ -0,0 +1,6 @@
+-- Código de regime tributário do emitente (emit/CRT): 1 Simples Nacional,
+-- 2 Simples Nacional com excesso de sublimite, 3 Regime Normal e 4 MEI. As NFes
+-- já cadastradas são preenchidas pelo reprocessamento com fields=crt.
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS crt CHAR(1);
+
+CREATE INDEX IF NOT EXISTS idx_nfes_crt ON nfes(crt);
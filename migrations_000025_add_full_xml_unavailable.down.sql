@@ -0,0 +1 @@
+ALTER TABLE nfes DROP COLUMN IF EXISTS full_xml_unavailable;
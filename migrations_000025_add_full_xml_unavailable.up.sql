@@ -0,0 +1,3 @@
+-- NFes já canceladas quando conhecidas pela distribuição DFe, para as quais a
+-- SEFAZ entrega apenas o evento de cancelamento no lugar do XML autorizado
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS full_xml_unavailable BOOLEAN NOT NULL DEFAULT FALSE;
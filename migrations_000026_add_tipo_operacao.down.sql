@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfes_tipo_operacao;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS tipo_operacao;
@@ -0,0 +1,5 @@
+-- Tipo de operação da NFe (ide/tpNF): entrada (0) ou saida (1). As NFes já
+-- cadastradas são preenchidas pelo reprocessamento com fields=tipo_operacao.
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS tipo_operacao VARCHAR(7);
+
+CREATE INDEX IF NOT EXISTS idx_nfes_tipo_operacao ON nfes(tipo_operacao);
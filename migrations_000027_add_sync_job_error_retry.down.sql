@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sync_job_errors_pending;
+
+ALTER TABLE sync_job_errors DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE sync_job_errors DROP COLUMN IF EXISTS resumo;
@@ -0,0 +1,9 @@
+-- Resumo da distribuição DFe das NFes que falharam na gravação, para que a
+-- próxima sincronização as processe novamente sem reter o cursor de NSU.
+-- resolved_at é preenchido quando a NFe é gravada ou volta a falhar em um
+-- job posterior.
+ALTER TABLE sync_job_errors ADD COLUMN IF NOT EXISTS resumo JSONB;
+ALTER TABLE sync_job_errors ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_sync_job_errors_pending ON sync_job_errors(cnpj)
+    WHERE resumo IS NOT NULL AND resolved_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_nfes_xml_file_missing;
+
+ALTER TABLE nfes DROP COLUMN IF EXISTS xml_file_missing;
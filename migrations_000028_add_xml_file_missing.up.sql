@@ -0,0 +1,6 @@
+-- Indica que o arquivo do XML gravado em xml_path não existe no armazenamento.
+-- Mantido pela verificação de consistência e pelo reparo do armazenamento e
+-- usado pelo filtro xml_missing da listagem.
+ALTER TABLE nfes ADD COLUMN IF NOT EXISTS xml_file_missing BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_nfes_xml_file_missing ON nfes(xml_file_missing) WHERE xml_file_missing;
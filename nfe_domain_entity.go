@@ -1,27 +1,71 @@
 package domain
 
 import (
+	"crypto/tls"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
 // NFe representa uma Nota Fiscal Eletrônica no domínio da aplicação
 type NFe struct {
-	ID            uuid.UUID  `json:"id" db:"id"`
-	ChaveAcesso   string     `json:"chave_acesso" db:"chave_acesso"`
-	Numero        string     `json:"numero" db:"numero"`
-	Serie         string     `json:"serie" db:"serie"`
-	CNPJEmitente  string     `json:"cnpj_emitente" db:"cnpj_emitente"`
-	NomeEmitente  string     `json:"nome_emitente" db:"nome_emitente"`
-	DataEmissao   time.Time  `json:"data_emissao" db:"data_emissao"`
-	ValorTotal    float64    `json:"valor_total" db:"valor_total"`
-	XMLPath       string     `json:"xml_path" db:"xml_path"`
-	Status        NFeStatus  `json:"status" db:"status"`
-	DataCancelamento *time.Time `json:"data_cancelamento,omitempty" db:"data_cancelamento"`
-	MotivoCancelamento string  `json:"motivo_cancelamento,omitempty" db:"motivo_cancelamento"`
-	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	ID           uuid.UUID `json:"id" db:"id"`
+	ChaveAcesso  string    `json:"chave_acesso" db:"chave_acesso"`
+	Numero       string    `json:"numero" db:"numero"`
+	Serie        string    `json:"serie" db:"serie"`
+	CNPJEmitente string    `json:"cnpj_emitente" db:"cnpj_emitente"`
+	NomeEmitente string    `json:"nome_emitente" db:"nome_emitente"`
+	// UFEmitente é a sigla da UF do emitente, extraída do cUF da chave de acesso
+	UFEmitente string `json:"uf_emitente" db:"uf_emitente"`
+	// CRT é o código de regime tributário do emitente (emit/CRT); vazio nas
+	// NFes que só têm o resumo
+	CRT CRT `json:"crt,omitempty" db:"crt"`
+	// TipoOperacao é o tpNF da NFe: entrada ou saída do ponto de vista do emitente
+	TipoOperacao TipoOperacao `json:"tipo_operacao,omitempty" db:"tipo_operacao"`
+	DataEmissao  time.Time    `json:"data_emissao" db:"data_emissao"`
+	ValorTotal   Money        `json:"valor_total" db:"valor_total"`
+	XMLPath      string       `json:"xml_path" db:"xml_path"`
+	// XMLFileMissing indica que o arquivo de XMLPath não foi encontrado pela
+	// última verificação do armazenamento
+	XMLFileMissing       bool       `json:"-" db:"xml_file_missing"`
+	Status               NFeStatus  `json:"status" db:"status"`
+	ProtocoloAutorizacao string     `json:"protocolo_autorizacao,omitempty" db:"protocolo_autorizacao"`
+	DataAutorizacao      *time.Time `json:"data_autorizacao,omitempty" db:"data_autorizacao"`
+	DataCancelamento     *time.Time `json:"data_cancelamento,omitempty" db:"data_cancelamento"`
+	MotivoCancelamento   string     `json:"motivo_cancelamento,omitempty" db:"motivo_cancelamento"`
+	// CodigoRejeicao e MotivoRejeicao trazem o cStat e o xMotivo do protocolo
+	// quando a SEFAZ rejeita a NFe
+	CodigoRejeicao string `json:"codigo_rejeicao,omitempty" db:"codigo_rejeicao"`
+	MotivoRejeicao string `json:"motivo_rejeicao,omitempty" db:"motivo_rejeicao"`
+	ResumoOnly     bool   `json:"resumo_only" db:"resumo_only"`
+	// FullXMLUnavailable indica que a NFe já estava cancelada quando foi
+	// conhecida e o XML armazenado é apenas o evento de cancelamento
+	FullXMLUnavailable bool      `json:"full_xml_unavailable" db:"full_xml_unavailable"`
+	Origem             NFeOrigem `json:"origem" db:"origem"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+
+	// Itens é preenchido apenas quando solicitado (ver NFeFilter.IncludeItens)
+	Itens []NFeItem `json:"itens,omitempty" db:"-"`
+
+	// Referencias são as chaves dos refNFe do XML, gravadas em nfe_referencias
+	Referencias []string `json:"-" db:"-"`
+}
+
+// NFeItem representa um item (det) de uma NFe
+type NFeItem struct {
+	NFeID         uuid.UUID `json:"-" db:"nfe_id"`
+	NumeroItem    int       `json:"numero_item" db:"numero_item"`
+	CodigoProduto string    `json:"codigo_produto" db:"codigo_produto"`
+	Descricao     string    `json:"descricao" db:"descricao"`
+	NCM           string    `json:"ncm" db:"ncm"`
+	CFOP          string    `json:"cfop" db:"cfop"`
+	Unidade       string    `json:"unidade" db:"unidade"`
+	Quantidade    float64   `json:"quantidade" db:"quantidade"`
+	ValorTotal    Money     `json:"valor_total" db:"valor_total"`
 }
 
 // NFeStatus representa o status de uma NFe
@@ -33,29 +77,254 @@ const (
 	NFeStatusDenegada    NFeStatus = "denegada"
 	NFeStatusRejeitada   NFeStatus = "rejeitada"
 	NFeStatusProcessando NFeStatus = "processando"
+	NFeStatusInvalida    NFeStatus = "invalida"
+	// NFeStatusSuspeita marca NFes autorizadas cujo vNF diverge do valor dos itens
+	NFeStatusSuspeita NFeStatus = "suspeita"
+	// NFeStatusEPEC marca NFes emitidas em contingência EPEC que ainda não
+	// foram autorizadas pela SEFAZ do emitente
+	NFeStatusEPEC NFeStatus = "epec"
 )
 
 // IsValid verifica se o status é válido
 func (s NFeStatus) IsValid() bool {
 	switch s {
-	case NFeStatusAutorizada, NFeStatusCancelada, NFeStatusDenegada, 
-		 NFeStatusRejeitada, NFeStatusProcessando:
+	case NFeStatusAutorizada, NFeStatusCancelada, NFeStatusDenegada,
+		NFeStatusRejeitada, NFeStatusProcessando, NFeStatusInvalida, NFeStatusSuspeita,
+		NFeStatusEPEC:
+		return true
+	}
+	return false
+}
+
+// nfeStatusOrder é a ordem estável de apresentação dos status nas estatísticas
+var nfeStatusOrder = []NFeStatus{
+	NFeStatusAutorizada,
+	NFeStatusCancelada,
+	NFeStatusDenegada,
+	NFeStatusRejeitada,
+	NFeStatusProcessando,
+	NFeStatusEPEC,
+	NFeStatusInvalida,
+	NFeStatusSuspeita,
+}
+
+// rank retorna a posição do status em nfeStatusOrder; status desconhecidos vêm por último
+func (s NFeStatus) rank() int {
+	for i, status := range nfeStatusOrder {
+		if s == status {
+			return i
+		}
+	}
+	return len(nfeStatusOrder)
+}
+
+// NFeOrigem indica se a NFe foi emitida pelo CNPJ configurado, recebida de
+// terceiros pela SEFAZ ou importada a partir de um XML enviado pelo parceiro
+type NFeOrigem string
+
+const (
+	NFeOrigemEmitida   NFeOrigem = "emitida"
+	NFeOrigemRecebida  NFeOrigem = "recebida"
+	NFeOrigemImportada NFeOrigem = "importada"
+)
+
+// IsValid verifica se a origem é válida
+func (o NFeOrigem) IsValid() bool {
+	return o == NFeOrigemEmitida || o == NFeOrigemRecebida || o == NFeOrigemImportada
+}
+
+// OrigemPara retorna a origem da NFe do ponto de vista do CNPJ informado
+func OrigemPara(cnpjEmitente, cnpj string) NFeOrigem {
+	if cnpjEmitente == cnpj {
+		return NFeOrigemEmitida
+	}
+	return NFeOrigemRecebida
+}
+
+// CRT é o código de regime tributário do emitente informado no XML da NFe
+type CRT string
+
+const (
+	CRTSimplesNacional        CRT = "1"
+	CRTSimplesNacionalExcesso CRT = "2"
+	CRTRegimeNormal           CRT = "3"
+	CRTSimplesNacionalMEI     CRT = "4"
+)
+
+// IsValid verifica se o código de regime tributário é válido
+func (c CRT) IsValid() bool {
+	switch c {
+	case CRTSimplesNacional, CRTSimplesNacionalExcesso, CRTRegimeNormal, CRTSimplesNacionalMEI:
 		return true
 	}
 	return false
 }
 
+// IsSimplesNacional indica se o emitente é optante do Simples Nacional,
+// inclusive com excesso de sublimite ou como MEI
+func (c CRT) IsSimplesNacional() bool {
+	return c == CRTSimplesNacional || c == CRTSimplesNacionalExcesso || c == CRTSimplesNacionalMEI
+}
+
+// TipoOperacao é o tipo de operação da NFe (ide/tpNF)
+type TipoOperacao string
+
+const (
+	TipoOperacaoEntrada TipoOperacao = "entrada"
+	TipoOperacaoSaida   TipoOperacao = "saida"
+)
+
+// IsValid verifica se o tipo de operação é válido
+func (t TipoOperacao) IsValid() bool {
+	return t == TipoOperacaoEntrada || t == TipoOperacaoSaida
+}
+
+// NFeResumo representa o resumo (resNFe) de uma NFe entregue pela distribuição DFe,
+// disponível antes do XML completo
+type NFeResumo struct {
+	ChaveAcesso  string    `json:"chave_acesso"`
+	CNPJEmitente string    `json:"cnpj_emitente"`
+	NomeEmitente string    `json:"nome_emitente"`
+	DataEmissao  time.Time `json:"data_emissao"`
+	ValorTotal   Money     `json:"valor_total"`
+	// TipoOperacao vem do tpNF do resumo; vazio quando não informado
+	TipoOperacao TipoOperacao `json:"tipo_operacao"`
+}
+
+// ConsultaNFes representa o resultado de uma consulta à distribuição DFe
+// UltNSU é o último NSU consumido, a partir do qual a próxima consulta continua
+type ConsultaNFes struct {
+	Resumos []NFeResumo `json:"resumos"`
+	// Failures são os documentos do lote que não puderam ser interpretados
+	Failures []DownloadFailure `json:"failures"`
+	UltNSU   string            `json:"ult_nsu"`
+}
+
+// DownloadFailure registra um documento da distribuição DFe que não pôde ser
+// interpretado (ex: docZip corrompido). Content guarda o docZip original em
+// base64 para reprocessamento, já que o cursor de NSU avança além dele.
+type DownloadFailure struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	JobID     uuid.UUID `json:"job_id" db:"job_id"`
+	CNPJ      string    `json:"cnpj" db:"cnpj"`
+	NSU       string    `json:"nsu" db:"nsu"`
+	Schema    string    `json:"schema" db:"doc_schema"`
+	Content   string    `json:"-" db:"content"`
+	Error     string    `json:"error" db:"error"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// QuarantinedNFe registra uma NFe baixada cujo tpAmb difere do ambiente
+// configurado (ex: nota de teste em homologação recebida em produção). Ela não
+// é cadastrada; Content guarda o XML original para conferência.
+type QuarantinedNFe struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	JobID         uuid.UUID `json:"job_id" db:"job_id"`
+	CNPJ          string    `json:"cnpj" db:"cnpj"`
+	ChaveAcesso   string    `json:"chave_acesso" db:"chave_acesso"`
+	CNPJEmitente  string    `json:"cnpj_emitente" db:"cnpj_emitente"`
+	TpAmb         string    `json:"tp_amb" db:"tp_amb"`
+	ExpectedTpAmb string    `json:"expected_tp_amb" db:"expected_tp_amb"`
+	Content       string    `json:"-" db:"content"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// QuarantineFilter representa a paginação da listagem de NFes em quarentena
+type QuarantineFilter struct {
+	Page  int
+	Limit int
+}
+
+// Normalize aplica os valores padrão de paginação
+func (f *QuarantineFilter) Normalize() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.Limit < 1 || f.Limit > 100 {
+		f.Limit = 20
+	}
+}
+
+// GetOffset retorna o offset para paginação
+func (f *QuarantineFilter) GetOffset() int {
+	return (f.Page - 1) * f.Limit
+}
+
+// IdempotencyRecord guarda a resposta de uma requisição enviada com o
+// cabeçalho Idempotency-Key, devolvida novamente nas repetições. Fingerprint
+// identifica o método, o caminho e o corpo da requisição original.
+type IdempotencyRecord struct {
+	Key         string     `db:"idempotency_key"`
+	Fingerprint string     `db:"fingerprint"`
+	StatusCode  int        `db:"status_code"`
+	ContentType string     `db:"content_type"`
+	Body        []byte     `db:"body"`
+	CreatedAt   time.Time  `db:"created_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+// Completed indica se a requisição original já terminou e tem resposta gravada
+func (r *IdempotencyRecord) Completed() bool {
+	return r.CompletedAt != nil
+}
+
 // NFeFilter representa os filtros para busca de NFes
 type NFeFilter struct {
-	CNPJEmitente string     `json:"cnpj_emitente"`
-	Status       NFeStatus  `json:"status"`
-	StartDate    *time.Time `json:"start_date"`
-	EndDate      *time.Time `json:"end_date"`
-	Page         int        `json:"page"`
-	Limit        int        `json:"limit"`
+	CNPJEmitente string `json:"cnpj_emitente"`
+	// UFEmitente filtra pela sigla da UF do emitente (ex: SP)
+	UFEmitente string `json:"uf_emitente"`
+	// CRTs filtra por qualquer um dos regimes tributários do emitente informados
+	CRTs []CRT `json:"crts"`
+	// TipoOperacao filtra pelo tpNF da NFe (entrada ou saida)
+	TipoOperacao TipoOperacao `json:"tipo_operacao"`
+	Status       NFeStatus    `json:"status"`
+	// Numero e Serie buscam a NFe pelo número impresso no DANFE; zeros à esquerda são ignorados
+	Numero string `json:"numero"`
+	Serie  string `json:"serie"`
+	// IncludeItens carrega os itens das NFes da página em uma única consulta
+	IncludeItens bool `json:"include_itens"`
+	// Statuses filtra por qualquer um dos status informados
+	Statuses []NFeStatus `json:"statuses"`
+	// ExcludeStatuses remove os status informados do resultado
+	ExcludeStatuses []NFeStatus `json:"exclude_statuses"`
+	StartDate       *time.Time  `json:"start_date"`
+	EndDate         *time.Time  `json:"end_date"`
+	AuthStartDate   *time.Time  `json:"auth_start_date"`
+	AuthEndDate     *time.Time  `json:"auth_end_date"`
+	ResumoOnly      *bool       `json:"resumo_only"`
+	// XMLMissing filtra as NFes que deveriam ter XML e não têm (true) ou que têm (false)
+	XMLMissing *bool `json:"xml_missing"`
+	// Cancelled filtra as NFes que já foram canceladas (true), mesmo que o status
+	// atual seja outro, ou que nunca foram (false)
+	Cancelled *bool `json:"cancelled"`
+	// EmissionAfterCancellation filtra as NFes com data de emissão posterior ao
+	// cancelamento registrado (true), um dado inconsistente, ou as remove (false)
+	EmissionAfterCancellation *bool `json:"emission_after_cancellation"`
+	// Projection seleciona as colunas carregadas; vazio equivale a NFeProjectionFull
+	Projection NFeProjection `json:"projection"`
+	Origem     NFeOrigem     `json:"origem"`
+	Page       int           `json:"page"`
+	Limit      int           `json:"limit"`
 }
 
-// Validate valida os filtros
+// NFeProjection define o conjunto de colunas carregado na listagem de NFes
+type NFeProjection string
+
+const (
+	// NFeProjectionFull carrega todas as colunas da NFe
+	NFeProjectionFull NFeProjection = "full"
+	// NFeProjectionSummary carrega apenas as colunas exibidas na grade: chave,
+	// número, série, emitente, data de emissão, valor e status. Os demais campos
+	// voltam com o valor zero.
+	NFeProjectionSummary NFeProjection = "summary"
+)
+
+// IsValid verifica se a projeção é válida
+func (p NFeProjection) IsValid() bool {
+	return p == NFeProjectionFull || p == NFeProjectionSummary
+}
+
+// Validate valida os filtros e retorna um *ValidationError com todos os campos inválidos
 func (f *NFeFilter) Validate() error {
 	if f.Page < 1 {
 		f.Page = 1
@@ -63,10 +332,113 @@ func (f *NFeFilter) Validate() error {
 	if f.Limit < 1 || f.Limit > 100 {
 		f.Limit = 20
 	}
+
+	verr := &ValidationError{}
 	if f.Status != "" && !f.Status.IsValid() {
-		return ErrInvalidStatus
+		verr.Add("status", "status inválido: "+string(f.Status), ErrInvalidStatus)
+	}
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			verr.Add("status", "status inválido: "+string(status), ErrInvalidStatus)
+		}
 	}
-	return nil
+	for _, status := range f.ExcludeStatuses {
+		if !status.IsValid() {
+			verr.Add("exclude_statuses", "status inválido: "+string(status), ErrInvalidStatus)
+		}
+	}
+	if f.Origem != "" && !f.Origem.IsValid() {
+		verr.Add("origem", "origem deve ser emitida, recebida ou importada", ErrInvalidOrigem)
+	}
+	if f.Projection != "" && !f.Projection.IsValid() {
+		verr.Add("projection", "projection deve ser full ou summary", nil)
+	}
+	if f.Numero != "" {
+		numero, ok := normalizeNumeroFiscal(f.Numero, 9)
+		if !ok {
+			verr.Add("numero", "numero deve ter até 9 dígitos", nil)
+		}
+		f.Numero = numero
+	}
+	if f.Serie != "" {
+		serie, ok := normalizeNumeroFiscal(f.Serie, 3)
+		if !ok {
+			verr.Add("serie", "serie deve ter até 3 dígitos", nil)
+		}
+		f.Serie = serie
+	}
+	if f.UFEmitente != "" {
+		f.UFEmitente = strings.ToUpper(strings.TrimSpace(f.UFEmitente))
+		if len(f.UFEmitente) != 2 || strings.Trim(f.UFEmitente, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			verr.Add("uf_emitente", "uf_emitente deve ser a sigla da UF (ex: SP)", nil)
+		}
+	}
+	if f.TipoOperacao != "" && !f.TipoOperacao.IsValid() {
+		verr.Add("tipo_operacao", "tipo_operacao deve ser entrada ou saida", nil)
+	}
+	for _, crt := range f.CRTs {
+		if !crt.IsValid() {
+			verr.Add("crt", "crt inválido: "+string(crt), nil)
+		}
+	}
+	if f.StartDate != nil && f.EndDate != nil && f.EndDate.Before(*f.StartDate) {
+		verr.Add("end_date", "end_date deve ser igual ou posterior a start_date", nil)
+	}
+	if f.AuthStartDate != nil && f.AuthEndDate != nil && f.AuthEndDate.Before(*f.AuthStartDate) {
+		verr.Add("auth_end_date", "auth_end_date deve ser igual ou posterior a auth_start_date", nil)
+	}
+	return verr.Err()
+}
+
+// normalizeNumeroFiscal remove os zeros à esquerda de um número ou série, como
+// são gravados a partir do XML, e indica se o valor tem apenas dígitos e cabe
+// em maxDigits
+func normalizeNumeroFiscal(value string, maxDigits int) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.Trim(value, "0123456789") != "" {
+		return value, false
+	}
+
+	trimmed := strings.TrimLeft(value, "0")
+	if trimmed == "" {
+		trimmed = "0"
+	}
+	return trimmed, len(trimmed) <= maxDigits
+}
+
+// NFeLookup identifica uma NFe pelo emitente, número e série impressos no
+// DANFE. Modelo (55 ou 65) é opcional e desempata NF-e e NFC-e de mesmo número.
+type NFeLookup struct {
+	CNPJ   string `json:"cnpj"`
+	Numero string `json:"numero"`
+	Serie  string `json:"serie"`
+	Modelo string `json:"modelo"`
+}
+
+// Validate valida a busca, normaliza número e série e retorna um
+// *ValidationError com todos os campos inválidos
+func (l *NFeLookup) Validate() error {
+	verr := &ValidationError{}
+	if len(l.CNPJ) != 14 || strings.Trim(l.CNPJ, "0123456789") != "" {
+		verr.Add("cnpj", "cnpj deve ter 14 dígitos", nil)
+	}
+
+	numero, ok := normalizeNumeroFiscal(l.Numero, 9)
+	if !ok {
+		verr.Add("numero", "numero obrigatório com até 9 dígitos", nil)
+	}
+	l.Numero = numero
+
+	serie, ok := normalizeNumeroFiscal(l.Serie, 3)
+	if !ok {
+		verr.Add("serie", "serie obrigatória com até 3 dígitos", nil)
+	}
+	l.Serie = serie
+
+	if l.Modelo != "" && l.Modelo != "55" && l.Modelo != "65" {
+		verr.Add("modelo", "modelo deve ser 55 ou 65", nil)
+	}
+	return verr.Err()
 }
 
 // GetOffset retorna o offset para paginação
@@ -74,12 +446,118 @@ func (f *NFeFilter) GetOffset() int {
 	return (f.Page - 1) * f.Limit
 }
 
-// NFePaginatedResponse representa uma resposta paginada de NFes
-type NFePaginatedResponse struct {
-	Data       []NFe      `json:"data"`
+// EmitenteFilter representa os filtros da listagem de emitentes. Search busca
+// pelo nome (qualquer parte) ou pelo início do CNPJ.
+type EmitenteFilter struct {
+	Search string
+	Page   int
+	Limit  int
+}
+
+// Normalize aplica os valores padrão de paginação
+func (f *EmitenteFilter) Normalize() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.Limit < 1 || f.Limit > 100 {
+		f.Limit = 20
+	}
+	f.Search = strings.TrimSpace(f.Search)
+}
+
+// GetOffset retorna o offset para paginação
+func (f *EmitenteFilter) GetOffset() int {
+	return (f.Page - 1) * f.Limit
+}
+
+// Emitente representa um emitente distinto das NFes armazenadas. O nome é o da
+// NFe mais recente do emitente.
+type Emitente struct {
+	CNPJ       string `json:"cnpj" db:"cnpj"`
+	Nome       string `json:"nome" db:"nome"`
+	NFeCount   int64  `json:"nfe_count" db:"nfe_count"`
+	TotalValor Money  `json:"total_valor" db:"total_valor"`
+}
+
+// NumeroGapFilter identifica a sequência de numeração analisada: as NFes de um
+// emitente em uma série
+type NumeroGapFilter struct {
+	CNPJ  string
+	Serie string
+}
+
+// Validate valida o filtro, normaliza a série e retorna um *ValidationError
+// com todos os campos inválidos
+func (f *NumeroGapFilter) Validate() error {
+	verr := &ValidationError{}
+	if len(f.CNPJ) != 14 || strings.Trim(f.CNPJ, "0123456789") != "" {
+		verr.Add("cnpj", "cnpj deve ter 14 dígitos", nil)
+	}
+
+	serie, ok := normalizeNumeroFiscal(f.Serie, 3)
+	if !ok {
+		verr.Add("serie", "serie obrigatória com até 3 dígitos", nil)
+	}
+	f.Serie = serie
+	return verr.Err()
+}
+
+// NumeroGap é uma faixa de números ausente entre duas NFes armazenadas do
+// emitente. Inutilizada indica que a faixa inteira está coberta por
+// inutilizações homologadas; as demais podem ser NFes que não sincronizamos.
+type NumeroGap struct {
+	NumeroInicial int64 `json:"numero_inicial" db:"numero_inicial"`
+	NumeroFinal   int64 `json:"numero_final" db:"numero_final"`
+	Quantidade    int64 `json:"quantidade" db:"quantidade"`
+	Inutilizada   bool  `json:"inutilizada" db:"-"`
+}
+
+// CoveredBy indica se as inutilizações cobrem todos os números da faixa,
+// somando faixas inutilizadas contíguas ou sobrepostas
+func (g NumeroGap) CoveredBy(inutilizacoes []Inutilizacao) bool {
+	next := g.NumeroInicial
+	for advanced := true; advanced && next <= g.NumeroFinal; {
+		advanced = false
+		for _, inut := range inutilizacoes {
+			if int64(inut.NumeroInicial) <= next && int64(inut.NumeroFinal) >= next {
+				next = int64(inut.NumeroFinal) + 1
+				advanced = true
+			}
+		}
+	}
+	return next > g.NumeroFinal
+}
+
+// NumeroGaps é o relatório das lacunas na numeração de um emitente e série,
+// entre o menor e o maior número armazenados
+type NumeroGaps struct {
+	CNPJ  string      `json:"cnpj"`
+	Serie string      `json:"serie"`
+	Gaps  []NumeroGap `json:"gaps"`
+}
+
+// PaginatedResponse é o envelope comum das listagens paginadas da API
+type PaginatedResponse[T any] struct {
+	Data       []T        `json:"data"`
 	Pagination Pagination `json:"pagination"`
 }
 
+// NewPaginatedResponse monta uma página da listagem. Uma página sem registros
+// tem data como lista vazia, nunca null.
+func NewPaginatedResponse[T any](data []T, page, limit int, total int64) *PaginatedResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return &PaginatedResponse[T]{
+		Data: data,
+		Pagination: Pagination{
+			Page:  page,
+			Limit: limit,
+			Total: total,
+		},
+	}
+}
+
 // Pagination representa informações de paginação
 type Pagination struct {
 	Page  int   `json:"page"`
@@ -89,10 +567,148 @@ type Pagination struct {
 
 // NFeStats representa estatísticas de NFes
 type NFeStats struct {
-	TotalNFes    int64              `json:"total_nfes"`
-	ValorTotal   float64            `json:"valor_total"`
-	Periodo      Periodo            `json:"periodo"`
-	PorStatus    map[NFeStatus]int64 `json:"por_status"`
+	TotalNFes  int64            `json:"total_nfes"`
+	ValorTotal Money            `json:"valor_total"`
+	Periodo    Periodo          `json:"periodo"`
+	PorStatus  []NFeStatusCount `json:"por_status"`
+}
+
+// EmitenteTotal representa a quantidade e o valor das NFes de um emitente no período
+type EmitenteTotal struct {
+	CNPJEmitente string `json:"cnpj_emitente" db:"cnpj_emitente"`
+	NomeEmitente string `json:"nome_emitente" db:"nome_emitente"`
+	TotalNFes    int64  `json:"total_nfes" db:"total_nfes"`
+	ValorTotal   Money  `json:"valor_total" db:"valor_total"`
+}
+
+// NFeStatsReport reúne os dados do relatório de estatísticas de um período
+type NFeStatsReport struct {
+	Stats        NFeStats        `json:"stats"`
+	TopEmitentes []EmitenteTotal `json:"top_emitentes"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+}
+
+// NFeStatusCount representa a quantidade de NFes de um status
+type NFeStatusCount struct {
+	Status NFeStatus `json:"status" db:"status"`
+	Count  int64     `json:"count" db:"count"`
+}
+
+// SortStatusCounts ordena as contagens pela ordem estável dos status
+// (autorizada, cancelada, denegada, rejeitada, processando, ...), para que a
+// resposta não mude de ordem entre consultas
+func SortStatusCounts(counts []NFeStatusCount) {
+	sort.SliceStable(counts, func(i, j int) bool {
+		ri, rj := counts[i].Status.rank(), counts[j].Status.rank()
+		if ri != rj {
+			return ri < rj
+		}
+		return counts[i].Status < counts[j].Status
+	})
+}
+
+// NFeXMLContent traz o XML armazenado de uma NFe como texto, para clientes que
+// exibem o conteúdo sem baixar o arquivo
+type NFeXMLContent struct {
+	ChaveAcesso string `json:"chave"`
+	XML         string `json:"xml"`
+}
+
+// NFeVerification representa o resultado da verificação de integridade do XML
+// armazenado de uma NFe. XSDValid é nulo quando a validação pelos schemas não é
+// executada.
+type NFeVerification struct {
+	ChaveAcesso    string   `json:"chave_acesso"`
+	XSDValid       *bool    `json:"xsd_valid"`
+	SignatureValid bool     `json:"signature_valid"`
+	Signer         string   `json:"signer,omitempty"`
+	Errors         []string `json:"errors"`
+}
+
+// XMLValidation representa o resultado da validação de um XML enviado por um
+// parceiro, sem cadastrá-lo. Valid é verdadeiro apenas quando a chave de acesso
+// e a assinatura são válidas e nenhum erro foi encontrado.
+type XMLValidation struct {
+	ChaveAcesso    string   `json:"chave_acesso,omitempty"`
+	Valid          bool     `json:"valid"`
+	XSDValid       *bool    `json:"xsd_valid"`
+	SignatureValid bool     `json:"signature_valid"`
+	ChaveValid     bool     `json:"chave_valid"`
+	Signer         string   `json:"signer,omitempty"`
+	Errors         []string `json:"errors"`
+}
+
+// maxNomeEmitente é o tamanho da coluna nome_emitente
+const maxNomeEmitente = 255
+
+// NFePatch é a correção manual de campos derivados do XML. Apenas os campos
+// abaixo são editáveis; o XML armazenado nunca é alterado. Campos nulos são
+// mantidos.
+type NFePatch struct {
+	NomeEmitente       *string `json:"nome_emitente"`
+	MotivoCancelamento *string `json:"motivo_cancelamento"`
+	MotivoRejeicao     *string `json:"motivo_rejeicao"`
+}
+
+// Validate valida a correção e retorna um *ValidationError com todos os campos inválidos
+func (p *NFePatch) Validate() error {
+	verr := &ValidationError{}
+	if p.NomeEmitente == nil && p.MotivoCancelamento == nil && p.MotivoRejeicao == nil {
+		verr.Add("body", "informe ao menos um campo para corrigir", nil)
+	}
+	if p.NomeEmitente != nil {
+		nome := strings.TrimSpace(*p.NomeEmitente)
+		if nome == "" || utf8.RuneCountInString(nome) > maxNomeEmitente {
+			verr.Add("nome_emitente", "nome_emitente deve ter entre 1 e 255 caracteres", nil)
+		}
+		p.NomeEmitente = &nome
+	}
+	return verr.Err()
+}
+
+// Apply aplica a correção na NFe e retorna uma entrada de auditoria, sem ID e
+// sem dados da requisição, para cada campo efetivamente alterado
+func (p NFePatch) Apply(nfe *NFe) []NFeAuditEntry {
+	changes := []NFeAuditEntry{}
+	apply := func(field string, value *string, target *string) {
+		if value == nil || *value == *target {
+			return
+		}
+		changes = append(changes, NFeAuditEntry{Field: field, OldValue: *target, NewValue: *value})
+		*target = *value
+	}
+
+	apply("nome_emitente", p.NomeEmitente, &nfe.NomeEmitente)
+	apply("motivo_cancelamento", p.MotivoCancelamento, &nfe.MotivoCancelamento)
+	apply("motivo_rejeicao", p.MotivoRejeicao, &nfe.MotivoRejeicao)
+	return changes
+}
+
+// NFeAuditEntry registra a alteração manual de um campo da NFe
+type NFeAuditEntry struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	NFeID       uuid.UUID `json:"nfe_id" db:"nfe_id"`
+	ChaveAcesso string    `json:"chave_acesso" db:"chave_acesso"`
+	Field       string    `json:"field" db:"field"`
+	OldValue    string    `json:"old_value" db:"old_value"`
+	NewValue    string    `json:"new_value" db:"new_value"`
+	// RequestID identifica a requisição que fez a alteração nos logs de acesso
+	RequestID string    `json:"request_id" db:"request_id"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// NFeReferencias relaciona uma NFe aos documentos que ela referencia (NFref) e
+// às NFes que a referenciam, como as devoluções de uma NFe de venda
+type NFeReferencias struct {
+	ChaveAcesso     string   `json:"chave_acesso"`
+	Referenciadas   []string `json:"referenciadas"`
+	ReferenciadaPor []string `json:"referenciada_por"`
+}
+
+// NFeCount representa o total de NFes e a soma dos valores para um filtro
+type NFeCount struct {
+	Total      int64 `json:"total"`
+	ValorTotal Money `json:"valor_total"`
 }
 
 // Periodo representa um período de datas
@@ -102,14 +718,67 @@ type Periodo struct {
 }
 
 // SyncJob representa um job de sincronização
+
 type SyncJob struct {
-	ID        uuid.UUID       `json:"id"`
-	Status    SyncJobStatus   `json:"status"`
-	StartedAt time.Time       `json:"started_at"`
-	EndedAt   *time.Time      `json:"ended_at,omitempty"`
-	NFesFound int             `json:"nfes_found"`
-	NFesError int             `json:"nfes_error"`
-	Error     string          `json:"error,omitempty"`
+	ID        uuid.UUID     `json:"id" db:"id"`
+	Status    SyncJobStatus `json:"status" db:"status"`
+	StartedAt time.Time     `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time    `json:"ended_at,omitempty" db:"ended_at"`
+	NFesFound int           `json:"nfes_found" db:"nfes_found"`
+	NFesError int           `json:"nfes_error" db:"nfes_error"`
+	Error     string        `json:"error,omitempty" db:"error"`
+	// NFesQuarantined conta as NFes de outro ambiente colocadas em quarentena
+	NFesQuarantined int `json:"nfes_quarantined" db:"nfes_quarantined"`
+}
+
+// SyncJobError registra uma NFe que falhou em um job de sincronização
+type SyncJobError struct {
+	JobID       uuid.UUID `json:"-" db:"job_id"`
+	CNPJ        string    `json:"cnpj" db:"cnpj"`
+	ChaveAcesso string    `json:"chave_acesso" db:"chave_acesso"`
+	Error       string    `json:"error" db:"error"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	// Resumo é preenchido nas NFes que falharam na gravação da sincronização,
+	// processadas novamente na execução seguinte
+	Resumo *NFeResumo `json:"-" db:"-"`
+}
+
+// SyncJobErrors lista as NFes que falharam em um job de sincronização
+type SyncJobErrors struct {
+	JobID  uuid.UUID      `json:"job_id"`
+	Status SyncJobStatus  `json:"status"`
+	Total  int            `json:"total"`
+	Errors []SyncJobError `json:"errors"`
+}
+
+// SyncJobRetention descreve o prazo de guarda do histórico de sincronizações.
+// Cutoff é nulo quando a limpeza está desativada.
+type SyncJobRetention struct {
+	RetentionDays int        `json:"retention_days"`
+	Enabled       bool       `json:"enabled"`
+	Cutoff        *time.Time `json:"cutoff,omitempty"`
+}
+
+// BuildInfo identifica o binário em execução. Os valores são gravados na
+// compilação via -ldflags.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// SchemaVersion é a última migration aplicada ao banco. Dirty indica que a
+// migration falhou no meio e o schema precisa de intervenção manual.
+type SchemaVersion struct {
+	Version int64 `json:"version" db:"version"`
+	Dirty   bool  `json:"dirty" db:"dirty"`
+}
+
+// VersionInfo descreve a versão da aplicação e do schema do banco em uso.
+// Schema é nulo quando nenhuma migration foi aplicada.
+type VersionInfo struct {
+	BuildInfo
+	Schema *SchemaVersion `json:"schema"`
 }
 
 // SyncJobStatus representa o status de um job de sincronização
@@ -121,27 +790,805 @@ const (
 	SyncJobStatusFailed    SyncJobStatus = "failed"
 )
 
+// ReprocessField identifica um grupo de campos derivados do XML que o
+// reprocessamento preenche nas NFes já cadastradas
+type ReprocessField string
+
+const (
+	// ReprocessProtocolo preenche protocolo_autorizacao e data_autorizacao
+	ReprocessProtocolo ReprocessField = "protocolo"
+	// ReprocessUF preenche uf_emitente
+	ReprocessUF ReprocessField = "uf"
+	// ReprocessCRT preenche o regime tributário do emitente
+	ReprocessCRT ReprocessField = "crt"
+	// ReprocessTipoOperacao preenche o tipo de operação (tpNF)
+	ReprocessTipoOperacao ReprocessField = "tipo_operacao"
+	// ReprocessItens regrava os itens da NFe
+	ReprocessItens ReprocessField = "itens"
+	// ReprocessReferencias regrava os documentos referenciados
+	ReprocessReferencias ReprocessField = "referencias"
+)
+
+// IsValid verifica se o campo de reprocessamento é conhecido
+func (f ReprocessField) IsValid() bool {
+	switch f {
+	case ReprocessProtocolo, ReprocessUF, ReprocessCRT, ReprocessTipoOperacao, ReprocessItens, ReprocessReferencias:
+		return true
+	}
+	return false
+}
+
+// ParseReprocessFields interpreta a lista de campos separados por vírgula
+// (ex: protocolo,uf), ignorando repetições
+func ParseReprocessFields(raw string) ([]ReprocessField, error) {
+	verr := &ValidationError{}
+	fields := []ReprocessField{}
+	seen := map[ReprocessField]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		field := ReprocessField(strings.ToLower(strings.TrimSpace(part)))
+		if field == "" || seen[field] {
+			continue
+		}
+		if !field.IsValid() {
+			verr.Add("fields", "campo de reprocessamento inválido: "+string(field), nil)
+			continue
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 && len(verr.Fields) == 0 {
+		verr.Add("fields", "informe ao menos um campo de reprocessamento", nil)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// ReprocessJob acompanha o reprocessamento dos XMLs armazenados, que preenche
+// os campos derivados nas NFes cadastradas antes de eles existirem. Total é o
+// número de NFes com XML armazenado a reprocessar.
+type ReprocessJob struct {
+	ID        uuid.UUID        `json:"id"`
+	Status    SyncJobStatus    `json:"status"`
+	Fields    []ReprocessField `json:"fields"`
+	StartedAt time.Time        `json:"started_at"`
+	EndedAt   *time.Time       `json:"ended_at,omitempty"`
+	Total     int              `json:"total"`
+	Processed int              `json:"processed"`
+	Updated   int              `json:"updated"`
+	Failed    int              `json:"failed"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// SyncHealth resume a situação das sincronizações para monitoramento.
+// LastSuccessAt e AgeSeconds são nulos quando nenhuma sincronização foi
+// concluída com sucesso; LastStatus é vazio quando nenhuma foi executada.
+type SyncHealth struct {
+	LastSuccessAt *time.Time    `json:"last_success_at"`
+	AgeSeconds    *int64        `json:"age_seconds"`
+	LastStatus    SyncJobStatus `json:"last_status"`
+	LastStartedAt *time.Time    `json:"last_started_at,omitempty"`
+}
+
+// XMLReference associa uma NFe ao caminho do seu XML no armazenamento
+type XMLReference struct {
+	ChaveAcesso string `json:"chave_acesso" db:"chave_acesso"`
+	XMLPath     string `json:"xml_path" db:"xml_path"`
+}
+
+// StorageConsistencyReport representa o resultado da verificação entre banco e disco
+type StorageConsistencyReport struct {
+	MissingFiles []XMLReference `json:"missing_files"`
+	OrphanFiles  []string       `json:"orphan_files"`
+	TotalNFes    int            `json:"total_nfes"`
+	TotalFiles   int            `json:"total_files"`
+	CheckedAt    time.Time      `json:"checked_at"`
+}
+
+// StorageRepairReport representa o resultado do reparo de XMLs ausentes
+type StorageRepairReport struct {
+	DryRun      bool           `json:"dry_run"`
+	Candidates  []XMLReference `json:"candidates"`
+	Recovered   []string       `json:"recovered"`
+	Invalidated []string       `json:"invalidated"`
+	Failed      []string       `json:"failed"`
+	RepairedAt  time.Time      `json:"repaired_at"`
+}
+
+// StorageCleanupReport representa o resultado da limpeza de XMLs fora do prazo de retenção
+type StorageCleanupReport struct {
+	DryRun         bool           `json:"dry_run"`
+	RetentionYears int            `json:"retention_years"`
+	Cutoff         time.Time      `json:"cutoff"`
+	Candidates     []XMLReference `json:"candidates"`
+	Archived       []string       `json:"archived"`
+	Deleted        []string       `json:"deleted"`
+	NotBackedUp    []string       `json:"not_backed_up"`
+	Failed         []string       `json:"failed"`
+	CleanedAt      time.Time      `json:"cleaned_at"`
+}
+
+// CertificateInfo descreve o certificado digital em uso por um CNPJ
+type CertificateInfo struct {
+	CNPJ      string    `json:"cnpj"`
+	Subject   string    `json:"subject"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// CertificateReloadReport representa o resultado da recarga dos certificados digitais
+type CertificateReloadReport struct {
+	Certificates []CertificateInfo `json:"certificates"`
+	ReloadedAt   time.Time         `json:"reloaded_at"`
+}
+
+// SelfCheckStatus é o resultado de um item da verificação de inicialização
+type SelfCheckStatus string
+
+const (
+	SelfCheckOK SelfCheckStatus = "ok"
+	// SelfCheckWarn indica um problema que não impede a inicialização
+	SelfCheckWarn SelfCheckStatus = "warn"
+	// SelfCheckFail indica um problema que impede a inicialização
+	SelfCheckFail SelfCheckStatus = "fail"
+)
+
+// severity ordena os resultados do melhor para o pior
+func (s SelfCheckStatus) severity() int {
+	switch s {
+	case SelfCheckFail:
+		return 2
+	case SelfCheckWarn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SelfCheck é o resultado de um item da verificação de inicialização
+type SelfCheck struct {
+	Name   string          `json:"name"`
+	Status SelfCheckStatus `json:"status"`
+	Detail string          `json:"detail,omitempty"`
+}
+
+// SelfCheckReport é o resultado da verificação de inicialização. Status é o
+// pior resultado entre os itens.
+type SelfCheckReport struct {
+	CheckedAt time.Time       `json:"checked_at"`
+	Status    SelfCheckStatus `json:"status"`
+	Checks    []SelfCheck     `json:"checks"`
+}
+
+// Add registra o resultado de um item e atualiza o status geral
+func (r *SelfCheckReport) Add(name string, status SelfCheckStatus, detail string) {
+	r.Checks = append(r.Checks, SelfCheck{Name: name, Status: status, Detail: detail})
+	if r.Status == "" || status.severity() > r.Status.severity() {
+		r.Status = status
+	}
+}
+
+// Failed retorna os nomes dos itens que impedem a inicialização
+func (r *SelfCheckReport) Failed() []string {
+	failed := []string{}
+	for _, check := range r.Checks {
+		if check.Status == SelfCheckFail {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+// MovementDirection indica se a movimentação de estoque é uma entrada ou saída
+type MovementDirection string
+
+const (
+	MovementEntrada MovementDirection = "entrada"
+	MovementSaida   MovementDirection = "saida"
+)
+
+// InventoryMovement representa a movimentação de estoque gerada por um item de NFe,
+// do ponto de vista do CNPJ configurado
+type InventoryMovement struct {
+	ChaveAcesso   string            `json:"chave_acesso"`
+	Numero        string            `json:"numero"`
+	DataEmissao   time.Time         `json:"data_emissao"`
+	Origem        NFeOrigem         `json:"origem"`
+	Item          int               `json:"item"`
+	CodigoProduto string            `json:"codigo_produto"`
+	Descricao     string            `json:"descricao"`
+	NCM           string            `json:"ncm"`
+	CFOP          string            `json:"cfop"`
+	Unidade       string            `json:"unidade"`
+	Quantidade    float64           `json:"quantidade"`
+	Valor         Money             `json:"valor"`
+	Direcao       MovementDirection `json:"direcao"`
+}
+
+// XMLExport é o arquivo ZIP com os XMLs das NFes exportadas. As NFes cujo XML
+// não pôde ser incluído ficam em Missing e no manifesto _errors.txt do ZIP.
+type XMLExport struct {
+	Data     []byte
+	Included int
+	Missing  []XMLExportError
+}
+
+// XMLExportError identifica uma NFe que ficou de fora da exportação e o motivo
+type XMLExportError struct {
+	ChaveAcesso string
+	Reason      string
+}
+
+// ConflictPolicy define o comportamento do cadastro quando a chave de acesso já existe
+type ConflictPolicy string
+
+const (
+	// ConflictSkip mantém a NFe já cadastrada sem alterações
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictUpdate sobrescreve a NFe cadastrada com os dados recebidos
+	ConflictUpdate ConflictPolicy = "update"
+)
+
+// IsValid verifica se a política é válida
+func (p ConflictPolicy) IsValid() bool {
+	return p == ConflictSkip || p == ConflictUpdate
+}
+
+// CancelledWithoutXMLPolicy define o tratamento das NFes já canceladas antes do
+// primeiro download, para as quais a SEFAZ entrega apenas o evento de cancelamento
+type CancelledWithoutXMLPolicy string
+
+const (
+	// CancelledWithoutXMLStore grava a NFe como cancelada com o XML do evento
+	CancelledWithoutXMLStore CancelledWithoutXMLPolicy = "store"
+	// CancelledWithoutXMLResumo registra a NFe pelo resumo, aguardando o XML completo
+	CancelledWithoutXMLResumo CancelledWithoutXMLPolicy = "resumo"
+)
+
+// IsValid verifica se a política é válida
+func (p CancelledWithoutXMLPolicy) IsValid() bool {
+	return p == CancelledWithoutXMLStore || p == CancelledWithoutXMLResumo
+}
+
+// TimestampSource define qual relógio preenche created_at e updated_at das NFes
+type TimestampSource string
+
+const (
+	// TimestampApp usa o relógio da aplicação
+	TimestampApp TimestampSource = "app"
+	// TimestampDatabase usa o relógio do banco, o mesmo para todas as réplicas
+	TimestampDatabase TimestampSource = "database"
+)
+
+// IsValid verifica se a opção é válida
+func (s TimestampSource) IsValid() bool {
+	return s == TimestampApp || s == TimestampDatabase
+}
+
+// StorageShardBy define qual data organiza os XMLs nos diretórios ano/mês
+type StorageShardBy string
+
+const (
+	// ShardByEmissao usa a data de emissão da NFe
+	ShardByEmissao StorageShardBy = "emissao"
+	// ShardByRecebimento usa a data em que o XML foi baixado, mantendo juntos os
+	// arquivos recebidos recentemente mesmo quando a NFe é antiga
+	ShardByRecebimento StorageShardBy = "recebimento"
+)
+
+// IsValid verifica se a opção é válida
+func (s StorageShardBy) IsValid() bool {
+	return s == ShardByEmissao || s == ShardByRecebimento
+}
+
+// XMLStorageBackend define onde os XMLs das NFes são guardados
+type XMLStorageBackend string
+
+const (
+	// XMLStorageDisk grava os XMLs em arquivos no diretório de armazenamento
+	XMLStorageDisk XMLStorageBackend = "disk"
+	// XMLStorageDatabase grava os XMLs compactados no próprio banco, para
+	// implantações sem sistema de arquivos persistente
+	XMLStorageDatabase XMLStorageBackend = "database"
+)
+
+// IsValid verifica se o backend é válido
+func (b XMLStorageBackend) IsValid() bool {
+	return b == XMLStorageDisk || b == XMLStorageDatabase
+}
+
+// ItemConflictStrategy define como os itens de uma NFe baixada novamente são regravados
+type ItemConflictStrategy string
+
+const (
+	// ItemConflictReplace apaga os itens cadastrados e insere os do XML
+	ItemConflictReplace ItemConflictStrategy = "replace"
+	// ItemConflictUpsert atualiza os itens pelo número do item e remove os que
+	// não constam mais no XML
+	ItemConflictUpsert ItemConflictStrategy = "upsert"
+)
+
+// IsValid verifica se a estratégia é válida
+func (s ItemConflictStrategy) IsValid() bool {
+	return s == ItemConflictReplace || s == ItemConflictUpsert
+}
+
+// Limites da inutilização de numeração definidos no leiaute da NFe
+const (
+	maxSerie             = 999
+	maxNumeroNFe         = 999999999
+	minJustificativaSize = 15
+	maxJustificativaSize = 255
+)
+
+// InutilizacaoRequest representa o pedido de inutilização de uma faixa de
+// números de NFe (modelo 55) não utilizados. CNPJ é opcional e, quando vazio,
+// vale o CNPJ principal.
+type InutilizacaoRequest struct {
+	CNPJ          string `json:"cnpj"`
+	Serie         int    `json:"serie"`
+	NumeroInicial int    `json:"numero_inicial"`
+	NumeroFinal   int    `json:"numero_final"`
+	Justificativa string `json:"justificativa"`
+}
+
+// Validate valida o pedido e retorna um *ValidationError com todos os campos inválidos
+func (r *InutilizacaoRequest) Validate() error {
+	r.Justificativa = strings.TrimSpace(r.Justificativa)
+
+	verr := &ValidationError{}
+	if r.CNPJ != "" && (len(r.CNPJ) != 14 || strings.Trim(r.CNPJ, "0123456789") != "") {
+		verr.Add("cnpj", "cnpj deve ter 14 dígitos", nil)
+	}
+	if r.Serie < 0 || r.Serie > maxSerie {
+		verr.Add("serie", "serie deve estar entre 0 e 999", nil)
+	}
+	if r.NumeroInicial < 1 || r.NumeroInicial > maxNumeroNFe {
+		verr.Add("numero_inicial", "numero_inicial deve estar entre 1 e 999999999", nil)
+	}
+	if r.NumeroFinal < 1 || r.NumeroFinal > maxNumeroNFe {
+		verr.Add("numero_final", "numero_final deve estar entre 1 e 999999999", nil)
+	} else if r.NumeroFinal < r.NumeroInicial {
+		verr.Add("numero_final", "numero_final deve ser igual ou maior que numero_inicial", nil)
+	}
+	if size := utf8.RuneCountInString(r.Justificativa); size < minJustificativaSize || size > maxJustificativaSize {
+		verr.Add("justificativa", "justificativa deve ter entre 15 e 255 caracteres", nil)
+	}
+	return verr.Err()
+}
+
+// InutilizacaoStatus representa o resultado de um pedido de inutilização
+type InutilizacaoStatus string
+
+const (
+	// InutilizacaoHomologada indica que a SEFAZ inutilizou a faixa (cStat 102)
+	InutilizacaoHomologada InutilizacaoStatus = "homologada"
+	// InutilizacaoRejeitada indica que a SEFAZ recusou o pedido
+	InutilizacaoRejeitada InutilizacaoStatus = "rejeitada"
+)
+
+// Inutilizacao representa um pedido de inutilização processado pela SEFAZ.
+// Ano são os dois últimos dígitos do ano do pedido.
+type Inutilizacao struct {
+	ID              uuid.UUID          `json:"id" db:"id"`
+	CNPJ            string             `json:"cnpj" db:"cnpj"`
+	Ano             string             `json:"ano" db:"ano"`
+	Modelo          string             `json:"modelo" db:"modelo"`
+	Serie           int                `json:"serie" db:"serie"`
+	NumeroInicial   int                `json:"numero_inicial" db:"numero_inicial"`
+	NumeroFinal     int                `json:"numero_final" db:"numero_final"`
+	Justificativa   string             `json:"justificativa" db:"justificativa"`
+	Status          InutilizacaoStatus `json:"status" db:"status"`
+	CStat           int                `json:"cstat" db:"cstat"`
+	Motivo          string             `json:"motivo" db:"motivo"`
+	Protocolo       string             `json:"protocolo,omitempty" db:"protocolo"`
+	DataRecebimento *time.Time         `json:"data_recebimento,omitempty" db:"data_recebimento"`
+	CreatedAt       time.Time          `json:"created_at" db:"created_at"`
+}
+
+// ManifestacaoTipo representa o evento de manifestação do destinatário
+type ManifestacaoTipo string
+
+const (
+	// ManifestacaoCiencia registra a ciência da operação (210210)
+	ManifestacaoCiencia ManifestacaoTipo = "ciencia"
+	// ManifestacaoConfirmacao confirma a operação (210200)
+	ManifestacaoConfirmacao ManifestacaoTipo = "confirmacao"
+	// ManifestacaoDesconhecimento declara o desconhecimento da operação (210220)
+	ManifestacaoDesconhecimento ManifestacaoTipo = "desconhecimento"
+	// ManifestacaoNaoRealizada declara que a operação não foi realizada (210240)
+	ManifestacaoNaoRealizada ManifestacaoTipo = "nao_realizada"
+)
+
+// manifestacaoEventos mapeia cada manifestação para o tpEvento e o descEvento do leiaute
+var manifestacaoEventos = map[ManifestacaoTipo][2]string{
+	ManifestacaoCiencia:         {"210210", "Ciencia da Operacao"},
+	ManifestacaoConfirmacao:     {"210200", "Confirmacao da Operacao"},
+	ManifestacaoDesconhecimento: {"210220", "Desconhecimento da Operacao"},
+	ManifestacaoNaoRealizada:    {"210240", "Operacao nao Realizada"},
+}
+
+// IsValid verifica se o tipo de manifestação é válido
+func (t ManifestacaoTipo) IsValid() bool {
+	_, ok := manifestacaoEventos[t]
+	return ok
+}
+
+// TpEvento retorna o código do evento no leiaute (ex: 210200)
+func (t ManifestacaoTipo) TpEvento() string {
+	return manifestacaoEventos[t][0]
+}
+
+// DescEvento retorna a descrição do evento exigida pelo leiaute
+func (t ManifestacaoTipo) DescEvento() string {
+	return manifestacaoEventos[t][1]
+}
+
+// maxManifestacaoChaves limita as chaves de um pedido de manifestação em lote
+const maxManifestacaoChaves = 500
+
+// ManifestacaoBatchRequest representa o pedido de manifestação de várias NFes
+// recebidas com o mesmo evento. Justificativa é obrigatória apenas para
+// nao_realizada. CNPJ é opcional e, quando vazio, vale o CNPJ principal.
+type ManifestacaoBatchRequest struct {
+	CNPJ          string           `json:"cnpj"`
+	Tipo          ManifestacaoTipo `json:"tipo"`
+	Chaves        []string         `json:"chaves"`
+	Justificativa string           `json:"justificativa"`
+}
+
+// Validate valida o pedido, remove chaves repetidas e retorna um
+// *ValidationError com todos os campos inválidos
+func (r *ManifestacaoBatchRequest) Validate() error {
+	r.Justificativa = strings.TrimSpace(r.Justificativa)
+
+	verr := &ValidationError{}
+	if r.CNPJ != "" && (len(r.CNPJ) != 14 || strings.Trim(r.CNPJ, "0123456789") != "") {
+		verr.Add("cnpj", "cnpj deve ter 14 dígitos", nil)
+	}
+	if !r.Tipo.IsValid() {
+		verr.Add("tipo", "tipo de manifestação inválido: "+string(r.Tipo), nil)
+	}
+
+	seen := make(map[string]bool, len(r.Chaves))
+	chaves := make([]string, 0, len(r.Chaves))
+	for _, chave := range r.Chaves {
+		chave = strings.TrimSpace(chave)
+		if len(chave) != 44 || strings.Trim(chave, "0123456789") != "" {
+			verr.Add("chaves", "chave de acesso inválida: "+chave, nil)
+			continue
+		}
+		if !seen[chave] {
+			seen[chave] = true
+			chaves = append(chaves, chave)
+		}
+	}
+	r.Chaves = chaves
+	if len(r.Chaves) == 0 || len(r.Chaves) > maxManifestacaoChaves {
+		verr.Add("chaves", "informe entre 1 e 500 chaves de acesso", nil)
+	}
+
+	if r.Tipo == ManifestacaoNaoRealizada {
+		if size := utf8.RuneCountInString(r.Justificativa); size < minJustificativaSize || size > maxJustificativaSize {
+			verr.Add("justificativa", "justificativa deve ter entre 15 e 255 caracteres", nil)
+		}
+	} else {
+		r.Justificativa = ""
+	}
+	return verr.Err()
+}
+
+// ManifestacaoStatus representa o resultado da manifestação de uma NFe
+type ManifestacaoStatus string
+
+const (
+	// ManifestacaoRegistrada indica que a SEFAZ registrou o evento (cStat 135 ou 136)
+	ManifestacaoRegistrada ManifestacaoStatus = "registrada"
+	// ManifestacaoRejeitada indica que a SEFAZ recusou o evento
+	ManifestacaoRejeitada ManifestacaoStatus = "rejeitada"
+	// ManifestacaoNaoEnviada indica que o evento não chegou a ser enviado,
+	// porque uma falha de comunicação interrompeu o lote
+	ManifestacaoNaoEnviada ManifestacaoStatus = "nao_enviada"
+)
+
+// ManifestacaoResult representa o resultado da manifestação de uma NFe
+type ManifestacaoResult struct {
+	ChaveAcesso  string             `json:"chave_acesso"`
+	Status       ManifestacaoStatus `json:"status"`
+	CStat        int                `json:"cstat,omitempty"`
+	Motivo       string             `json:"motivo"`
+	Protocolo    string             `json:"protocolo,omitempty"`
+	DataRegistro *time.Time         `json:"data_registro,omitempty"`
+}
+
+// ProtocoloNFe representa a situação atual da NFe na SEFAZ autorizadora,
+// retornada pela consulta protocolo
+type ProtocoloNFe struct {
+	ChaveAcesso string    `json:"chave_acesso"`
+	Status      NFeStatus `json:"status"`
+	CStat       int       `json:"cstat"`
+	Motivo      string    `json:"motivo"`
+	Protocolo   string    `json:"protocolo,omitempty"`
+	// DigestValue é o digVal do protocolo, o digest do infNFe autorizado
+	DigestValue        string     `json:"-"`
+	DataAutorizacao    *time.Time `json:"data_autorizacao,omitempty"`
+	DataCancelamento   *time.Time `json:"data_cancelamento,omitempty"`
+	MotivoCancelamento string     `json:"motivo_cancelamento,omitempty"`
+	// StatusArmazenado é o status da NFe cadastrada; vazio quando a chave não
+	// está no banco
+	StatusArmazenado NFeStatus `json:"status_armazenado,omitempty"`
+	// Divergente indica que o status cadastrado não corresponde ao da SEFAZ
+	Divergente bool `json:"divergente"`
+}
+
+// Cancelamento é o cancelamento de uma NFe informado pela SEFAZ, com a data
+// e a justificativa do evento
+type Cancelamento struct {
+	ChaveAcesso string
+	// Data é nil quando a SEFAZ não informa o evento
+	Data   *time.Time
+	Motivo string
+}
+
+// StatusMatches indica se o status cadastrado corresponde ao status na SEFAZ.
+// Uma NFe suspeita está autorizada na SEFAZ.
+func (p *ProtocoloNFe) StatusMatches(status NFeStatus) bool {
+	if status == NFeStatusSuspeita {
+		status = NFeStatusAutorizada
+	}
+	return status == p.Status
+}
+
+// ManifestacaoBatch reúne os resultados de uma manifestação em lote, na ordem
+// das chaves do pedido
+type ManifestacaoBatch struct {
+	Tipo        ManifestacaoTipo     `json:"tipo"`
+	Total       int                  `json:"total"`
+	Registradas int                  `json:"registradas"`
+	Rejeitadas  int                  `json:"rejeitadas"`
+	NaoEnviadas int                  `json:"nao_enviadas"`
+	Resultados  []ManifestacaoResult `json:"resultados"`
+}
+
+// Add inclui o resultado de uma chave e atualiza os totais
+func (b *ManifestacaoBatch) Add(result ManifestacaoResult) {
+	b.Resultados = append(b.Resultados, result)
+	b.Total++
+	switch result.Status {
+	case ManifestacaoRegistrada:
+		b.Registradas++
+	case ManifestacaoRejeitada:
+		b.Rejeitadas++
+	default:
+		b.NaoEnviadas++
+	}
+}
+
+// RepoTx define as operações de escrita disponíveis dentro de uma transação
+type RepoTx interface {
+	FindByChaveAcessoForUpdate(chaveAcesso string) (*NFe, error)
+	Create(nfe *NFe) error
+	Update(nfe *NFe) error
+	UpdateStatusBatch(chaves []string, status NFeStatus) (int64, error)
+	CancelBatch(cancelamentos []Cancelamento) (int64, error)
+	ReplaceItens(chaveAcesso string, itens []NFeItem) error
+	UpsertItens(chaveAcesso string, itens []NFeItem) error
+	ReplaceReferencias(chaveAcesso string, chaves []string) error
+	CreateAuditEntries(entries []NFeAuditEntry) error
+}
+
 // NFeRepository define a interface para repositório de NFes
 type NFeRepository interface {
 	Create(nfe *NFe) error
 	Update(nfe *NFe) error
+	UpdateStatusBatch(chaves []string, status NFeStatus) (int64, error)
 	FindByChaveAcesso(chaveAcesso string) (*NFe, error)
+	FindByNumero(lookup NFeLookup, limit int) ([]NFe, error)
 	FindByFilter(filter NFeFilter) ([]NFe, int64, error)
+	CountByFilter(filter NFeFilter) (*NFeCount, error)
+	FindItensByNFeIDs(ids []uuid.UUID) (map[uuid.UUID][]NFeItem, error)
+	CreateItemsBatch(itens []NFeItem) error
+	FindReferencias(chaveAcesso string) (*NFeReferencias, error)
 	ExistsByChaveAcesso(chaveAcesso string) (bool, error)
 	GetStats(startDate, endDate time.Time) (*NFeStats, error)
+	TopEmitentes(startDate, endDate time.Time, limit int) ([]EmitenteTotal, error)
+	FindEmitentes(filter EmitenteFilter) ([]Emitente, int64, error)
+	FindNumeroGaps(filter NumeroGapFilter) ([]NumeroGap, error)
+	ListXMLReferences() ([]XMLReference, error)
+	ListXMLReferencesBefore(cutoff time.Time) ([]XMLReference, error)
+	MarkXMLRemoved(chaveAcesso, xmlPath string) error
+	// MarkXMLFilesMissing marca como ausente o arquivo das NFes informadas e
+	// desmarca as demais
+	MarkXMLFilesMissing(chaves []string) (int64, error)
+	WithTx(fn func(tx RepoTx) error) error
 }
 
 // NFeService define a interface para serviço de NFes
 type NFeService interface {
 	SyncNFes() (*SyncJob, error)
-	ListNFes(filter NFeFilter) (*NFePaginatedResponse, error)
+	// SyncStatuses consulta na SEFAZ a situação das NFes autorizadas já
+	// cadastradas e registra os cancelamentos, sem baixar os XMLs novamente
+	SyncStatuses() (*SyncJob, error)
+	ListNFes(filter NFeFilter) (*PaginatedResponse[NFe], error)
+	ListIncompleteNFes(filter NFeFilter) (*PaginatedResponse[NFe], error)
+	CountNFes(filter NFeFilter) (*NFeCount, error)
 	GetNFeByChave(chaveAcesso string) (*NFe, error)
-	GetXMLPath(chaveAcesso string) (string, error)
+	LookupNFe(lookup NFeLookup) (*NFe, error)
+	PatchNFe(chaveAcesso string, patch NFePatch, requestID string) (*NFe, error)
+	GetXML(chaveAcesso string) ([]byte, error)
+	GetNFeProc(chaveAcesso string) ([]byte, error)
 	GetStats(startDate, endDate time.Time) (*NFeStats, error)
+	GetStatsReport(startDate, endDate time.Time) (*NFeStatsReport, error)
+	ListEmitentes(filter EmitenteFilter) (*PaginatedResponse[Emitente], error)
+	GetNumeroGaps(filter NumeroGapFilter) (*NumeroGaps, error)
+	ListQuarantine(filter QuarantineFilter) (*PaginatedResponse[QuarantinedNFe], error)
+	CheckStorageConsistency() (*StorageConsistencyReport, error)
+	RepairStorage(dryRun bool) (*StorageRepairReport, error)
+	CleanupStorage(dryRun bool) (*StorageCleanupReport, error)
+	ExportInventoryMovements(filter NFeFilter) ([]InventoryMovement, error)
+	ExportXMLs(filter NFeFilter) (*XMLExport, error)
+	GetSefazStatus() SefazStatus
+	VerifyNFe(chaveAcesso string) (*NFeVerification, error)
+	// ConsultarProtocolo consulta a situação atual da NFe na SEFAZ e a compara
+	// com a NFe cadastrada, quando existe
+	ConsultarProtocolo(chaveAcesso string) (*ProtocoloNFe, error)
+	ValidateXML(data []byte) (*XMLValidation, error)
+	ImportXML(data []byte) (*NFe, error)
+	GetNFeReferencias(chaveAcesso string) (*NFeReferencias, error)
+	GetSyncHealth() (*SyncHealth, error)
+	GetSyncJobErrors(jobID uuid.UUID) (*SyncJobErrors, error)
+	GetSyncJobRetention() SyncJobRetention
+	GetVersionInfo() (*VersionInfo, error)
+	CleanupSyncJobs() (int64, error)
+	StartReprocess(fields []ReprocessField) (*ReprocessJob, error)
+	GetReprocessJob(id uuid.UUID) (*ReprocessJob, error)
+	ReloadCertificates() (*CertificateReloadReport, error)
+	// RunSelfCheck verifica banco, migrations, certificados, armazenamento e
+	// SEFAZ e guarda o resultado, retornado depois por GetSelfCheck
+	RunSelfCheck() *SelfCheckReport
+	GetSelfCheck() (*SelfCheckReport, error)
+	InutilizarNumeracao(req InutilizacaoRequest) (*Inutilizacao, error)
+	ManifestarBatch(req ManifestacaoBatchRequest) (*ManifestacaoBatch, error)
+}
+
+// NSUCursorRepository define a interface para persistência do cursor de NSU
+// da distribuição DFe
+type NSUCursorRepository interface {
+	GetUltNSU(cnpj string) (string, error)
+	SaveUltNSU(cnpj, ultNSU string) error
+}
+
+// SyncJobRepository define a interface para persistência do histórico de sincronizações
+type SyncJobRepository interface {
+	Save(job *SyncJob) error
+	FindByID(id uuid.UUID) (*SyncJob, error)
+	FindLast() (*SyncJob, error)
+	FindLastSuccess() (*SyncJob, error)
+	SaveError(jobErr *SyncJobError) error
+	FindErrors(jobID uuid.UUID) ([]SyncJobError, error)
+	// FindPendingResumos lista os resumos das NFes do CNPJ que falharam na
+	// gravação e ainda não foram processadas novamente
+	FindPendingResumos(cnpj string) ([]NFeResumo, error)
+	// ResolveErrors encerra as pendências da NFe registradas em jobs
+	// diferentes de jobID
+	ResolveErrors(jobID uuid.UUID, cnpj, chaveAcesso string) error
+	DeleteStartedBefore(cutoff time.Time) (int64, error)
+}
+
+// DownloadFailureRepository define a interface para persistência dos
+// documentos da distribuição DFe que falharam
+type DownloadFailureRepository interface {
+	Save(failure *DownloadFailure) error
+}
+
+// QuarantineRepository define a interface para persistência das NFes em
+// quarentena por divergência de ambiente
+type QuarantineRepository interface {
+	Save(nfe *QuarantinedNFe) error
+	FindAll(filter QuarantineFilter) ([]QuarantinedNFe, int64, error)
+}
+
+// ReprocessJobRepository define a interface para persistência dos jobs de
+// reprocessamento
+type ReprocessJobRepository interface {
+	Save(job *ReprocessJob) error
+	FindByID(id uuid.UUID) (*ReprocessJob, error)
+}
+
+// IdempotencyRepository define a interface para persistência das chaves de
+// idempotência das requisições
+type IdempotencyRepository interface {
+	// Reserve grava a chave como em processamento. Quando já existe um registro
+	// criado a partir de notBefore, ele é retornado e nada é gravado.
+	Reserve(record *IdempotencyRecord, notBefore time.Time) (*IdempotencyRecord, error)
+	Complete(record *IdempotencyRecord) error
+	DeleteBefore(cutoff time.Time) (int64, error)
+}
+
+// InutilizacaoRepository define a interface para persistência dos pedidos de inutilização
+type InutilizacaoRepository interface {
+	Save(inutilizacao *Inutilizacao) error
+	FindHomologadas(cnpj string, serie int) ([]Inutilizacao, error)
+}
+
+// XMLStorage guarda os XMLs das NFes. A referência retornada por Save é gravada
+// em xml_path e identifica o XML em Read e Exists.
+type XMLStorage interface {
+	Backend() XMLStorageBackend
+	// Save grava o XML da NFe; currentRef é a referência atual, vazia quando a
+	// NFe ainda não tem XML
+	Save(chave string, dataEmissao time.Time, currentRef string, data []byte) (string, error)
+	Read(ref string) ([]byte, error)
+	Exists(ref string) (bool, error)
+	// Check verifica se é possível gravar no armazenamento
+	Check() error
+}
+
+// SchemaVersionRepository consulta a versão do schema registrada pelas migrations
+type SchemaVersionRepository interface {
+	// Current retorna nil quando nenhuma migration foi aplicada
+	Current() (*SchemaVersion, error)
+}
+
+// SyncAlerter notifica falhas de sincronização para acompanhamento em tempo real
+type SyncAlerter interface {
+	NotifySync(job *SyncJob) error
 }
 
 // SefazClient define a interface para cliente SEFAZ
 type SefazClient interface {
-	ConsultarNFes(cnpj string, dataInicio, dataFim time.Time) ([]string, error)
+	ConsultarNFes(cnpj, ultNSU string) (*ConsultaNFes, error)
 	DownloadXML(chaveAcesso string) ([]byte, error)
-}
\ No newline at end of file
+	CircuitStatus() CircuitStatus
+	// Ping verifica se o web service da distribuição DFe responde, sem passar
+	// pelo circuit breaker
+	Ping() error
+	// SetCertificate passa a usar o certificado informado nas próximas chamadas
+	SetCertificate(cert tls.Certificate) error
+	// InutilizarNumeracao envia o pedido de inutilização assinado com o
+	// certificado do cliente. Rejeições de negócio retornam o resultado com
+	// status rejeitada, não um erro.
+	InutilizarNumeracao(req InutilizacaoRequest) (*Inutilizacao, error)
+	// Manifestar envia em um único lote os eventos de manifestação das chaves,
+	// assinados com o certificado do cliente, e retorna o resultado de cada uma.
+	// Como na inutilização, rejeições de negócio não são erros.
+	Manifestar(tipo ManifestacaoTipo, chaves []string, justificativa string) ([]ManifestacaoResult, error)
+	// ConsultarProtocolo consulta a situação atual da NFe na SEFAZ autorizadora
+	// da UF da chave. Uma chave desconhecida pela SEFAZ retorna
+	// ErrNFeNotFoundAtSefaz.
+	ConsultarProtocolo(chaveAcesso string) (*ProtocoloNFe, error)
+}
+
+// CircuitState representa o estado do circuit breaker das chamadas à SEFAZ
+type CircuitState string
+
+const (
+	// CircuitClosed permite as chamadas normalmente
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejeita as chamadas até o fim do cooldown
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen permite uma chamada de teste para verificar a recuperação
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitStatus representa o estado atual do circuit breaker da SEFAZ
+type CircuitStatus struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Threshold           int          `json:"threshold"`
+	Cooldown            string       `json:"cooldown"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+}
+
+// SefazStatus reúne o estado dos circuit breakers de todos os CNPJs, cada um
+// com o próprio cliente SEFAZ
+type SefazStatus struct {
+	// State é o pior estado entre os CNPJs: open, half_open e closed, nessa ordem
+	State CircuitState `json:"state"`
+	// OpenCNPJs lista os CNPJs com o circuito aberto
+	OpenCNPJs []string `json:"open_cnpjs"`
+	// Companies traz o estado de cada CNPJ
+	Companies map[string]CircuitStatus `json:"companies"`
+}
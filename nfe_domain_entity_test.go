@@ -0,0 +1,294 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortStatusCounts(t *testing.T) {
+	counts := []NFeStatusCount{
+		{Status: NFeStatusProcessando, Count: 3},
+		{Status: "desconhecido", Count: 1},
+		{Status: NFeStatusRejeitada, Count: 2},
+		{Status: NFeStatusAutorizada, Count: 10},
+		{Status: NFeStatusCancelada, Count: 4},
+	}
+
+	SortStatusCounts(counts)
+
+	assert.Equal(t, []NFeStatusCount{
+		{Status: NFeStatusAutorizada, Count: 10},
+		{Status: NFeStatusCancelada, Count: 4},
+		{Status: NFeStatusRejeitada, Count: 2},
+		{Status: NFeStatusProcessando, Count: 3},
+		{Status: "desconhecido", Count: 1},
+	}, counts)
+}
+
+func TestInutilizacaoRequestValidate(t *testing.T) {
+	req := InutilizacaoRequest{
+		Serie:         1,
+		NumeroInicial: 10,
+		NumeroFinal:   12,
+		Justificativa: "  Falha na numeração do sistema emissor  ",
+	}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "Falha na numeração do sistema emissor", req.Justificativa)
+
+	req = InutilizacaoRequest{
+		CNPJ:          "1234",
+		Serie:         1000,
+		NumeroInicial: 12,
+		NumeroFinal:   10,
+		Justificativa: "curta",
+	}
+	err := req.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+
+	fields := []string{}
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"cnpj", "serie", "numero_final", "justificativa"}, fields)
+}
+
+func TestManifestacaoBatchRequestValidate(t *testing.T) {
+	chave := "35251234567890123456789012345678901234567890"
+	req := ManifestacaoBatchRequest{
+		Tipo:          ManifestacaoConfirmacao,
+		Chaves:        []string{chave, " " + chave + " "},
+		Justificativa: "ignorada fora de nao_realizada",
+	}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, []string{chave}, req.Chaves)
+	assert.Equal(t, "", req.Justificativa)
+	assert.Equal(t, "210200", req.Tipo.TpEvento())
+
+	req = ManifestacaoBatchRequest{
+		CNPJ:   "1234",
+		Tipo:   ManifestacaoNaoRealizada,
+		Chaves: []string{"123"},
+	}
+	err := req.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+
+	fields := []string{}
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"cnpj", "chaves", "chaves", "justificativa"}, fields)
+
+	req = ManifestacaoBatchRequest{Tipo: "cancelamento", Chaves: []string{chave}}
+	assert.Error(t, req.Validate())
+}
+
+func TestManifestacaoBatchAdd(t *testing.T) {
+	batch := &ManifestacaoBatch{Tipo: ManifestacaoCiencia}
+	batch.Add(ManifestacaoResult{ChaveAcesso: "1", Status: ManifestacaoRegistrada})
+	batch.Add(ManifestacaoResult{ChaveAcesso: "2", Status: ManifestacaoRejeitada})
+	batch.Add(ManifestacaoResult{ChaveAcesso: "3", Status: ManifestacaoNaoEnviada})
+	batch.Add(ManifestacaoResult{ChaveAcesso: "4", Status: ManifestacaoRegistrada})
+
+	assert.Equal(t, 4, batch.Total)
+	assert.Equal(t, 2, batch.Registradas)
+	assert.Equal(t, 1, batch.Rejeitadas)
+	assert.Equal(t, 1, batch.NaoEnviadas)
+	assert.Len(t, batch.Resultados, 4)
+}
+
+func TestSelfCheckReportAdd(t *testing.T) {
+	report := &SelfCheckReport{}
+	report.Add("database", SelfCheckOK, "")
+	assert.Equal(t, SelfCheckOK, report.Status)
+
+	report.Add("sefaz", SelfCheckWarn, "timeout")
+	assert.Equal(t, SelfCheckWarn, report.Status)
+
+	report.Add("storage", SelfCheckFail, "permission denied")
+	report.Add("migrations", SelfCheckOK, "")
+	assert.Equal(t, SelfCheckFail, report.Status)
+	assert.Equal(t, []string{"storage"}, report.Failed())
+	assert.Len(t, report.Checks, 4)
+}
+
+func TestNFeFilterValidate_NumeroSerie(t *testing.T) {
+	filter := NFeFilter{Numero: "000123", Serie: "001"}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, "123", filter.Numero)
+	assert.Equal(t, "1", filter.Serie)
+
+	filter = NFeFilter{Serie: "000"}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, "0", filter.Serie)
+
+	filter = NFeFilter{Numero: "12a", Serie: "1000"}
+	err := filter.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+
+	fields := []string{}
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"numero", "serie"}, fields)
+}
+
+func TestNFeFilterValidate_UFEmitente(t *testing.T) {
+	filter := NFeFilter{UFEmitente: " sp "}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, "SP", filter.UFEmitente)
+
+	for _, uf := range []string{"S", "SPA", "3S"} {
+		filter = NFeFilter{UFEmitente: uf}
+		assert.Error(t, filter.Validate(), uf)
+	}
+}
+
+func TestNFeFilterValidate_CRT(t *testing.T) {
+	filter := NFeFilter{CRTs: []CRT{CRTSimplesNacional, CRTRegimeNormal}}
+	assert.NoError(t, filter.Validate())
+
+	for _, crt := range []CRT{"0", "5", "simples"} {
+		filter = NFeFilter{CRTs: []CRT{crt}}
+		assert.Error(t, filter.Validate(), string(crt))
+	}
+}
+
+func TestNFeFilterValidate_TipoOperacao(t *testing.T) {
+	for _, tipo := range []TipoOperacao{"", TipoOperacaoEntrada, TipoOperacaoSaida} {
+		filter := NFeFilter{TipoOperacao: tipo}
+		assert.NoError(t, filter.Validate(), string(tipo))
+	}
+
+	filter := NFeFilter{TipoOperacao: "1"}
+	assert.Error(t, filter.Validate())
+}
+
+func TestCRTIsSimplesNacional(t *testing.T) {
+	for _, crt := range []CRT{CRTSimplesNacional, CRTSimplesNacionalExcesso, CRTSimplesNacionalMEI} {
+		assert.True(t, crt.IsSimplesNacional(), string(crt))
+	}
+	assert.False(t, CRTRegimeNormal.IsSimplesNacional())
+	assert.False(t, CRT("").IsSimplesNacional())
+}
+
+func TestCancelledXMLError(t *testing.T) {
+	err := fmt.Errorf("download xml: %w", &CancelledXMLError{EventXML: []byte("<procEventoNFe/>")})
+	assert.ErrorIs(t, err, ErrXMLUnavailable)
+
+	var cancelled *CancelledXMLError
+	assert.True(t, errors.As(err, &cancelled))
+	assert.Equal(t, "<procEventoNFe/>", string(cancelled.EventXML))
+}
+
+func TestNFeFilterValidate_Projection(t *testing.T) {
+	for _, projection := range []NFeProjection{"", NFeProjectionFull, NFeProjectionSummary} {
+		filter := NFeFilter{Projection: projection}
+		assert.NoError(t, filter.Validate(), string(projection))
+	}
+
+	filter := NFeFilter{Projection: "minimal"}
+	assert.Error(t, filter.Validate())
+}
+
+func TestNFeLookupValidate(t *testing.T) {
+	lookup := NFeLookup{CNPJ: "12345678000100", Numero: "000123", Serie: "1"}
+	assert.NoError(t, lookup.Validate())
+	assert.Equal(t, "123", lookup.Numero)
+
+	lookup = NFeLookup{CNPJ: "123", Modelo: "57"}
+	err := lookup.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+
+	fields := []string{}
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"cnpj", "numero", "serie", "modelo"}, fields)
+}
+
+func TestParseReprocessFields(t *testing.T) {
+	fields, err := ParseReprocessFields(" Protocolo,uf,,protocolo ")
+	assert.NoError(t, err)
+	assert.Equal(t, []ReprocessField{ReprocessProtocolo, ReprocessUF}, fields)
+
+	for _, raw := range []string{"", " , ", "totais", "protocolo,totais"} {
+		_, err := ParseReprocessFields(raw)
+		_, ok := err.(*ValidationError)
+		assert.True(t, ok, raw)
+	}
+}
+
+func TestNFePatchApply(t *testing.T) {
+	nome := "  Indústria São João LTDA  "
+	motivo := "Erro de digitação"
+	patch := NFePatch{NomeEmitente: &nome, MotivoCancelamento: &motivo}
+	assert.NoError(t, patch.Validate())
+
+	nfe := &NFe{NomeEmitente: "INDUSTRIA S?O JO?O LTDA", MotivoCancelamento: motivo}
+	changes := patch.Apply(nfe)
+
+	assert.Equal(t, "Indústria São João LTDA", nfe.NomeEmitente)
+	assert.Equal(t, []NFeAuditEntry{
+		{Field: "nome_emitente", OldValue: "INDUSTRIA S?O JO?O LTDA", NewValue: "Indústria São João LTDA"},
+	}, changes)
+
+	empty := " "
+	patch = NFePatch{NomeEmitente: &empty}
+	assert.Error(t, patch.Validate())
+	assert.Error(t, (&NFePatch{}).Validate())
+}
+
+func TestNewPaginatedResponse(t *testing.T) {
+	page := NewPaginatedResponse[Emitente](nil, 3, 20, 41)
+
+	body, err := json.Marshal(page)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data":[],"pagination":{"page":3,"limit":20,"total":41}}`, string(body))
+}
+
+func TestNumeroGapFilterValidate(t *testing.T) {
+	filter := NumeroGapFilter{CNPJ: "12345678000100", Serie: "001"}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, "1", filter.Serie)
+
+	filter = NumeroGapFilter{CNPJ: "123"}
+	err := filter.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Len(t, verr.Fields, 2)
+}
+
+func TestNumeroGapCoveredBy(t *testing.T) {
+	gap := NumeroGap{NumeroInicial: 10, NumeroFinal: 20}
+
+	assert.False(t, gap.CoveredBy(nil))
+	assert.True(t, gap.CoveredBy([]Inutilizacao{{NumeroInicial: 5, NumeroFinal: 25}}))
+	// Faixas contíguas informadas fora de ordem
+	assert.True(t, gap.CoveredBy([]Inutilizacao{
+		{NumeroInicial: 16, NumeroFinal: 20},
+		{NumeroInicial: 10, NumeroFinal: 15},
+	}))
+	assert.False(t, gap.CoveredBy([]Inutilizacao{
+		{NumeroInicial: 10, NumeroFinal: 14},
+		{NumeroInicial: 16, NumeroFinal: 20},
+	}))
+}
+
+func TestProtocoloNFeStatusMatches(t *testing.T) {
+	protocolo := ProtocoloNFe{Status: NFeStatusAutorizada}
+	assert.True(t, protocolo.StatusMatches(NFeStatusAutorizada))
+	assert.True(t, protocolo.StatusMatches(NFeStatusSuspeita))
+	assert.False(t, protocolo.StatusMatches(NFeStatusProcessando))
+
+	protocolo.Status = NFeStatusCancelada
+	assert.True(t, protocolo.StatusMatches(NFeStatusCancelada))
+	assert.False(t, protocolo.StatusMatches(NFeStatusSuspeita))
+}
@@ -2,13 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
 	"nfe-sefaz-sync/pkg/logger"
 )
 
@@ -16,13 +18,30 @@ import (
 type NFeHandler struct {
 	service domain.NFeService
 	logger  *logger.Logger
+
+	// defaultExcludeStatuses são omitidos da listagem quando nenhum status é filtrado
+	defaultExcludeStatuses []domain.NFeStatus
+
+	// idempotency guarda as respostas das requisições com Idempotency-Key; nil desativa
+	idempotency    domain.IdempotencyRepository
+	idempotencyTTL time.Duration
 }
 
-// NewNFeHandler cria uma nova instância do handler
-func NewNFeHandler(service domain.NFeService, log *logger.Logger) *NFeHandler {
+// NewNFeHandler cria uma nova instância do handler. As respostas das
+// requisições com Idempotency-Key são repetidas por idempotencyTTL.
+func NewNFeHandler(
+	service domain.NFeService,
+	log *logger.Logger,
+	defaultExcludeStatuses []domain.NFeStatus,
+	idempotency domain.IdempotencyRepository,
+	idempotencyTTL time.Duration,
+) *NFeHandler {
 	return &NFeHandler{
-		service: service,
-		logger:  log,
+		service:                service,
+		logger:                 log,
+		defaultExcludeStatuses: defaultExcludeStatuses,
+		idempotency:            idempotency,
+		idempotencyTTL:         idempotencyTTL,
 	}
 }
 
@@ -30,10 +49,52 @@ func NewNFeHandler(service domain.NFeService, log *logger.Logger) *NFeHandler {
 func (h *NFeHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/nfe", func(r chi.Router) {
 		r.Post("/sync", h.SyncNFes)
+		r.Post("/sync/status-only", h.SyncStatuses)
+		r.Post("/inutilizar", h.idempotent(h.InutilizarNumeracao))
+		r.Post("/manifestacao/batch", h.idempotent(h.ManifestarBatch))
 		r.Get("/", h.ListNFes)
+		r.Get("/incomplete", h.ListIncompleteNFes)
+		r.Get("/count", h.CountNFes)
+		r.Get("/lookup", h.LookupNFe)
+		r.Get("/quarantine", h.ListQuarantine)
+		r.Post("/validate", h.ValidateXML)
+		r.Post("/import", h.ImportXML)
 		r.Get("/{chave}", h.GetNFe)
+		r.Patch("/{chave}", h.idempotent(h.PatchNFe))
 		r.Get("/{chave}/xml", h.DownloadXML)
+		r.Get("/{chave}/xml/raw", h.GetXMLRaw)
+		r.Get("/{chave}/proc", h.DownloadNFeProc)
+		r.Post("/{chave}/verify", h.VerifyNFe)
+		r.Get("/{chave}/referencias", h.GetNFeReferencias)
+		r.Get("/{chave}/protocolo", h.GetProtocolo)
 		r.Get("/stats", h.GetStats)
+		r.Get("/stats/report", h.GetStatsReport)
+		r.Get("/inventory-movements", h.ExportInventoryMovements)
+		r.Get("/export", h.ExportXMLs)
+	})
+
+	r.Get("/api/v1/emitentes", h.ListEmitentes)
+	r.Get("/api/v1/emitentes/{cnpj}/gaps", h.GetNumeroGaps)
+
+	r.Route("/api/v1/admin", func(r chi.Router) {
+		r.Get("/storage/consistency", h.CheckStorageConsistency)
+		r.Post("/storage/repair", h.RepairStorage)
+		r.Post("/storage/cleanup", h.CleanupStorage)
+		r.Post("/certificate/reload", h.ReloadCertificates)
+		r.Get("/sync/retention", h.GetSyncJobRetention)
+		r.Get("/version", h.GetVersion)
+		r.Get("/selfcheck", h.GetSelfCheck)
+		r.Post("/reprocess", h.StartReprocess)
+		r.Get("/reprocess/{id}", h.GetReprocessJob)
+	})
+
+	r.Route("/api/v1/sefaz", func(r chi.Router) {
+		r.Get("/status", h.GetSefazStatus)
+	})
+
+	r.Route("/api/v1/sync", func(r chi.Router) {
+		r.Get("/health", h.GetSyncHealth)
+		r.Get("/jobs/{id}/errors", h.GetSyncJobErrors)
 	})
 }
 
@@ -44,6 +105,7 @@ func (h *NFeHandler) RegisterRoutes(r chi.Router) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} domain.SyncJob
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/nfe/sync [post]
 func (h *NFeHandler) SyncNFes(w http.ResponseWriter, r *http.Request) {
@@ -51,8 +113,38 @@ func (h *NFeHandler) SyncNFes(w http.ResponseWriter, r *http.Request) {
 
 	job, err := h.service.SyncNFes()
 	if err != nil {
+		if errors.Is(err, domain.ErrSyncLimitReached) {
+			h.sendError(w, r, http.StatusTooManyRequests, "Limite de sincronizações simultâneas atingido", err)
+			return
+		}
 		h.logger.Error("Erro ao sincronizar NFes", "error", err)
-		h.sendError(w, http.StatusInternalServerError, "Erro ao sincronizar NFes", err)
+		h.sendError(w, r, sefazErrorStatus(err), "Erro ao sincronizar NFes", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, job)
+}
+
+// SyncStatuses atualiza a situação das NFes já cadastradas
+// @Summary Sincronizar status das NFes
+// @Description Consulta na SEFAZ o protocolo das NFes autorizadas dos últimos 30 dias e registra os cancelamentos, sem baixar os XMLs novamente. nfes_found conta as NFes atualizadas.
+// @Tags NFe
+// @Produce json
+// @Success 200 {object} domain.SyncJob
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/sync/status-only [post]
+func (h *NFeHandler) SyncStatuses(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Requisição de sincronização de status recebida")
+
+	job, err := h.service.SyncStatuses()
+	if err != nil {
+		if errors.Is(err, domain.ErrSyncLimitReached) {
+			h.sendError(w, r, http.StatusTooManyRequests, "Limite de sincronizações simultâneas atingido", err)
+			return
+		}
+		h.logger.Error("Erro ao sincronizar status das NFes", "error", err)
+		h.sendError(w, r, sefazErrorStatus(err), "Erro ao sincronizar status das NFes", err)
 		return
 	}
 
@@ -68,18 +160,142 @@ func (h *NFeHandler) SyncNFes(w http.ResponseWriter, r *http.Request) {
 // @Param page query int false "Número da página" default(1)
 // @Param limit query int false "Itens por página" default(20)
 // @Param cnpj_emitente query string false "CNPJ do emitente"
-// @Param status query string false "Status da NFe"
+// @Param status query []string false "Status da NFe (pode ser repetido)" collectionFormat(multi)
+// @Param origem query string false "Origem da NFe (emitida, recebida ou importada)"
+// @Param numero query string false "Número da NFe"
+// @Param serie query string false "Série da NFe"
+// @Param uf_emitente query string false "Sigla da UF do emitente (ex: SP)"
+// @Param tipo_operacao query string false "Tipo de operação da NFe (tpNF): entrada ou saida"
+// @Param crt query []string false "Regime tributário do emitente: 1, 2 ou 4 (Simples Nacional) e 3 (Regime Normal); pode ser repetido" collectionFormat(multi)
 // @Param start_date query string false "Data início (YYYY-MM-DD)"
 // @Param end_date query string false "Data fim (YYYY-MM-DD)"
-// @Success 200 {object} domain.NFePaginatedResponse
+// @Param auth_start_date query string false "Data início da autorização (YYYY-MM-DD)"
+// @Param auth_end_date query string false "Data fim da autorização (YYYY-MM-DD)"
+// @Param xml_missing query bool false "Apenas NFes sem XML baixado (true) ou com XML (false)"
+// @Param cancelled query bool false "Apenas NFes já canceladas, mesmo com outro status atual (true), ou nunca canceladas (false)"
+// @Param emission_after_cancellation query bool false "Apenas NFes emitidas depois do cancelamento registrado (true), ou sem essa inconsistência (false)"
+// @Param include query string false "Relacionamentos a carregar (itens)"
+// @Param projection query string false "Colunas retornadas: full (padrão) ou summary (chave, número, emitente, data, valor e status)"
+// @Success 200 {object} domain.PaginatedResponse[domain.NFe]
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/nfe [get]
 func (h *NFeHandler) ListNFes(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
+	filter := h.parseNFeFilter(r)
+
+	// Lista as NFes
+	response, err := h.service.ListNFes(filter)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Filtro inválido", err)
+			return
+		}
+		h.logger.Error("Erro ao listar NFes", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao listar NFes", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// CountNFes retorna o total e o valor das NFes do filtro, sem os registros
+// @Summary Contar NFes
+// @Description Retorna o total de NFes e a soma dos valores para os filtros informados
+// @Tags NFe
+// @Accept json
+// @Produce json
+// @Param cnpj_emitente query string false "CNPJ do emitente"
+// @Param status query []string false "Status da NFe (pode ser repetido)" collectionFormat(multi)
+// @Param origem query string false "Origem da NFe (emitida, recebida ou importada)"
+// @Param numero query string false "Número da NFe"
+// @Param serie query string false "Série da NFe"
+// @Param uf_emitente query string false "Sigla da UF do emitente (ex: SP)"
+// @Param tipo_operacao query string false "Tipo de operação da NFe (tpNF): entrada ou saida"
+// @Param crt query []string false "Regime tributário do emitente: 1, 2 ou 4 (Simples Nacional) e 3 (Regime Normal); pode ser repetido" collectionFormat(multi)
+// @Param start_date query string false "Data início (YYYY-MM-DD)"
+// @Param end_date query string false "Data fim (YYYY-MM-DD)"
+// @Param auth_start_date query string false "Data início da autorização (YYYY-MM-DD)"
+// @Param auth_end_date query string false "Data fim da autorização (YYYY-MM-DD)"
+// @Param xml_missing query bool false "Apenas NFes sem XML baixado (true) ou com XML (false)"
+// @Param cancelled query bool false "Apenas NFes já canceladas, mesmo com outro status atual (true), ou nunca canceladas (false)"
+// @Param emission_after_cancellation query bool false "Apenas NFes emitidas depois do cancelamento registrado (true), ou sem essa inconsistência (false)"
+// @Success 200 {object} domain.NFeCount
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/count [get]
+func (h *NFeHandler) CountNFes(w http.ResponseWriter, r *http.Request) {
+	count, err := h.service.CountNFes(h.parseNFeFilter(r))
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Filtro inválido", err)
+			return
+		}
+		h.logger.Error("Erro ao contar NFes", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao contar NFes", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, count)
+}
+
+// parseNFeFilter monta o filtro de NFes a partir dos parâmetros da query
+func (h *NFeHandler) parseNFeFilter(r *http.Request) domain.NFeFilter {
 	filter := domain.NFeFilter{
 		CNPJEmitente: r.URL.Query().Get("cnpj_emitente"),
-		Status:       domain.NFeStatus(r.URL.Query().Get("status")),
+		Origem:       domain.NFeOrigem(r.URL.Query().Get("origem")),
+		Numero:       r.URL.Query().Get("numero"),
+		Serie:        r.URL.Query().Get("serie"),
+		UFEmitente:   r.URL.Query().Get("uf_emitente"),
+		TipoOperacao: domain.TipoOperacao(r.URL.Query().Get("tipo_operacao")),
+	}
+
+	// Status: um único valor mantém o filtro simples; valores repetidos
+	// (?status=autorizada&status=cancelada) filtram por qualquer um deles
+	if statuses := r.URL.Query()["status"]; len(statuses) == 1 {
+		filter.Status = domain.NFeStatus(statuses[0])
+	} else {
+		for _, status := range statuses {
+			filter.Statuses = append(filter.Statuses, domain.NFeStatus(status))
+		}
+	}
+
+	// crt pode ser repetido (?crt=1&crt=2&crt=4 lista os emitentes do Simples Nacional)
+	for _, crt := range r.URL.Query()["crt"] {
+		filter.CRTs = append(filter.CRTs, domain.CRT(strings.TrimSpace(crt)))
+	}
+
+	// xml_missing=true lista as NFes sem XML baixado
+	if xmlMissingStr := r.URL.Query().Get("xml_missing"); xmlMissingStr != "" {
+		if xmlMissing, err := strconv.ParseBool(xmlMissingStr); err == nil {
+			filter.XMLMissing = &xmlMissing
+		}
+	}
+
+	// cancelled=true lista as NFes já canceladas, mesmo com outro status atual
+	if cancelledStr := r.URL.Query().Get("cancelled"); cancelledStr != "" {
+		if cancelled, err := strconv.ParseBool(cancelledStr); err == nil {
+			filter.Cancelled = &cancelled
+		}
+	}
+	if emissionAfterStr := r.URL.Query().Get("emission_after_cancellation"); emissionAfterStr != "" {
+		if emissionAfter, err := strconv.ParseBool(emissionAfterStr); err == nil {
+			filter.EmissionAfterCancellation = &emissionAfter
+		}
+	}
+
+	// include=itens carrega os itens das NFes da página
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(include) == "itens" {
+			filter.IncludeItens = true
+		}
+	}
+
+	// projection=summary carrega só as colunas da grade
+	filter.Projection = domain.NFeProjection(r.URL.Query().Get("projection"))
+
+	// Sem filtro de status a listagem omite os status configurados (ex: processando)
+	if filter.Status == "" && len(filter.Statuses) == 0 {
+		filter.ExcludeStatuses = h.defaultExcludeStatuses
 	}
 
 	// Page
@@ -110,11 +326,49 @@ func (h *NFeHandler) ListNFes(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Lista as NFes
-	response, err := h.service.ListNFes(filter)
+	// Período de autorização
+	if authStartStr := r.URL.Query().Get("auth_start_date"); authStartStr != "" {
+		if authStart, err := time.Parse("2006-01-02", authStartStr); err == nil {
+			filter.AuthStartDate = &authStart
+		}
+	}
+	if authEndStr := r.URL.Query().Get("auth_end_date"); authEndStr != "" {
+		if authEnd, err := time.Parse("2006-01-02", authEndStr); err == nil {
+			filter.AuthEndDate = &authEnd
+		}
+	}
+
+	return filter
+}
+
+// ListIncompleteNFes lista as NFes que ainda não possuem o XML completo
+// @Summary Listar NFes incompletas
+// @Description Lista as NFes registradas apenas pelo resumo da distribuição DFe
+// @Tags NFe
+// @Accept json
+// @Produce json
+// @Param page query int false "Número da página" default(1)
+// @Param limit query int false "Itens por página" default(20)
+// @Param cnpj_emitente query string false "CNPJ do emitente"
+// @Success 200 {object} domain.PaginatedResponse[domain.NFe]
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/incomplete [get]
+func (h *NFeHandler) ListIncompleteNFes(w http.ResponseWriter, r *http.Request) {
+	filter := domain.NFeFilter{
+		CNPJEmitente: r.URL.Query().Get("cnpj_emitente"),
+	}
+
+	if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+		filter.Page = page
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	response, err := h.service.ListIncompleteNFes(filter)
 	if err != nil {
-		h.logger.Error("Erro ao listar NFes", "error", err)
-		h.sendError(w, http.StatusInternalServerError, "Erro ao listar NFes", err)
+		h.logger.Error("Erro ao listar NFes incompletas", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao listar NFes incompletas", err)
 		return
 	}
 
@@ -138,11 +392,57 @@ func (h *NFeHandler) GetNFe(w http.ResponseWriter, r *http.Request) {
 	nfe, err := h.service.GetNFeByChave(chaveAcesso)
 	if err != nil {
 		if err == domain.ErrNFeNotFound {
-			h.sendError(w, http.StatusNotFound, "NFe não encontrada", err)
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
 			return
 		}
 		h.logger.Error("Erro ao buscar NFe", "chave", chaveAcesso, "error", err)
-		h.sendError(w, http.StatusInternalServerError, "Erro ao buscar NFe", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar NFe", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, nfe)
+}
+
+// LookupNFe retorna a NFe pelo emitente, número e série
+// @Summary Buscar NFe por número
+// @Description Retorna a NFe do emitente com o número e a série impressos no DANFE
+// @Tags NFe
+// @Accept json
+// @Produce json
+// @Param cnpj query string true "CNPJ do emitente"
+// @Param numero query string true "Número da NFe"
+// @Param serie query string true "Série da NFe"
+// @Param modelo query string false "Modelo do documento (55 ou 65)"
+// @Success 200 {object} domain.NFe
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/lookup [get]
+func (h *NFeHandler) LookupNFe(w http.ResponseWriter, r *http.Request) {
+	lookup := domain.NFeLookup{
+		CNPJ:   r.URL.Query().Get("cnpj"),
+		Numero: r.URL.Query().Get("numero"),
+		Serie:  r.URL.Query().Get("serie"),
+		Modelo: r.URL.Query().Get("modelo"),
+	}
+
+	nfe, err := h.service.LookupNFe(lookup)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Busca inválida", err)
+			return
+		}
+		if errors.Is(err, domain.ErrNFeNotFound) {
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+			return
+		}
+		if errors.Is(err, domain.ErrNFeAmbiguous) {
+			h.sendError(w, r, http.StatusConflict, "Mais de uma NFe encontrada para o número e série", err)
+			return
+		}
+		h.logger.Error("Erro ao buscar NFe por número", "cnpj", lookup.CNPJ, "numero", lookup.Numero, "serie", lookup.Serie, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar NFe", err)
 		return
 	}
 
@@ -163,22 +463,18 @@ func (h *NFeHandler) GetNFe(w http.ResponseWriter, r *http.Request) {
 func (h *NFeHandler) DownloadXML(w http.ResponseWriter, r *http.Request) {
 	chaveAcesso := chi.URLParam(r, "chave")
 
-	xmlPath, err := h.service.GetXMLPath(chaveAcesso)
+	xmlData, err := h.service.GetXML(chaveAcesso)
 	if err != nil {
 		if err == domain.ErrNFeNotFound {
-			h.sendError(w, http.StatusNotFound, "NFe não encontrada", err)
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+			return
+		}
+		if err == domain.ErrXMLNotStored {
+			h.sendError(w, r, http.StatusNotFound, "NFe não possui XML armazenado", err)
 			return
 		}
 		h.logger.Error("Erro ao buscar XML", "chave", chaveAcesso, "error", err)
-		h.sendError(w, http.StatusInternalServerError, "Erro ao buscar XML", err)
-		return
-	}
-
-	// Lê o arquivo XML
-	xmlData, err := os.ReadFile(xmlPath)
-	if err != nil {
-		h.logger.Error("Erro ao ler arquivo XML", "path", xmlPath, "error", err)
-		h.sendError(w, http.StatusInternalServerError, "Erro ao ler XML", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar XML", err)
 		return
 	}
 
@@ -192,6 +488,134 @@ func (h *NFeHandler) DownloadXML(w http.ResponseWriter, r *http.Request) {
 	w.Write(xmlData)
 }
 
+// GetXMLRaw retorna o XML de uma NFe como texto em JSON
+// @Summary XML em JSON
+// @Description Retorna o XML armazenado da NFe no campo xml de um objeto JSON, sem Content-Disposition, para exibição no navegador
+// @Tags NFe
+// @Produce json
+// @Param chave path string true "Chave de acesso da NFe"
+// @Success 200 {object} domain.NFeXMLContent
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/{chave}/xml/raw [get]
+func (h *NFeHandler) GetXMLRaw(w http.ResponseWriter, r *http.Request) {
+	chaveAcesso := chi.URLParam(r, "chave")
+
+	xmlData, err := h.service.GetXML(chaveAcesso)
+	if err != nil {
+		if err == domain.ErrNFeNotFound {
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+			return
+		}
+		if err == domain.ErrXMLNotStored {
+			h.sendError(w, r, http.StatusNotFound, "NFe não possui XML armazenado", err)
+			return
+		}
+		h.logger.Error("Erro ao buscar XML", "chave", chaveAcesso, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar XML", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, domain.NFeXMLContent{ChaveAcesso: chaveAcesso, XML: string(xmlData)})
+}
+
+// VerifyNFe verifica novamente os schemas e a assinatura do XML armazenado de uma NFe
+// @Summary Verificar NFe
+// @Description Verifica os schemas XSD e a assinatura digital do XML armazenado e retorna o relatório de integridade
+// @Tags NFe
+// @Produce json
+// @Param chave path string true "Chave de acesso da NFe"
+// @Success 200 {object} domain.NFeVerification
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/{chave}/verify [post]
+func (h *NFeHandler) VerifyNFe(w http.ResponseWriter, r *http.Request) {
+	chaveAcesso := chi.URLParam(r, "chave")
+
+	report, err := h.service.VerifyNFe(chaveAcesso)
+	if err != nil {
+		if err == domain.ErrNFeNotFound {
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+			return
+		}
+		if err == domain.ErrXMLNotStored {
+			h.sendError(w, r, http.StatusNotFound, "NFe não possui XML armazenado", err)
+			return
+		}
+		if err == domain.ErrFullXMLUnavailable {
+			h.sendError(w, r, http.StatusConflict, "NFe possui apenas o evento de cancelamento", err)
+			return
+		}
+		h.logger.Error("Erro ao verificar NFe", "chave", chaveAcesso, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao verificar NFe", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// GetNFeReferencias retorna os documentos referenciados por uma NFe e as NFes que a referenciam
+// @Summary Referências da NFe
+// @Description Retorna as chaves referenciadas (refNFe) pela NFe e as NFes que a referenciam, como devoluções
+// @Tags NFe
+// @Produce json
+// @Param chave path string true "Chave de acesso da NFe"
+// @Success 200 {object} domain.NFeReferencias
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/{chave}/referencias [get]
+func (h *NFeHandler) GetNFeReferencias(w http.ResponseWriter, r *http.Request) {
+	chaveAcesso := chi.URLParam(r, "chave")
+
+	refs, err := h.service.GetNFeReferencias(chaveAcesso)
+	if err != nil {
+		if err == domain.ErrNFeNotFound {
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+			return
+		}
+		h.logger.Error("Erro ao buscar referências da NFe", "chave", chaveAcesso, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar referências da NFe", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, refs)
+}
+
+// GetProtocolo consulta a situação da NFe na SEFAZ
+// @Summary Consultar protocolo da NFe
+// @Description Consulta na SEFAZ autorizadora o status e o protocolo atuais da NFe. Quando a chave está cadastrada, informa o status armazenado e se ele diverge do da SEFAZ; nada é gravado.
+// @Tags NFe
+// @Produce json
+// @Param chave path string true "Chave de acesso da NFe"
+// @Success 200 {object} domain.ProtocoloNFe
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/nfe/{chave}/protocolo [get]
+func (h *NFeHandler) GetProtocolo(w http.ResponseWriter, r *http.Request) {
+	chaveAcesso := chi.URLParam(r, "chave")
+
+	protocolo, err := h.service.ConsultarProtocolo(chaveAcesso)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Chave de acesso inválida", err)
+			return
+		}
+		if errors.Is(err, domain.ErrNFeNotFoundAtSefaz) {
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada na SEFAZ", err)
+			return
+		}
+		h.logger.Error("Erro ao consultar protocolo da NFe", "chave", chaveAcesso, "error", err)
+		h.sendError(w, r, sefazErrorStatus(err), "Erro ao consultar protocolo da NFe", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, protocolo)
+}
+
 // GetStats retorna estatísticas de NFes
 // @Summary Estatísticas
 // @Description Retorna estatísticas de NFes em um período
@@ -210,19 +634,19 @@ func (h *NFeHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	endDateStr := r.URL.Query().Get("end_date")
 
 	if startDateStr == "" || endDateStr == "" {
-		h.sendError(w, http.StatusBadRequest, "start_date e end_date são obrigatórios", nil)
+		h.sendError(w, r, http.StatusBadRequest, "start_date e end_date são obrigatórios", nil)
 		return
 	}
 
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "Formato de data inválido para start_date", err)
+		h.sendError(w, r, http.StatusBadRequest, "Formato de data inválido para start_date", err)
 		return
 	}
 
 	endDate, err := time.Parse("2006-01-02", endDateStr)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "Formato de data inválido para end_date", err)
+		h.sendError(w, r, http.StatusBadRequest, "Formato de data inválido para end_date", err)
 		return
 	}
 
@@ -230,17 +654,93 @@ func (h *NFeHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.service.GetStats(startDate, endDate)
 	if err != nil {
 		h.logger.Error("Erro ao buscar estatísticas", "error", err)
-		h.sendError(w, http.StatusInternalServerError, "Erro ao buscar estatísticas", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar estatísticas", err)
 		return
 	}
 
 	h.sendJSON(w, http.StatusOK, stats)
 }
 
+// ExportInventoryMovements exporta as movimentações de estoque dos itens das NFes
+// @Summary Movimentações de estoque
+// @Description Gera as movimentações de estoque (entrada/saída por CFOP) dos itens das NFes autorizadas no período
+// @Tags NFe
+// @Produce json
+// @Param start_date query string true "Data início (YYYY-MM-DD)"
+// @Param end_date query string true "Data fim (YYYY-MM-DD)"
+// @Param origem query string false "Origem da NFe (emitida, recebida ou importada)"
+// @Param cnpj_emitente query string false "CNPJ do emitente"
+// @Success 200 {array} domain.InventoryMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/inventory-movements [get]
+func (h *NFeHandler) ExportInventoryMovements(w http.ResponseWriter, r *http.Request) {
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "start_date obrigatório no formato YYYY-MM-DD", err)
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "end_date obrigatório no formato YYYY-MM-DD", err)
+		return
+	}
+
+	filter := domain.NFeFilter{
+		CNPJEmitente: r.URL.Query().Get("cnpj_emitente"),
+		Origem:       domain.NFeOrigem(r.URL.Query().Get("origem")),
+		StartDate:    &startDate,
+		EndDate:      &endDate,
+	}
+
+	movements, err := h.service.ExportInventoryMovements(filter)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Filtro inválido", err)
+			return
+		}
+		if errors.Is(err, domain.ErrExportTooLarge) {
+			h.sendError(w, r, http.StatusRequestEntityTooLarge, "Exportação excede o limite de NFes, reduza o período", err)
+			return
+		}
+		h.logger.Error("Erro ao exportar movimentações de estoque", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao exportar movimentações de estoque", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, movements)
+}
+
 // ErrorResponse representa uma resposta de erro
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error   string              `json:"error"`
+	Message string              `json:"message"`
+	Details []domain.FieldError `json:"details,omitempty"`
+}
+
+// isValidationError indica se o erro é um erro de validação da requisição
+func isValidationError(err error) bool {
+	var verr *domain.ValidationError
+	return errors.As(err, &verr)
+}
+
+// sefazErrorStatus mapeia erros da SEFAZ para o status HTTP correspondente
+func sefazErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sefaz.ErrServicoParalisado), errors.Is(err, sefaz.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, sefaz.ErrConsumoIndevido):
+		return http.StatusTooManyRequests
+	case errors.Is(err, sefaz.ErrRejeicao):
+		return http.StatusBadGateway
+	case sefaz.IsRetryable(err):
+		// Falha de comunicação ou HTTP 5xx da SEFAZ
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // sendJSON envia uma resposta JSON
@@ -250,13 +750,22 @@ func (h *NFeHandler) sendJSON(w http.ResponseWriter, status int, data interface{
 	json.NewEncoder(w).Encode(data)
 }
 
-// sendError envia uma resposta de erro
-func (h *NFeHandler) sendError(w http.ResponseWriter, status int, message string, err error) {
+// sendError envia uma resposta de erro, com a mensagem no idioma do
+// Accept-Language da requisição (pt-BR por padrão)
+func (h *NFeHandler) sendError(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
+	lang := preferredLanguage(r)
 	errResp := ErrorResponse{
-		Message: message,
+		Message: translate(lang, message),
 	}
 	if err != nil {
 		errResp.Error = err.Error()
+
+		var verr *domain.ValidationError
+		if errors.As(err, &verr) {
+			errResp.Details = translateFields(lang, verr.Fields)
+		}
 	}
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
 	h.sendJSON(w, status, errResp)
-}
\ No newline at end of file
+}
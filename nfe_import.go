@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ImportXML cadastra uma NFe a partir do nfeProc enviado diretamente por um
+// parceiro, como se tivesse sido sincronizada: todos os campos vêm do XML e a
+// origem é importada. O XML precisa passar nas validações de ValidateXML,
+// trazer o protNFe desta NFe e ser do ambiente configurado. Como a cadeia do
+// certificado não é verificada, o protocolo é confirmado na SEFAZ antes de
+// gravar. Apenas NFes ainda não cadastradas, ou que aguardam o XML completo,
+// podem ser importadas.
+func (s *nfeService) ImportXML(data []byte) (*domain.NFe, error) {
+	report, proc, err := s.validateXML(data)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		return nil, fmt.Errorf("%w: %s", domain.ErrXMLRejected, strings.Join(report.Errors, "; "))
+	}
+	if strings.TrimSpace(proc.ProtNFe.InfProt.CStat) == "" {
+		return nil, fmt.Errorf("%w: missing protNFe", domain.ErrXMLRejected)
+	}
+	if tpAmb := proc.NFe.InfNFe.Ide.TpAmb; tpAmb != s.tpAmb {
+		return nil, fmt.Errorf("%w: tpAmb %s differs from the configured ambiente (%s)", domain.ErrXMLRejected, tpAmb, s.tpAmb)
+	}
+
+	if err := checkProtNFe(proc); err != nil {
+		return nil, err
+	}
+
+	nfe, err := nfeFromProc(proc)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByChaveAcesso(nfe.ChaveAcesso)
+	if err != nil && err != domain.ErrNFeNotFound {
+		return nil, err
+	}
+	if existing != nil && !awaitingCompletion(existing) {
+		return nil, domain.ErrNFeAlreadyExists
+	}
+
+	if err := s.checkValorTotal(nfe, proc.NFe.InfNFe); err != nil {
+		return nil, err
+	}
+	if err := s.confirmProtocolo(nfe, proc); err != nil {
+		return nil, err
+	}
+
+	prepared := preparedNFe{
+		company:  s.companies[0],
+		existing: existing,
+		nfe:      nfe,
+		xmlData:  data,
+		origem:   domain.NFeOrigemImportada,
+	}
+	if err := s.storeNFe(prepared); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("NFe importada a partir de XML enviado",
+		"chave", nfe.ChaveAcesso,
+		"cnpj_emitente", nfe.CNPJEmitente,
+		"status", nfe.Status,
+	)
+
+	return nfe, nil
+}
+
+// checkProtNFe confere se o protNFe pertence à NFe assinada: a chave deve ser
+// a do infNFe e o digVal, o digest da assinatura
+func checkProtNFe(proc *nfeProcXML) error {
+	chave := strings.TrimPrefix(proc.NFe.InfNFe.ID, "NFe")
+	if chNFe := strings.TrimSpace(proc.ProtNFe.InfProt.ChNFe); chNFe != chave {
+		return fmt.Errorf("%w: protNFe chNFe %s does not match infNFe %s", domain.ErrXMLRejected, chNFe, chave)
+	}
+
+	digest := strings.TrimSpace(proc.NFe.Signature.DigestValue)
+	if digVal := strings.TrimSpace(proc.ProtNFe.InfProt.DigVal); digVal != digest {
+		return fmt.Errorf("%w: protNFe digVal %s does not match signature digest %s", domain.ErrXMLRejected, digVal, digest)
+	}
+
+	return nil
+}
+
+// confirmProtocolo consulta o protocolo da NFe na SEFAZ e exige que o número e
+// o digest informados no XML sejam os da autorização. Uma NFe cancelada
+// depois da autorização é cadastrada com o cancelamento.
+func (s *nfeService) confirmProtocolo(nfe *domain.NFe, proc *nfeProcXML) error {
+	protocolo, err := s.companies[0].Client.ConsultarProtocolo(nfe.ChaveAcesso)
+	if errors.Is(err, domain.ErrNFeNotFoundAtSefaz) {
+		return fmt.Errorf("%w: %v", domain.ErrXMLRejected, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if nProt := strings.TrimSpace(proc.ProtNFe.InfProt.NProt); protocolo.Protocolo != nProt {
+		return fmt.Errorf("%w: protocolo %s not confirmed by sefaz (sefaz returned %q)", domain.ErrXMLRejected, nProt, protocolo.Protocolo)
+	}
+	if digVal := strings.TrimSpace(proc.ProtNFe.InfProt.DigVal); protocolo.DigestValue != digVal {
+		return fmt.Errorf("%w: digVal %s not confirmed by sefaz (sefaz returned %q)", domain.ErrXMLRejected, digVal, protocolo.DigestValue)
+	}
+
+	if protocolo.Status == domain.NFeStatusCancelada && nfe.Status != domain.NFeStatusCancelada {
+		nfe.Status = domain.NFeStatusCancelada
+		nfe.DataCancelamento = protocolo.DataCancelamento
+		nfe.MotivoCancelamento = protocolo.MotivoCancelamento
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ImportXML cadastra uma NFe a partir de um nfeProc enviado pelo parceiro
+// @Summary Importar XML
+// @Description Valida e cadastra uma NFe a partir do XML nfeProc enviado diretamente por um parceiro, como se tivesse sido sincronizada. Todos os campos vêm do XML e a origem é importada. O protocolo do XML é confirmado na SEFAZ antes de gravar. O XML pode ser enviado no corpo da requisição ou no campo file de um formulário multipart.
+// @Tags NFe
+// @Accept application/xml
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "Arquivo XML (multipart)"
+// @Success 201 {object} domain.NFe
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/nfe/import [post]
+func (h *NFeHandler) ImportXML(w http.ResponseWriter, r *http.Request) {
+	data, err := readUploadedXML(r)
+	if err != nil {
+		h.sendUploadError(w, r, err)
+		return
+	}
+	if len(data) == 0 {
+		h.sendError(w, r, http.StatusBadRequest, "Corpo da requisição é obrigatório", nil)
+		return
+	}
+
+	nfe, err := h.service.ImportXML(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidXML):
+			h.sendError(w, r, http.StatusBadRequest, "XML inválido", err)
+		case errors.Is(err, domain.ErrXMLRejected):
+			h.sendError(w, r, http.StatusUnprocessableEntity, "XML não passou na validação", err)
+		case errors.Is(err, domain.ErrNFeAlreadyExists):
+			h.sendError(w, r, http.StatusConflict, "NFe já cadastrada", err)
+		default:
+			h.logger.Error("Erro ao importar XML", "error", err)
+			h.sendError(w, r, sefazErrorStatus(err), "Erro ao importar XML", err)
+		}
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, nfe)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// PatchNFe corrige manualmente os campos editáveis da NFe e registra cada
+// alteração no log de auditoria, na mesma transação. A NFe é lida com a linha
+// bloqueada, para que uma sincronização ou outra correção simultânea não seja
+// sobrescrita. O XML armazenado não é alterado, por isso uma nova
+// sincronização com SYNC_ON_CONFLICT=update sobrescreve a correção.
+func (s *nfeService) PatchNFe(chaveAcesso string, patch domain.NFePatch, requestID string) (*domain.NFe, error) {
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+
+	var nfe *domain.NFe
+	fields := []string{}
+	err := s.repo.WithTx(func(tx domain.RepoTx) error {
+		var err error
+		nfe, err = tx.FindByChaveAcessoForUpdate(chaveAcesso)
+		if err != nil {
+			return err
+		}
+
+		changes := patch.Apply(nfe)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		nfe.UpdatedAt = now
+		for i := range changes {
+			changes[i].ID = uuid.New()
+			changes[i].NFeID = nfe.ID
+			changes[i].ChaveAcesso = nfe.ChaveAcesso
+			changes[i].RequestID = requestID
+			changes[i].ChangedAt = now
+			fields = append(fields, changes[i].Field)
+		}
+
+		if err := tx.Update(nfe); err != nil {
+			return err
+		}
+		return tx.CreateAuditEntries(changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nfe, nil
+	}
+
+	s.logger.Info("NFe corrigida manualmente",
+		"chave", chaveAcesso,
+		"campos", fields,
+		"request_id", requestID,
+	)
+	return nfe, nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// PatchNFe corrige campos derivados do XML de uma NFe
+// @Summary Corrigir NFe
+// @Description Corrige nome_emitente, motivo_cancelamento ou motivo_rejeicao sem alterar o XML armazenado. Cada alteração é registrada no log de auditoria; outros campos são recusados.
+// @Tags NFe
+// @Accept json
+// @Produce json
+// @Param chave path string true "Chave de acesso da NFe"
+// @Param request body domain.NFePatch true "Campos a corrigir"
+// @Success 200 {object} domain.NFe
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/{chave} [patch]
+func (h *NFeHandler) PatchNFe(w http.ResponseWriter, r *http.Request) {
+	chaveAcesso := chi.URLParam(r, "chave")
+
+	var patch domain.NFePatch
+	if !h.decodeJSON(w, r, &patch) {
+		return
+	}
+
+	nfe, err := h.service.PatchNFe(chaveAcesso, patch, middleware.GetReqID(r.Context()))
+	if err != nil {
+		switch {
+		case isValidationError(err):
+			h.sendError(w, r, http.StatusBadRequest, "Correção inválida", err)
+		case errors.Is(err, domain.ErrNFeNotFound):
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+		default:
+			h.logger.Error("Erro ao corrigir NFe", "chave", chaveAcesso, "error", err)
+			h.sendError(w, r, http.StatusInternalServerError, "Erro ao corrigir NFe", err)
+		}
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, nfe)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+const (
+	// nfeProcVersion é a versão do leiaute do nfeProc montado
+	nfeProcVersion = "4.00"
+
+	// procVerAplic identifica, no protNFe remontado, que o protocolo foi
+	// reconstruído a partir dos dados cadastrados e não veio da SEFAZ
+	procVerAplic = "nfe-sefaz-sync"
+
+	// xMotivoAutorizada é o motivo do cStat 100
+	xMotivoAutorizada = "Autorizado o uso da NF-e"
+)
+
+// procNFeSignatureXML lê do elemento NFe os dados necessários ao protNFe
+type procNFeSignatureXML struct {
+	InfNFe struct {
+		Ide struct {
+			TpAmb string `xml:"tpAmb"`
+		} `xml:"ide"`
+	} `xml:"infNFe"`
+	DigestValue string `xml:"Signature>SignedInfo>Reference>DigestValue"`
+}
+
+// GetNFeProc retorna o XML nfeProc (NFe + protNFe) da NFe, aceito pelo
+// visualizador oficial. Um XML armazenado sem o protNFe tem o protocolo
+// remontado a partir da autorização cadastrada.
+func (s *nfeService) GetNFeProc(chaveAcesso string) ([]byte, error) {
+	nfe, err := s.repo.FindByChaveAcesso(chaveAcesso)
+	if err != nil {
+		return nil, err
+	}
+	if nfe.FullXMLUnavailable {
+		return nil, domain.ErrFullXMLUnavailable
+	}
+	data, err := s.readStoredXML(nfe)
+	if err != nil {
+		return nil, err
+	}
+
+	rawNFe, hasProt, err := splitNFeProc(data)
+	if err != nil {
+		return nil, err
+	}
+	if hasProt {
+		return data, nil
+	}
+
+	s.logger.Info("Remontando protNFe a partir da autorização cadastrada", "chave", nfe.ChaveAcesso)
+	return buildNFeProc(rawNFe, nfe)
+}
+
+// splitNFeProc localiza o elemento NFe original, byte a byte para preservar a
+// assinatura, e indica se o documento já traz o protNFe
+func splitNFeProc(data []byte) ([]byte, bool, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var rawNFe []byte
+	hasProt := false
+	start := int64(-1)
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "protNFe" {
+				hasProt = true
+			}
+			if t.Name.Local == "NFe" && start < 0 {
+				start = offset
+			}
+		case xml.EndElement:
+			if t.Name.Local == "NFe" && start >= 0 && rawNFe == nil {
+				rawNFe = data[start:dec.InputOffset()]
+			}
+		}
+	}
+
+	if rawNFe == nil {
+		return nil, false, fmt.Errorf("%w: missing NFe element", domain.ErrInvalidXML)
+	}
+	return rawNFe, hasProt, nil
+}
+
+// buildNFeProc envolve o elemento NFe em um nfeProc com o protNFe montado a
+// partir da autorização cadastrada. Apenas NFes autorizadas com protocolo
+// podem ser remontadas, já que o cStat das demais não é guardado.
+func buildNFeProc(rawNFe []byte, nfe *domain.NFe) ([]byte, error) {
+	if nfe.Status != domain.NFeStatusAutorizada || nfe.ProtocoloAutorizacao == "" || nfe.DataAutorizacao == nil {
+		return nil, domain.ErrProtocoloUnavailable
+	}
+
+	var signed procNFeSignatureXML
+	if err := xml.Unmarshal(rawNFe, &signed); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, `<nfeProc xmlns="%s" versao="%s">`, nfeNamespace, nfeProcVersion)
+	buf.Write(rawNFe)
+	fmt.Fprintf(&buf, `<protNFe versao="%s"><infProt>`, nfeProcVersion)
+	writeXMLElement(&buf, "tpAmb", signed.InfNFe.Ide.TpAmb)
+	writeXMLElement(&buf, "verAplic", procVerAplic)
+	writeXMLElement(&buf, "chNFe", nfe.ChaveAcesso)
+	writeXMLElement(&buf, "dhRecbto", nfe.DataAutorizacao.Format("2006-01-02T15:04:05-07:00"))
+	writeXMLElement(&buf, "nProt", nfe.ProtocoloAutorizacao)
+	writeXMLElement(&buf, "digVal", signed.DigestValue)
+	writeXMLElement(&buf, "cStat", "100")
+	writeXMLElement(&buf, "xMotivo", xMotivoAutorizada)
+	buf.WriteString(`</infProt></protNFe></nfeProc>`)
+
+	return buf.Bytes(), nil
+}
+
+// writeXMLElement escreve um elemento simples com o valor escapado
+func writeXMLElement(buf *bytes.Buffer, name, value string) {
+	buf.WriteString("<" + name + ">")
+	xml.EscapeText(buf, []byte(value))
+	buf.WriteString("</" + name + ">")
+}
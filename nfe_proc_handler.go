@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// DownloadNFeProc faz download do nfeProc (NFe + protNFe) de uma NFe
+// @Summary Download nfeProc
+// @Description Faz download do XML nfeProc completo, com a NFe e o protocolo de autorização, no formato aceito pelo visualizador oficial. Quando o XML armazenado não traz o protNFe, ele é remontado a partir da autorização cadastrada.
+// @Tags NFe
+// @Produce application/xml
+// @Param chave path string true "Chave de acesso da NFe"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/{chave}/proc [get]
+func (h *NFeHandler) DownloadNFeProc(w http.ResponseWriter, r *http.Request) {
+	chaveAcesso := chi.URLParam(r, "chave")
+
+	xmlData, err := h.service.GetNFeProc(chaveAcesso)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNFeNotFound):
+			h.sendError(w, r, http.StatusNotFound, "NFe não encontrada", err)
+		case errors.Is(err, domain.ErrXMLNotStored):
+			h.sendError(w, r, http.StatusNotFound, "NFe não possui XML armazenado", err)
+		case errors.Is(err, domain.ErrProtocoloUnavailable):
+			h.sendError(w, r, http.StatusConflict, "NFe sem protocolo de autorização para montar o nfeProc", err)
+		case errors.Is(err, domain.ErrFullXMLUnavailable):
+			h.sendError(w, r, http.StatusConflict, "NFe possui apenas o evento de cancelamento", err)
+		default:
+			h.logger.Error("Erro ao montar nfeProc", "chave", chaveAcesso, "error", err)
+			h.sendError(w, r, http.StatusInternalServerError, "Erro ao montar nfeProc", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", "attachment; filename="+chaveAcesso+"-procNFe.xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(xmlData)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(xmlData)
+}
@@ -0,0 +1,670 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// nfeColumns lista as colunas retornadas nas consultas de NFe
+const nfeColumns = `id, chave_acesso, numero, serie, cnpj_emitente, nome_emitente,
+	data_emissao, valor_total, xml_path, xml_file_missing, status,
+	COALESCE(protocolo_autorizacao, '') AS protocolo_autorizacao, data_autorizacao,
+	data_cancelamento, COALESCE(motivo_cancelamento, '') AS motivo_cancelamento,
+	COALESCE(codigo_rejeicao, '') AS codigo_rejeicao, COALESCE(motivo_rejeicao, '') AS motivo_rejeicao,
+	resumo_only, full_xml_unavailable, origem, COALESCE(uf_emitente, '') AS uf_emitente,
+	COALESCE(crt, '') AS crt, COALESCE(tipo_operacao, '') AS tipo_operacao, created_at, updated_at`
+
+// nfeSummaryColumns é a projeção reduzida usada pelas listagens que exibem só a
+// grade; o id é mantido para carregar os itens da página
+const nfeSummaryColumns = `id, chave_acesso, numero, serie, cnpj_emitente, nome_emitente,
+	data_emissao, valor_total, status`
+
+// nfeRepository implementa domain.NFeRepository usando PostgreSQL
+type nfeRepository struct {
+	db               *timedDB
+	table            string
+	itensTable       string
+	referenciasTable string
+	auditTable       string
+	onConflict       domain.ConflictPolicy
+	timestamps       domain.TimestampSource
+}
+
+// NewNFeRepository cria uma nova instância do repositório. Quando schema é
+// informado, todas as consultas qualificam a tabela com ele (ex: staging.nfes).
+// onConflict define se Create ignora ou sobrescreve uma chave de acesso já cadastrada.
+// timestamps define se created_at e updated_at vêm da aplicação ou do banco.
+// slowQuery registra as consultas lentas; nil desativa o registro.
+func NewNFeRepository(
+	db *sqlx.DB,
+	schema string,
+	onConflict domain.ConflictPolicy,
+	timestamps domain.TimestampSource,
+	slowQuery *SlowQueryLogger,
+) domain.NFeRepository {
+	return &nfeRepository{
+		db:               &timedDB{DB: db, slow: slowQuery},
+		table:            qualifiedTable(schema, "nfes"),
+		itensTable:       qualifiedTable(schema, "nfe_itens"),
+		referenciasTable: qualifiedTable(schema, "nfe_referencias"),
+		auditTable:       qualifiedTable(schema, "nfe_audit_log"),
+		onConflict:       onConflict,
+		timestamps:       timestamps,
+	}
+}
+
+// dbNow é o relógio do banco no tipo das colunas TIMESTAMP. As consultas gravam
+// created_at e updated_at como COALESCE($n, dbNow) e recebem nil no lugar do
+// horário da aplicação quando os timestamps vêm do banco.
+const dbNow = "LOCALTIMESTAMP"
+
+// timestampArg retorna o horário da aplicação, ou nil para usar o relógio do banco
+func timestampArg(source domain.TimestampSource, t time.Time) interface{} {
+	if source == domain.TimestampDatabase {
+		return nil
+	}
+	return t
+}
+
+// qualifiedTable retorna o nome da tabela qualificado pelo schema
+func qualifiedTable(schema, table string) string {
+	if schema == "" {
+		return table
+	}
+	return pq.QuoteIdentifier(schema) + "." + table
+}
+
+// Create insere uma nova NFe no banco
+func (r *nfeRepository) Create(nfe *domain.NFe) error {
+	return createNFe(r.db, r.table, r.onConflict, r.timestamps, nfe)
+}
+
+// Update atualiza os dados de uma NFe existente
+func (r *nfeRepository) Update(nfe *domain.NFe) error {
+	return updateNFe(r.db, r.table, r.timestamps, nfe)
+}
+
+// UpdateStatusBatch atualiza o status de várias NFes em um único comando,
+// garantindo que todas sejam atualizadas ou nenhuma. Retorna o número de NFes alteradas.
+func (r *nfeRepository) UpdateStatusBatch(chaves []string, status domain.NFeStatus) (int64, error) {
+	return updateStatusBatch(r.db, r.table, r.timestamps, chaves, status)
+}
+
+// FindByChaveAcesso busca uma NFe pela chave de acesso
+func (r *nfeRepository) FindByChaveAcesso(chaveAcesso string) (*domain.NFe, error) {
+	query := `SELECT ` + nfeColumns + ` FROM ` + r.table + ` WHERE chave_acesso = $1`
+	return findNFe(r.db, query, chaveAcesso)
+}
+
+// findNFe busca uma única NFe, retornando domain.ErrNFeNotFound quando não há registro
+func findNFe(q sqlx.Queryer, query string, args ...interface{}) (*domain.NFe, error) {
+	var nfe domain.NFe
+	if err := sqlx.Get(q, &nfe, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNFeNotFound
+		}
+		return nil, fmt.Errorf("failed to find nfe: %w", err)
+	}
+
+	return &nfe, nil
+}
+
+// FindByNumero busca até limit NFes do emitente com o número e a série
+// informados. O modelo, quando informado, é comparado com as posições 21-22
+// da chave de acesso.
+func (r *nfeRepository) FindByNumero(lookup domain.NFeLookup, limit int) ([]domain.NFe, error) {
+	conditions := []string{"cnpj_emitente = $1", "numero = $2", "serie = $3"}
+	args := []interface{}{lookup.CNPJ, lookup.Numero, lookup.Serie}
+	if lookup.Modelo != "" {
+		args = append(args, lookup.Modelo)
+		conditions = append(conditions, fmt.Sprintf("SUBSTRING(chave_acesso FROM 21 FOR 2) = $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY data_emissao DESC LIMIT $%d`,
+		nfeColumns, r.table, strings.Join(conditions, " AND "), len(args)+1)
+	args = append(args, limit)
+
+	nfes := []domain.NFe{}
+	if err := r.db.Select(&nfes, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to find nfes by numero: %w", err)
+	}
+
+	return nfes, nil
+}
+
+// FindByFilter busca NFes aplicando filtros e paginação
+func (r *nfeRepository) FindByFilter(filter domain.NFeFilter) ([]domain.NFe, int64, error) {
+	where, args := buildWhereClause(filter)
+
+	// Conta o total de registros
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM ` + r.table + ` ` + where
+	if err := r.db.Get(&total, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count nfes: %w", err)
+	}
+
+	// Busca a página solicitada
+	columns := nfeColumns
+	if filter.Projection == domain.NFeProjectionSummary {
+		columns = nfeSummaryColumns
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s %s ORDER BY data_emissao DESC LIMIT $%d OFFSET $%d`,
+		columns, r.table, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, filter.GetOffset())
+
+	nfes := []domain.NFe{}
+	if err := r.db.Select(&nfes, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to find nfes: %w", err)
+	}
+
+	return nfes, total, nil
+}
+
+// CountByFilter retorna o total de NFes e a soma dos valores para o filtro,
+// sem buscar os registros
+func (r *nfeRepository) CountByFilter(filter domain.NFeFilter) (*domain.NFeCount, error) {
+	where, args := buildWhereClause(filter)
+
+	query := `SELECT COUNT(*) AS total, COALESCE(SUM(valor_total), 0) AS valor_total FROM ` + r.table + ` ` + where
+
+	count := &domain.NFeCount{}
+	if err := r.db.QueryRow(query, args...).Scan(&count.Total, &count.ValorTotal); err != nil {
+		return nil, fmt.Errorf("failed to count nfes: %w", err)
+	}
+
+	return count, nil
+}
+
+// ExistsByChaveAcesso verifica se uma NFe já está cadastrada
+func (r *nfeRepository) ExistsByChaveAcesso(chaveAcesso string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM ` + r.table + ` WHERE chave_acesso = $1)`
+
+	var exists bool
+	if err := r.db.Get(&exists, query, chaveAcesso); err != nil {
+		return false, fmt.Errorf("failed to check nfe existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetStats retorna estatísticas das NFes emitidas no período
+func (r *nfeRepository) GetStats(startDate, endDate time.Time) (*domain.NFeStats, error) {
+	stats := &domain.NFeStats{
+		Periodo: domain.Periodo{
+			Inicio: startDate,
+			Fim:    endDate,
+		},
+		PorStatus: []domain.NFeStatusCount{},
+	}
+
+	totalsQuery := `
+		SELECT COUNT(*) AS total_nfes, COALESCE(SUM(valor_total), 0) AS valor_total
+		FROM ` + r.table + `
+		WHERE data_emissao >= $1 AND data_emissao < $2`
+
+	row := r.db.QueryRowx(totalsQuery, startDate, endDate.AddDate(0, 0, 1))
+	if err := row.Scan(&stats.TotalNFes, &stats.ValorTotal); err != nil {
+		return nil, fmt.Errorf("failed to get nfe totals: %w", err)
+	}
+
+	statusQuery := `
+		SELECT status, COUNT(*) AS count
+		FROM ` + r.table + `
+		WHERE data_emissao >= $1 AND data_emissao < $2
+		GROUP BY status`
+
+	if err := r.db.Select(&stats.PorStatus, statusQuery, startDate, endDate.AddDate(0, 0, 1)); err != nil {
+		return nil, fmt.Errorf("failed to get nfe stats by status: %w", err)
+	}
+	domain.SortStatusCounts(stats.PorStatus)
+
+	return stats, nil
+}
+
+// TopEmitentes retorna os emitentes com maior valor em NFes no período, em
+// ordem decrescente de valor. O nome é o da NFe mais recente do emitente.
+func (r *nfeRepository) TopEmitentes(startDate, endDate time.Time, limit int) ([]domain.EmitenteTotal, error) {
+	query := `
+		SELECT cnpj_emitente,
+			(ARRAY_AGG(nome_emitente ORDER BY data_emissao DESC))[1] AS nome_emitente,
+			COUNT(*) AS total_nfes,
+			COALESCE(SUM(valor_total), 0) AS valor_total
+		FROM ` + r.table + `
+		WHERE data_emissao >= $1 AND data_emissao < $2
+		GROUP BY cnpj_emitente
+		ORDER BY valor_total DESC, cnpj_emitente
+		LIMIT $3`
+
+	emitentes := []domain.EmitenteTotal{}
+	if err := r.db.Select(&emitentes, query, startDate, endDate.AddDate(0, 0, 1), limit); err != nil {
+		return nil, fmt.Errorf("failed to get top emitentes: %w", err)
+	}
+
+	return emitentes, nil
+}
+
+// ListXMLReferences retorna a chave de acesso e o caminho do XML de todas as NFes
+// que devem possuir XML armazenado (NFes rejeitadas e resumos não guardam XML, e
+// as inválidas já tiveram o XML dado como irrecuperável pelo reparo)
+func (r *nfeRepository) ListXMLReferences() ([]domain.XMLReference, error) {
+	query := `SELECT chave_acesso, xml_path FROM ` + r.table + `
+		WHERE status NOT IN ($1, $2) AND NOT resumo_only AND xml_removed_at IS NULL
+		ORDER BY chave_acesso`
+
+	refs := []domain.XMLReference{}
+	if err := r.db.Select(&refs, query, domain.NFeStatusRejeitada, domain.NFeStatusInvalida); err != nil {
+		return nil, fmt.Errorf("failed to list xml references: %w", err)
+	}
+
+	return refs, nil
+}
+
+// ListXMLReferencesBefore lista as NFes emitidas antes da data informada cujo XML
+// ainda está no armazenamento principal
+func (r *nfeRepository) ListXMLReferencesBefore(cutoff time.Time) ([]domain.XMLReference, error) {
+	query := `SELECT chave_acesso, xml_path FROM ` + r.table + `
+		WHERE data_emissao < $1 AND xml_path <> '' AND xml_removed_at IS NULL
+		ORDER BY data_emissao`
+
+	refs := []domain.XMLReference{}
+	if err := r.db.Select(&refs, query, cutoff); err != nil {
+		return nil, fmt.Errorf("failed to list xml references: %w", err)
+	}
+
+	return refs, nil
+}
+
+// MarkXMLRemoved registra que o XML saiu do armazenamento principal, arquivado
+// em xmlPath ou excluído quando xmlPath é vazio
+func (r *nfeRepository) MarkXMLRemoved(chaveAcesso, xmlPath string) error {
+	query := `UPDATE ` + r.table + `
+		SET xml_path = $2, xml_removed_at = $3, updated_at = COALESCE($4, ` + dbNow + `)
+		WHERE chave_acesso = $1`
+
+	now := time.Now()
+	result, err := r.db.Exec(query, chaveAcesso, xmlPath, now, timestampArg(r.timestamps, now))
+	if err != nil {
+		return fmt.Errorf("failed to mark xml removed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNFeNotFound
+	}
+
+	return nil
+}
+
+// MarkXMLFilesMissing grava o resultado da verificação do armazenamento:
+// marca as NFes informadas como sem arquivo e desmarca as demais. Só as linhas
+// que mudam são atualizadas. Retorna o número de NFes alteradas.
+func (r *nfeRepository) MarkXMLFilesMissing(chaves []string) (int64, error) {
+	query := `UPDATE ` + r.table + `
+		SET xml_file_missing = (chave_acesso = ANY($1))
+		WHERE xml_file_missing <> (chave_acesso = ANY($1))`
+
+	// Um array nulo não desmarcaria nenhuma NFe
+	if chaves == nil {
+		chaves = []string{}
+	}
+	result, err := r.db.Exec(query, pq.Array(chaves))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark xml files missing: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows, nil
+}
+
+// createNFe insere uma nova NFe usando o executor informado (banco ou transação).
+// Se a chave de acesso já existir, a NFe é ignorada ou sobrescrita conforme onConflict;
+// id, created_at e os dados de cancelamento do registro existente são sempre preservados.
+// Os dados de cancelamento são gravados apenas em NFes novas, já canceladas ao
+// serem conhecidas.
+func createNFe(exec sqlx.Execer, table string, onConflict domain.ConflictPolicy, timestamps domain.TimestampSource, nfe *domain.NFe) error {
+	query := `
+		INSERT INTO ` + table + ` AS n (
+			id, chave_acesso, numero, serie, cnpj_emitente, nome_emitente,
+			data_emissao, valor_total, xml_path, status, protocolo_autorizacao, data_autorizacao,
+			codigo_rejeicao, motivo_rejeicao, resumo_only, origem, created_at, updated_at, uf_emitente, crt,
+			data_cancelamento, motivo_cancelamento, full_xml_unavailable, tipo_operacao
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12,
+			NULLIF($13, ''), NULLIF($14, ''), $15, $16, COALESCE($17, ` + dbNow + `), COALESCE($18, ` + dbNow + `), NULLIF($19, ''),
+			NULLIF($20, ''), $21, $22, $23, NULLIF($24, ''))
+		` + onConflictClause(onConflict)
+
+	_, err := exec.Exec(query,
+		nfe.ID,
+		nfe.ChaveAcesso,
+		nfe.Numero,
+		nfe.Serie,
+		nfe.CNPJEmitente,
+		nfe.NomeEmitente,
+		nfe.DataEmissao,
+		nfe.ValorTotal,
+		nfe.XMLPath,
+		nfe.Status,
+		nfe.ProtocoloAutorizacao,
+		nfe.DataAutorizacao,
+		nfe.CodigoRejeicao,
+		nfe.MotivoRejeicao,
+		nfe.ResumoOnly,
+		nfe.Origem,
+		timestampArg(timestamps, nfe.CreatedAt),
+		timestampArg(timestamps, nfe.UpdatedAt),
+		nfe.UFEmitente,
+		nfe.CRT,
+		nfe.DataCancelamento,
+		nfe.MotivoCancelamento,
+		nfe.FullXMLUnavailable,
+		nfe.TipoOperacao,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert nfe: %w", err)
+	}
+
+	return nil
+}
+
+// onConflictClause monta a cláusula ON CONFLICT da chave de acesso
+func onConflictClause(policy domain.ConflictPolicy) string {
+	if policy != domain.ConflictUpdate {
+		return `ON CONFLICT (chave_acesso) DO NOTHING`
+	}
+	return `ON CONFLICT (chave_acesso) DO UPDATE SET
+			numero = EXCLUDED.numero,
+			serie = EXCLUDED.serie,
+			cnpj_emitente = EXCLUDED.cnpj_emitente,
+			nome_emitente = EXCLUDED.nome_emitente,
+			data_emissao = EXCLUDED.data_emissao,
+			valor_total = EXCLUDED.valor_total,
+			xml_path = EXCLUDED.xml_path,
+			status = CASE WHEN n.status = 'cancelada' THEN n.status ELSE EXCLUDED.status END,
+			protocolo_autorizacao = EXCLUDED.protocolo_autorizacao,
+			data_autorizacao = EXCLUDED.data_autorizacao,
+			codigo_rejeicao = EXCLUDED.codigo_rejeicao,
+			motivo_rejeicao = EXCLUDED.motivo_rejeicao,
+			resumo_only = EXCLUDED.resumo_only,
+			origem = EXCLUDED.origem,
+			uf_emitente = EXCLUDED.uf_emitente,
+			crt = EXCLUDED.crt,
+			full_xml_unavailable = EXCLUDED.full_xml_unavailable,
+			tipo_operacao = EXCLUDED.tipo_operacao,
+			updated_at = EXCLUDED.updated_at`
+}
+
+// updateNFe atualiza os dados de uma NFe existente usando o executor informado
+func updateNFe(exec sqlx.Execer, table string, timestamps domain.TimestampSource, nfe *domain.NFe) error {
+	query := `
+		UPDATE ` + table + ` SET
+			numero = $2,
+			serie = $3,
+			cnpj_emitente = $4,
+			nome_emitente = $5,
+			data_emissao = $6,
+			valor_total = $7,
+			xml_path = $8,
+			status = $9,
+			data_cancelamento = $10,
+			motivo_cancelamento = $11,
+			resumo_only = $12,
+			origem = $13,
+			protocolo_autorizacao = NULLIF($14, ''),
+			data_autorizacao = $15,
+			codigo_rejeicao = NULLIF($16, ''),
+			motivo_rejeicao = NULLIF($17, ''),
+			updated_at = COALESCE($18, ` + dbNow + `),
+			uf_emitente = NULLIF($19, ''),
+			crt = NULLIF($20, ''),
+			full_xml_unavailable = $21,
+			tipo_operacao = NULLIF($22, ''),
+			xml_file_missing = $23
+		WHERE id = $1`
+
+	result, err := exec.Exec(query,
+		nfe.ID,
+		nfe.Numero,
+		nfe.Serie,
+		nfe.CNPJEmitente,
+		nfe.NomeEmitente,
+		nfe.DataEmissao,
+		nfe.ValorTotal,
+		nfe.XMLPath,
+		nfe.Status,
+		nfe.DataCancelamento,
+		nfe.MotivoCancelamento,
+		nfe.ResumoOnly,
+		nfe.Origem,
+		nfe.ProtocoloAutorizacao,
+		nfe.DataAutorizacao,
+		nfe.CodigoRejeicao,
+		nfe.MotivoRejeicao,
+		timestampArg(timestamps, nfe.UpdatedAt),
+		nfe.UFEmitente,
+		nfe.CRT,
+		nfe.FullXMLUnavailable,
+		nfe.TipoOperacao,
+		nfe.XMLFileMissing,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update nfe: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNFeNotFound
+	}
+
+	return nil
+}
+
+// updateStatusBatch atualiza o status de várias NFes em um único comando usando o executor informado
+func updateStatusBatch(exec sqlx.Execer, table string, timestamps domain.TimestampSource, chaves []string, status domain.NFeStatus) (int64, error) {
+	if len(chaves) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		UPDATE ` + table + ` SET
+			status = $1,
+			data_cancelamento = CASE
+				WHEN $1 = 'cancelada' THEN COALESCE(data_cancelamento, $2, ` + dbNow + `)
+				ELSE data_cancelamento
+			END,
+			updated_at = COALESCE($2, ` + dbNow + `)
+		WHERE chave_acesso = ANY($3)`
+
+	result, err := exec.Exec(query, status, timestampArg(timestamps, time.Now()), pq.Array(chaves))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update nfe status batch: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows, nil
+}
+
+// cancelBatchSize limita as NFes de cada comando de cancelBatch, mantendo os
+// parâmetros abaixo do limite do PostgreSQL
+const cancelBatchSize = 1000
+
+// cancelBatch marca as NFes como canceladas com UPDATE ... FROM (VALUES ...),
+// gravando a data e a justificativa de cada evento. Sem a data do evento a
+// data já cadastrada é mantida. Retorna o número de NFes alteradas.
+func cancelBatch(exec sqlx.Execer, table string, timestamps domain.TimestampSource, cancelamentos []domain.Cancelamento) (int64, error) {
+	var total int64
+	for start := 0; start < len(cancelamentos); start += cancelBatchSize {
+		end := start + cancelBatchSize
+		if end > len(cancelamentos) {
+			end = len(cancelamentos)
+		}
+
+		args := []interface{}{domain.NFeStatusCancelada, timestampArg(timestamps, time.Now())}
+		values := make([]string, 0, end-start)
+		for _, c := range cancelamentos[start:end] {
+			n := len(args)
+			values = append(values, fmt.Sprintf("($%d, $%d::timestamp, $%d)", n+1, n+2, n+3))
+			args = append(args, c.ChaveAcesso, c.Data, c.Motivo)
+		}
+
+		query := `
+			UPDATE ` + table + ` AS n SET
+				status = $1,
+				data_cancelamento = COALESCE(v.data_cancelamento, n.data_cancelamento),
+				motivo_cancelamento = COALESCE(NULLIF(v.motivo, ''), n.motivo_cancelamento),
+				updated_at = COALESCE($2, ` + dbNow + `)
+			FROM (VALUES ` + strings.Join(values, ", ") + `) AS v(chave_acesso, data_cancelamento, motivo)
+			WHERE n.chave_acesso = v.chave_acesso`
+
+		result, err := exec.Exec(query, args...)
+		if err != nil {
+			return total, fmt.Errorf("failed to cancel nfe batch: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		total += rows
+	}
+
+	return total, nil
+}
+
+// statusStrings converte os status para o formato aceito por pq.Array
+func statusStrings(statuses []domain.NFeStatus) []string {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+	return values
+}
+
+// xmlMissingCondition seleciona as NFes sem XML baixado ou cujo arquivo não foi
+// encontrado pela última verificação do armazenamento. Rejeitadas nunca têm
+// XML e as removidas pela retenção foram arquivadas de propósito, por isso não
+// contam como ausentes.
+const xmlMissingCondition = `((COALESCE(xml_path, '') = '' OR xml_file_missing) AND xml_removed_at IS NULL AND status <> 'rejeitada')`
+
+// cancelledCondition seleciona as NFes que já foram canceladas. A data do
+// cancelamento é preservada mesmo que o status mude depois.
+const cancelledCondition = `(data_cancelamento IS NOT NULL OR status = 'cancelada')`
+
+// emissionAfterCancellationCondition seleciona as NFes emitidas depois do
+// cancelamento registrado, o que indica um evento de cancelamento incorreto
+const emissionAfterCancellationCondition = `(data_cancelamento IS NOT NULL AND data_emissao > data_cancelamento)`
+
+// buildWhereClause monta a cláusula WHERE e os argumentos a partir do filtro
+func buildWhereClause(filter domain.NFeFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter.CNPJEmitente != "" {
+		args = append(args, filter.CNPJEmitente)
+		conditions = append(conditions, fmt.Sprintf("cnpj_emitente = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(statusStrings(filter.Statuses)))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+	if len(filter.ExcludeStatuses) > 0 {
+		args = append(args, pq.Array(statusStrings(filter.ExcludeStatuses)))
+		conditions = append(conditions, fmt.Sprintf("status <> ALL($%d)", len(args)))
+	}
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		conditions = append(conditions, fmt.Sprintf("data_emissao >= $%d", len(args)))
+	}
+	if filter.EndDate != nil {
+		// Inclui o dia inteiro da data final
+		args = append(args, filter.EndDate.AddDate(0, 0, 1))
+		conditions = append(conditions, fmt.Sprintf("data_emissao < $%d", len(args)))
+	}
+	if filter.AuthStartDate != nil {
+		args = append(args, *filter.AuthStartDate)
+		conditions = append(conditions, fmt.Sprintf("data_autorizacao >= $%d", len(args)))
+	}
+	if filter.AuthEndDate != nil {
+		// Inclui o dia inteiro da data final de autorização
+		args = append(args, filter.AuthEndDate.AddDate(0, 0, 1))
+		conditions = append(conditions, fmt.Sprintf("data_autorizacao < $%d", len(args)))
+	}
+	if filter.Origem != "" {
+		args = append(args, filter.Origem)
+		conditions = append(conditions, fmt.Sprintf("origem = $%d", len(args)))
+	}
+	if filter.Numero != "" {
+		args = append(args, filter.Numero)
+		conditions = append(conditions, fmt.Sprintf("numero = $%d", len(args)))
+	}
+	if filter.Serie != "" {
+		args = append(args, filter.Serie)
+		conditions = append(conditions, fmt.Sprintf("serie = $%d", len(args)))
+	}
+	if filter.UFEmitente != "" {
+		args = append(args, filter.UFEmitente)
+		conditions = append(conditions, fmt.Sprintf("uf_emitente = $%d", len(args)))
+	}
+	if filter.TipoOperacao != "" {
+		args = append(args, filter.TipoOperacao)
+		conditions = append(conditions, fmt.Sprintf("tipo_operacao = $%d", len(args)))
+	}
+	if len(filter.CRTs) > 0 {
+		crts := make([]string, len(filter.CRTs))
+		for i, crt := range filter.CRTs {
+			crts[i] = string(crt)
+		}
+		args = append(args, pq.Array(crts))
+		conditions = append(conditions, fmt.Sprintf("crt = ANY($%d)", len(args)))
+	}
+	if filter.ResumoOnly != nil {
+		args = append(args, *filter.ResumoOnly)
+		conditions = append(conditions, fmt.Sprintf("resumo_only = $%d", len(args)))
+	}
+	if filter.XMLMissing != nil {
+		if *filter.XMLMissing {
+			conditions = append(conditions, xmlMissingCondition)
+		} else {
+			conditions = append(conditions, "NOT "+xmlMissingCondition)
+		}
+	}
+	if filter.Cancelled != nil {
+		if *filter.Cancelled {
+			conditions = append(conditions, cancelledCondition)
+		} else {
+			conditions = append(conditions, "NOT "+cancelledCondition)
+		}
+	}
+	if filter.EmissionAfterCancellation != nil {
+		if *filter.EmissionAfterCancellation {
+			conditions = append(conditions, emissionAfterCancellationCondition)
+		} else {
+			conditions = append(conditions, "NOT "+emissionAfterCancellationCondition)
+		}
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// reprocessProgressEvery é a cada quantas NFes o progresso do reprocessamento é gravado
+const reprocessProgressEvery = 100
+
+// StartReprocess inicia em segundo plano o reprocessamento dos XMLs
+// armazenados, preenchendo os campos solicitados sem baixar novamente da SEFAZ.
+// Apenas um reprocessamento é executado por vez.
+func (s *nfeService) StartReprocess(fields []domain.ReprocessField) (*domain.ReprocessJob, error) {
+	if !s.reprocessing.CompareAndSwap(false, true) {
+		return nil, domain.ErrReprocessRunning
+	}
+
+	refs, err := s.repo.ListXMLReferences()
+	if err != nil {
+		s.reprocessing.Store(false)
+		return nil, fmt.Errorf("failed to list xml references: %w", err)
+	}
+
+	job := &domain.ReprocessJob{
+		ID:        uuid.New(),
+		Status:    domain.SyncJobStatusRunning,
+		Fields:    fields,
+		StartedAt: time.Now(),
+		Total:     len(refs),
+	}
+	if err := s.reprocessRepo.Save(job); err != nil {
+		s.reprocessing.Store(false)
+		return nil, err
+	}
+
+	s.logger.Info("Reprocessamento iniciado",
+		"job_id", job.ID,
+		"fields", fields,
+		"total", job.Total,
+	)
+
+	started := *job
+	go s.runReprocess(job, refs)
+
+	return &started, nil
+}
+
+// GetReprocessJob retorna o progresso de um reprocessamento
+func (s *nfeService) GetReprocessJob(id uuid.UUID) (*domain.ReprocessJob, error) {
+	return s.reprocessRepo.FindByID(id)
+}
+
+// runReprocess percorre os XMLs armazenados e grava o progresso periodicamente.
+// A falha de uma NFe é contada e não interrompe as demais.
+func (s *nfeService) runReprocess(job *domain.ReprocessJob, refs []domain.XMLReference) {
+	defer s.reprocessing.Store(false)
+
+	for _, ref := range refs {
+		updated, err := s.reprocessNFe(ref, job.Fields)
+		switch {
+		case err != nil:
+			job.Failed++
+			s.logger.Warn("Erro ao reprocessar NFe",
+				"job_id", job.ID,
+				"chave", ref.ChaveAcesso,
+				"error", err,
+			)
+		case updated:
+			job.Updated++
+		}
+		job.Processed++
+
+		if job.Processed%reprocessProgressEvery == 0 {
+			s.saveReprocessJob(job)
+		}
+	}
+
+	endedAt := time.Now()
+	job.EndedAt = &endedAt
+	job.Status = domain.SyncJobStatusCompleted
+	s.saveReprocessJob(job)
+
+	s.logger.Info("Reprocessamento concluído",
+		"job_id", job.ID,
+		"processed", job.Processed,
+		"updated", job.Updated,
+		"failed", job.Failed,
+	)
+}
+
+// reprocessNFe preenche os campos solicitados da NFe a partir do XML
+// armazenado e indica se algo foi gravado
+func (s *nfeService) reprocessNFe(ref domain.XMLReference, fields []domain.ReprocessField) (bool, error) {
+	nfe, err := s.repo.FindByChaveAcesso(ref.ChaveAcesso)
+	if err != nil {
+		return false, err
+	}
+	// O evento de cancelamento não traz os campos derivados da NFe
+	if nfe.FullXMLUnavailable {
+		return false, nil
+	}
+
+	data, err := s.xmlStorage.Read(ref.XMLPath)
+	if err != nil {
+		return false, err
+	}
+
+	parsed, err := parseNFeXML(data)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	if slices.Contains(fields, domain.ReprocessProtocolo) && parsed.ProtocoloAutorizacao != "" &&
+		(nfe.ProtocoloAutorizacao != parsed.ProtocoloAutorizacao || !sameTime(nfe.DataAutorizacao, parsed.DataAutorizacao)) {
+		nfe.ProtocoloAutorizacao = parsed.ProtocoloAutorizacao
+		nfe.DataAutorizacao = parsed.DataAutorizacao
+		changed = true
+	}
+	if slices.Contains(fields, domain.ReprocessUF) && parsed.UFEmitente != "" && nfe.UFEmitente != parsed.UFEmitente {
+		nfe.UFEmitente = parsed.UFEmitente
+		changed = true
+	}
+	if slices.Contains(fields, domain.ReprocessCRT) && parsed.CRT != "" && nfe.CRT != parsed.CRT {
+		nfe.CRT = parsed.CRT
+		changed = true
+	}
+	if slices.Contains(fields, domain.ReprocessTipoOperacao) && parsed.TipoOperacao != "" && nfe.TipoOperacao != parsed.TipoOperacao {
+		nfe.TipoOperacao = parsed.TipoOperacao
+		changed = true
+	}
+
+	writeItens := slices.Contains(fields, domain.ReprocessItens)
+	writeReferencias := slices.Contains(fields, domain.ReprocessReferencias)
+	if !changed && !writeItens && !writeReferencias {
+		return false, nil
+	}
+
+	err = s.repo.WithTx(func(tx domain.RepoTx) error {
+		if changed {
+			nfe.UpdatedAt = time.Now()
+			if err := tx.Update(nfe); err != nil {
+				return err
+			}
+		}
+		if writeItens {
+			nfe.Itens = parsed.Itens
+			if err := s.writeItens(tx, nfe); err != nil {
+				return err
+			}
+		}
+		if writeReferencias {
+			return tx.ReplaceReferencias(nfe.ChaveAcesso, parsed.Referencias)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// saveReprocessJob grava o progresso do reprocessamento; falhas são apenas registradas
+func (s *nfeService) saveReprocessJob(job *domain.ReprocessJob) {
+	if err := s.reprocessRepo.Save(job); err != nil {
+		s.logger.Warn("Não foi possível gravar o job de reprocessamento", "job_id", job.ID, "error", err)
+	}
+}
+
+// sameTime compara dois instantes opcionais
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,1204 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
+	"nfe-sefaz-sync/pkg/logger"
+	"nfe-sefaz-sync/pkg/xsdvalidator"
+)
+
+const (
+	// syncLookbackDays define quantos dias para trás a sincronização de status
+	// consulta os protocolos na SEFAZ
+	syncLookbackDays = 30
+
+	// reportTopEmitentes é o número de emitentes listados no relatório de estatísticas
+	reportTopEmitentes = 10
+)
+
+// Company é um CNPJ sincronizado com o cliente SEFAZ do seu certificado. O
+// caminho e a senha permitem recarregar o certificado sem reiniciar.
+type Company struct {
+	CNPJ         string
+	CertPath     string
+	CertPassword string
+	Client       domain.SefazClient
+}
+
+// nfeService implementa domain.NFeService
+type nfeService struct {
+	repo       domain.NFeRepository
+	cursorRepo domain.NSUCursorRepository
+	jobRepo    domain.SyncJobRepository
+	inutRepo   domain.InutilizacaoRepository
+	// failureRepo guarda os documentos da distribuição DFe que falharam
+	failureRepo domain.DownloadFailureRepository
+	// quarantineRepo guarda as NFes de outro ambiente, que não são cadastradas
+	quarantineRepo domain.QuarantineRepository
+	// reprocessRepo guarda o progresso dos reprocessamentos dos XMLs armazenados
+	reprocessRepo domain.ReprocessJobRepository
+	// schemaRepo informa a última migration aplicada ao banco
+	schemaRepo domain.SchemaVersionRepository
+	// reprocessing impede dois reprocessamentos simultâneos
+	reprocessing atomic.Bool
+	// selfCheck é o resultado da última verificação de inicialização
+	selfCheck atomic.Pointer[domain.SelfCheckReport]
+	// alerter é opcional; nil desativa os alertas de falha
+	alerter domain.SyncAlerter
+	// companies são os CNPJs sincronizados; o primeiro é o principal
+	companies      []Company
+	xmlStoragePath string
+	xmlStorage     domain.XMLStorage
+	// xsdValidator valida os XMLs com os schemas da NFe; nil quando os
+	// schemas não estão configurados
+	xsdValidator *xsdvalidator.Validator
+	onConflict   domain.ConflictPolicy
+	itemConflict domain.ItemConflictStrategy
+	// onCancelled trata as NFes para as quais a SEFAZ entrega apenas o evento de cancelamento
+	onCancelled domain.CancelledWithoutXMLPolicy
+	retention   StorageRetention
+	// tpAmb é o código do ambiente configurado que os XMLs baixados devem ter
+	tpAmb string
+	// jobRetentionDays é o prazo de guarda do histórico de sincronizações; zero o mantém para sempre
+	jobRetentionDays int
+	valueTolerance   domain.Money
+	// parseConcurrency é o número de workers que baixam e interpretam os XMLs
+	parseConcurrency int
+	// syncSlots limita as sincronizações simultâneas; cada execução ocupa uma vaga
+	syncSlots chan struct{}
+	// maxTotalRetries é o orçamento de novas tentativas de cada sincronização
+	maxTotalRetries int
+	// maxExportRows limita as NFes de uma exportação; zero desativa o limite
+	maxExportRows int
+	// build identifica o binário em execução
+	build  domain.BuildInfo
+	logger *logger.Logger
+}
+
+// NewNFeService cria uma nova instância do serviço de NFes. companies deve ter
+// ao menos um CNPJ.
+func NewNFeService(
+	repo domain.NFeRepository,
+	cursorRepo domain.NSUCursorRepository,
+	jobRepo domain.SyncJobRepository,
+	inutRepo domain.InutilizacaoRepository,
+	failureRepo domain.DownloadFailureRepository,
+	quarantineRepo domain.QuarantineRepository,
+	reprocessRepo domain.ReprocessJobRepository,
+	schemaRepo domain.SchemaVersionRepository,
+	alerter domain.SyncAlerter,
+	companies []Company,
+	ambiente string,
+	xmlStoragePath string,
+	xmlStorage domain.XMLStorage,
+	xsdValidator *xsdvalidator.Validator,
+	onConflict domain.ConflictPolicy,
+	itemConflict domain.ItemConflictStrategy,
+	onCancelled domain.CancelledWithoutXMLPolicy,
+	retention StorageRetention,
+	jobRetentionDays int,
+	valueTolerance domain.Money,
+	parseConcurrency int,
+	maxConcurrentJobs int,
+	maxTotalRetries int,
+	maxExportRows int,
+	build domain.BuildInfo,
+	log *logger.Logger,
+) domain.NFeService {
+	return &nfeService{
+		repo:             repo,
+		cursorRepo:       cursorRepo,
+		jobRepo:          jobRepo,
+		inutRepo:         inutRepo,
+		failureRepo:      failureRepo,
+		quarantineRepo:   quarantineRepo,
+		reprocessRepo:    reprocessRepo,
+		schemaRepo:       schemaRepo,
+		alerter:          alerter,
+		companies:        companies,
+		tpAmb:            sefaz.TpAmb(ambiente),
+		xmlStoragePath:   xmlStoragePath,
+		xmlStorage:       xmlStorage,
+		xsdValidator:     xsdValidator,
+		onConflict:       onConflict,
+		itemConflict:     itemConflict,
+		onCancelled:      onCancelled,
+		retention:        retention,
+		jobRetentionDays: jobRetentionDays,
+		valueTolerance:   valueTolerance,
+		parseConcurrency: parseConcurrency,
+		syncSlots:        make(chan struct{}, maxConcurrentJobs),
+		maxTotalRetries:  maxTotalRetries,
+		maxExportRows:    maxExportRows,
+		build:            build,
+		logger:           log,
+	}
+}
+
+// companyByCNPJ retorna o CNPJ configurado informado no pedido, ou o principal
+// quando cnpj é vazio. Um CNPJ não configurado retorna *domain.ValidationError.
+func (s *nfeService) companyByCNPJ(cnpj string) (Company, error) {
+	if cnpj == "" {
+		return s.companies[0], nil
+	}
+	for _, c := range s.companies {
+		if c.CNPJ == cnpj {
+			return c, nil
+		}
+	}
+
+	verr := &domain.ValidationError{}
+	verr.Add("cnpj", "cnpj não configurado: "+cnpj, domain.ErrCompanyNotConfigured)
+	return Company{}, verr
+}
+
+// SyncNFes consulta a SEFAZ e armazena as NFes ainda não cadastradas de todos
+// os CNPJs configurados. Retorna domain.ErrSyncLimitReached, sem iniciar a
+// sincronização, quando o limite de execuções simultâneas já foi atingido.
+func (s *nfeService) SyncNFes() (*domain.SyncJob, error) {
+	select {
+	case s.syncSlots <- struct{}{}:
+		defer func() { <-s.syncSlots }()
+	default:
+		s.logger.Warn("Sincronização recusada: limite de execuções simultâneas atingido", "max_concurrent_jobs", cap(s.syncSlots))
+		return nil, domain.ErrSyncLimitReached
+	}
+
+	job := &domain.SyncJob{
+		ID:        uuid.New(),
+		Status:    domain.SyncJobStatusRunning,
+		StartedAt: time.Now(),
+	}
+	s.saveJob(job)
+
+	budget := newRetryBudget(s.maxTotalRetries)
+
+	// Cada CNPJ usa o próprio certificado e cursor de NSU; a falha de um não
+	// impede a sincronização dos demais, exceto quando o orçamento de novas
+	// tentativas se esgota, o que encerra a execução
+	var errs []error
+	for _, company := range s.companies {
+		if budget.exhausted() {
+			s.logger.Warn("CNPJ não sincronizado: orçamento de novas tentativas esgotado", "job_id", job.ID, "cnpj", company.CNPJ)
+			errs = append(errs, fmt.Errorf("cnpj %s: %w", company.CNPJ, domain.ErrRetryBudgetExhausted))
+			continue
+		}
+		if err := s.syncCompany(job, company, budget); err != nil {
+			s.logger.Error("Erro ao sincronizar CNPJ",
+				"job_id", job.ID,
+				"cnpj", company.CNPJ,
+				"retryable", sefaz.IsRetryable(err),
+				"error", err,
+			)
+			errs = append(errs, fmt.Errorf("cnpj %s: %w", company.CNPJ, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		s.finishJob(job, domain.SyncJobStatusFailed, err)
+		return job, err
+	}
+
+	s.finishJob(job, domain.SyncJobStatusCompleted, nil)
+
+	s.logger.Info("Sincronização finalizada",
+		"job_id", job.ID,
+		"nfes_found", job.NFesFound,
+		"nfes_error", job.NFesError,
+		"nfes_quarantined", job.NFesQuarantined,
+	)
+
+	return job, nil
+}
+
+// syncCompany consulta a distribuição DFe de um CNPJ e armazena suas NFes,
+// acumulando as contagens no job. As NFes que falharam na gravação em
+// execuções anteriores são processadas novamente junto com o lote. Quando o
+// orçamento de novas tentativas se esgota, as NFes restantes são registradas
+// para a próxima execução e domain.ErrRetryBudgetExhausted é retornado.
+func (s *nfeService) syncCompany(job *domain.SyncJob, company Company, budget *retryBudget) error {
+	s.logger.Info("Consultando NFes na SEFAZ", "job_id", job.ID, "cnpj", company.CNPJ)
+
+	ultNSU, err := s.cursorRepo.GetUltNSU(company.CNPJ)
+	if err != nil {
+		return err
+	}
+
+	var consulta *domain.ConsultaNFes
+	err = s.withRetry(budget, "consultar nfes", func() error {
+		consulta, err = company.Client.ConsultarNFes(company.CNPJ, ultNSU)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query sefaz: %w", err)
+	}
+
+	// Documentos corrompidos não bloqueiam o lote: ficam registrados para
+	// reprocessamento e o cursor avança. Se o registro falhar, o cursor é
+	// mantido para que o documento seja recebido novamente.
+	nfesError, unrecorded := 0, 0
+	for i := range consulta.Failures {
+		if err := s.saveDownloadFailure(job, company.CNPJ, &consulta.Failures[i]); err != nil {
+			s.logger.Error("Erro ao registrar documento inválido da distribuição DFe",
+				"job_id", job.ID,
+				"cnpj", company.CNPJ,
+				"nsu", consulta.Failures[i].NSU,
+				"error", err,
+			)
+			nfesError++
+			unrecorded++
+		}
+	}
+
+	resumos, pending := s.withPendingResumos(company.CNPJ, consulta.Resumos)
+
+	// Download e parsing rodam em paralelo; a gravação segue em uma única
+	// goroutine, na ordem em que as NFes ficam prontas
+	for prepared := range s.prepareAll(company, resumos, budget) {
+		chave := prepared.resumo.ChaveAcesso
+		// NFes de outro ambiente nunca são cadastradas; ficam em quarentena e
+		// o cursor avança como para as demais
+		if prepared.quarantine != nil {
+			if err := s.saveQuarantine(job, company.CNPJ, prepared.quarantine); err != nil {
+				s.logger.Error("Erro ao registrar NFe em quarentena", "job_id", job.ID, "chave", chave, "error", err)
+				if !s.saveRetry(job, company.CNPJ, prepared.resumo, pending[chave], err) {
+					unrecorded++
+				}
+				nfesError++
+				continue
+			}
+			s.resolveRetry(job, company.CNPJ, chave, pending[chave])
+			s.logger.Warn("NFe de outro ambiente colocada em quarentena",
+				"job_id", job.ID,
+				"chave", prepared.resumo.ChaveAcesso,
+				"tp_amb", prepared.quarantine.TpAmb,
+				"expected_tp_amb", prepared.quarantine.ExpectedTpAmb,
+			)
+			job.NFesQuarantined++
+			continue
+		}
+
+		if err := s.storeNFe(prepared); err != nil {
+			s.logger.Error("Erro ao sincronizar NFe", "job_id", job.ID, "chave", chave, "error", err)
+			if !s.saveRetry(job, company.CNPJ, prepared.resumo, pending[chave], err) {
+				unrecorded++
+			}
+			nfesError++
+			continue
+		}
+		s.resolveRetry(job, company.CNPJ, chave, pending[chave])
+		job.NFesFound++
+	}
+	job.NFesError += nfesError
+
+	// As NFes com falha ficam em sync_job_errors e download_failures, então o
+	// cursor avança. Ele só é mantido quando alguma falha não pôde ser
+	// registrada, para que a próxima execução receba o documento novamente.
+	if unrecorded > 0 {
+		s.logger.Warn("Cursor de NSU mantido por falhas não registradas", "job_id", job.ID, "cnpj", company.CNPJ, "ult_nsu", ultNSU)
+	} else if consulta.UltNSU != ultNSU {
+		if err := s.cursorRepo.SaveUltNSU(company.CNPJ, consulta.UltNSU); err != nil {
+			s.logger.Warn("Não foi possível gravar o cursor de NSU", "job_id", job.ID, "cnpj", company.CNPJ, "ult_nsu", consulta.UltNSU, "error", err)
+		}
+	}
+
+	if budget.exhausted() {
+		return domain.ErrRetryBudgetExhausted
+	}
+	return nil
+}
+
+// preparedNFe é o resultado da etapa de download e parsing de uma NFe,
+// executada em paralelo antes da gravação
+type preparedNFe struct {
+	company  Company
+	resumo   domain.NFeResumo
+	existing *domain.NFe
+	// nfe é nil quando o XML completo ainda não está disponível
+	nfe     *domain.NFe
+	xmlData []byte
+	// quarantine é preenchido quando o tpAmb do XML difere do ambiente configurado
+	quarantine *domain.QuarantinedNFe
+	skip       bool
+	err        error
+	// origem fixa a origem da NFe importada; vazia usa a do ponto de vista do CNPJ
+	origem domain.NFeOrigem
+}
+
+// prepareNFe baixa e interpreta o XML de uma NFe caso ainda não exista. Não
+// grava nada, podendo ser executada em paralelo para várias NFes. Com a
+// política ConflictUpdate as NFes já cadastradas são baixadas novamente.
+// Falhas repetíveis do download consomem o orçamento de novas tentativas.
+func (s *nfeService) prepareNFe(company Company, resumo domain.NFeResumo, budget *retryBudget) preparedNFe {
+	prepared := preparedNFe{company: company, resumo: resumo}
+
+	existing, err := s.repo.FindByChaveAcesso(resumo.ChaveAcesso)
+	if err != nil && err != domain.ErrNFeNotFound {
+		prepared.err = err
+		return prepared
+	}
+	prepared.existing = existing
+	if existing != nil && !awaitingCompletion(existing) && s.onConflict != domain.ConflictUpdate {
+		prepared.skip = true
+		return prepared
+	}
+
+	var xmlData []byte
+	err = s.withRetry(budget, "download xml", func() error {
+		xmlData, err = company.Client.DownloadXML(resumo.ChaveAcesso)
+		return err
+	})
+	if err != nil {
+		var cancelled *domain.CancelledXMLError
+		if errors.As(err, &cancelled) && s.onCancelled == domain.CancelledWithoutXMLStore &&
+			(existing == nil || awaitingCompletion(existing)) {
+			s.logger.Info("NFe cancelada antes do download, registrada com o evento de cancelamento", "chave", resumo.ChaveAcesso)
+			prepared.nfe, prepared.err = cancelledNFe(resumo, cancelled.EventXML)
+			prepared.xmlData = cancelled.EventXML
+			return prepared
+		}
+		if !errors.Is(err, domain.ErrXMLUnavailable) {
+			prepared.err = fmt.Errorf("failed to download xml: %w", err)
+		}
+		prepared.skip = existing != nil
+		return prepared
+	}
+
+	proc, err := unmarshalNFeProc(xmlData)
+	if err != nil {
+		prepared.err = err
+		return prepared
+	}
+	nfe, err := nfeFromProc(proc)
+	if err != nil {
+		prepared.err = err
+		return prepared
+	}
+	if tpAmb := proc.NFe.InfNFe.Ide.TpAmb; tpAmb != s.tpAmb {
+		prepared.quarantine = &domain.QuarantinedNFe{
+			ChaveAcesso:   nfe.ChaveAcesso,
+			CNPJEmitente:  nfe.CNPJEmitente,
+			TpAmb:         tpAmb,
+			ExpectedTpAmb: s.tpAmb,
+			Content:       string(xmlData),
+		}
+		return prepared
+	}
+	if err := s.checkValorTotal(nfe, proc.NFe.InfNFe); err != nil {
+		prepared.err = err
+		return prepared
+	}
+
+	prepared.nfe = nfe
+	prepared.xmlData = xmlData
+	return prepared
+}
+
+// cancelledNFe monta a NFe já cancelada quando conhecida, a partir do resumo e
+// do evento de cancelamento, único XML que a SEFAZ ainda entrega
+func cancelledNFe(resumo domain.NFeResumo, eventXML []byte) (*domain.NFe, error) {
+	evento, err := parseCancelamentoXML(eventXML)
+	if err != nil {
+		return nil, err
+	}
+
+	numero, serie := numeroSerieFromChave(resumo.ChaveAcesso)
+	return &domain.NFe{
+		ChaveAcesso:        resumo.ChaveAcesso,
+		Numero:             numero,
+		Serie:              serie,
+		CNPJEmitente:       resumo.CNPJEmitente,
+		NomeEmitente:       resumo.NomeEmitente,
+		UFEmitente:         ufFromChave(resumo.ChaveAcesso),
+		DataEmissao:        resumo.DataEmissao,
+		ValorTotal:         resumo.ValorTotal,
+		TipoOperacao:       resumo.TipoOperacao,
+		Status:             domain.NFeStatusCancelada,
+		DataCancelamento:   &evento.DataCancelamento,
+		MotivoCancelamento: evento.Motivo,
+		FullXMLUnavailable: true,
+	}, nil
+}
+
+// storeNFe armazena e cadastra uma NFe preparada por prepareNFe. Quando a
+// SEFAZ ainda não disponibiliza o XML completo a NFe é registrada apenas com os
+// dados do resumo e enriquecida nas próximas sincronizações.
+func (s *nfeService) storeNFe(prepared preparedNFe) error {
+	if prepared.err != nil {
+		return prepared.err
+	}
+	if prepared.skip {
+		return nil
+	}
+	if prepared.nfe == nil {
+		return s.createResumo(prepared.resumo, prepared.company.CNPJ)
+	}
+
+	nfe, existing := prepared.nfe, prepared.existing
+
+	// NFes denegadas têm XML oficial fornecido pela SEFAZ e devem ser guardadas;
+	// rejeitadas não possuem documento fiscal válido e são registradas sem XML
+	xmlPath := ""
+	if storesXML(nfe.Status) {
+		var err error
+		currentPath := ""
+		if existing != nil {
+			currentPath = existing.XMLPath
+		}
+		xmlPath, err = s.xmlStorage.Save(nfe.ChaveAcesso, nfe.DataEmissao, currentPath, prepared.xmlData)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	nfe.XMLPath = xmlPath
+	nfe.Origem = prepared.origem
+	if nfe.Origem == "" {
+		nfe.Origem = domain.OrigemPara(nfe.CNPJEmitente, prepared.company.CNPJ)
+	}
+	nfe.UpdatedAt = now
+
+	if existing != nil && awaitingCompletion(existing) {
+		// Enriquece o resumo ou a NFe em EPEC cadastrada anteriormente com o
+		// XML completo ou já autorizado
+		nfe.ID = existing.ID
+		nfe.CreatedAt = existing.CreatedAt
+		return s.repo.WithTx(func(tx domain.RepoTx) error {
+			if err := tx.Update(nfe); err != nil {
+				return err
+			}
+			if err := s.writeItens(tx, nfe); err != nil {
+				return err
+			}
+			return tx.ReplaceReferencias(nfe.ChaveAcesso, nfe.Referencias)
+		})
+	}
+
+	// Com ConflictUpdate, Create sobrescreve a NFe já cadastrada mantendo id e created_at
+	nfe.ID = uuid.New()
+	nfe.CreatedAt = now
+
+	return s.repo.WithTx(func(tx domain.RepoTx) error {
+		if err := tx.Create(nfe); err != nil {
+			return err
+		}
+		if err := s.writeItens(tx, nfe); err != nil {
+			return err
+		}
+		return tx.ReplaceReferencias(nfe.ChaveAcesso, nfe.Referencias)
+	})
+}
+
+// writeItens grava os itens da NFe conforme a estratégia configurada
+func (s *nfeService) writeItens(tx domain.RepoTx, nfe *domain.NFe) error {
+	if s.itemConflict == domain.ItemConflictUpsert {
+		return tx.UpsertItens(nfe.ChaveAcesso, nfe.Itens)
+	}
+	return tx.ReplaceItens(nfe.ChaveAcesso, nfe.Itens)
+}
+
+// checkValorTotal compara o vNF com o valor recomposto a partir dos itens e
+// marca como suspeita a NFe autorizada cuja diferença excede a tolerância
+func (s *nfeService) checkValorTotal(nfe *domain.NFe, inf infNFeXML) error {
+	if nfe.Status != domain.NFeStatusAutorizada {
+		return nil
+	}
+
+	calculado, err := valorCalculado(inf)
+	if err != nil {
+		return err
+	}
+
+	if diferenca := nfe.ValorTotal.Sub(calculado).Abs(); diferenca > s.valueTolerance {
+		s.logger.Warn("Valor total da NFe diverge da soma dos itens, NFe marcada como suspeita",
+			"chave", nfe.ChaveAcesso,
+			"vnf", nfe.ValorTotal.String(),
+			"valor_calculado", calculado.String(),
+			"diferenca", diferenca.String(),
+		)
+		nfe.Status = domain.NFeStatusSuspeita
+	}
+
+	return nil
+}
+
+// createResumo cadastra uma NFe conhecida apenas pelo resumo da distribuição DFe
+// do CNPJ informado
+func (s *nfeService) createResumo(resumo domain.NFeResumo, cnpj string) error {
+	numero, serie := numeroSerieFromChave(resumo.ChaveAcesso)
+	now := time.Now()
+
+	nfe := &domain.NFe{
+		ID:           uuid.New(),
+		ChaveAcesso:  resumo.ChaveAcesso,
+		Numero:       numero,
+		Serie:        serie,
+		CNPJEmitente: resumo.CNPJEmitente,
+		NomeEmitente: resumo.NomeEmitente,
+		UFEmitente:   ufFromChave(resumo.ChaveAcesso),
+		DataEmissao:  resumo.DataEmissao,
+		ValorTotal:   resumo.ValorTotal,
+		TipoOperacao: resumo.TipoOperacao,
+		Status:       domain.NFeStatusProcessando,
+		ResumoOnly:   true,
+		Origem:       domain.OrigemPara(resumo.CNPJEmitente, cnpj),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	s.logger.Info("XML completo indisponível, NFe registrada a partir do resumo", "chave", resumo.ChaveAcesso)
+
+	return s.repo.WithTx(func(tx domain.RepoTx) error {
+		return tx.Create(nfe)
+	})
+}
+
+// awaitingCompletion indica se a NFe cadastrada ainda será completada por uma
+// próxima distribuição: registrada apenas pelo resumo ou emitida em EPEC e
+// ainda não autorizada
+func awaitingCompletion(nfe *domain.NFe) bool {
+	return nfe.ResumoOnly || nfe.Status == domain.NFeStatusEPEC
+}
+
+// storesXML indica se o XML de uma NFe com o status informado deve ser armazenado
+func storesXML(status domain.NFeStatus) bool {
+	return status != domain.NFeStatusRejeitada
+}
+
+// finishJob marca o job como finalizado com o status informado
+func (s *nfeService) finishJob(job *domain.SyncJob, status domain.SyncJobStatus, err error) {
+	endedAt := time.Now()
+	job.Status = status
+	job.EndedAt = &endedAt
+	if err != nil {
+		job.Error = err.Error()
+	}
+	s.saveJob(job)
+
+	if s.alerter != nil {
+		if err := s.alerter.NotifySync(job); err != nil {
+			s.logger.Warn("Não foi possível enviar o alerta de sincronização", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// saveJob grava o job no histórico. Falhas são apenas registradas no log para
+// não interromper a sincronização.
+func (s *nfeService) saveJob(job *domain.SyncJob) {
+	if err := s.jobRepo.Save(job); err != nil {
+		s.logger.Warn("Não foi possível gravar o job de sincronização", "job_id", job.ID, "error", err)
+	}
+}
+
+// saveDownloadFailure grava um documento da distribuição DFe que não pôde ser interpretado
+func (s *nfeService) saveDownloadFailure(job *domain.SyncJob, cnpj string, failure *domain.DownloadFailure) error {
+	failure.ID = uuid.New()
+	failure.JobID = job.ID
+	failure.CNPJ = cnpj
+	failure.CreatedAt = time.Now()
+	return s.failureRepo.Save(failure)
+}
+
+// saveQuarantine grava uma NFe cujo ambiente difere do configurado
+func (s *nfeService) saveQuarantine(job *domain.SyncJob, cnpj string, nfe *domain.QuarantinedNFe) error {
+	nfe.ID = uuid.New()
+	nfe.JobID = job.ID
+	nfe.CNPJ = cnpj
+	nfe.CreatedAt = time.Now()
+	return s.quarantineRepo.Save(nfe)
+}
+
+// saveJobError registra a falha de uma NFe no job. Assim como em saveJob,
+// falhas na gravação são apenas registradas no log.
+func (s *nfeService) saveJobError(job *domain.SyncJob, cnpj, chave string, err error) {
+	jobErr := &domain.SyncJobError{
+		JobID:       job.ID,
+		CNPJ:        cnpj,
+		ChaveAcesso: chave,
+		Error:       err.Error(),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.jobRepo.SaveError(jobErr); err != nil {
+		s.logger.Warn("Não foi possível gravar o erro da NFe no job de sincronização", "job_id", job.ID, "chave", chave, "error", err)
+	}
+}
+
+// withPendingResumos acrescenta aos resumos do lote os das NFes do CNPJ que
+// falharam na gravação em execuções anteriores, retornando também as chaves
+// pendentes. Se as pendências não puderem ser lidas, o lote segue sem elas.
+func (s *nfeService) withPendingResumos(cnpj string, resumos []domain.NFeResumo) ([]domain.NFeResumo, map[string]bool) {
+	pendingResumos, err := s.jobRepo.FindPendingResumos(cnpj)
+	if err != nil {
+		s.logger.Warn("Não foi possível ler as NFes pendentes de sincronizações anteriores", "cnpj", cnpj, "error", err)
+		return resumos, map[string]bool{}
+	}
+
+	inBatch := make(map[string]bool, len(resumos))
+	for _, resumo := range resumos {
+		inBatch[resumo.ChaveAcesso] = true
+	}
+
+	pending := make(map[string]bool, len(pendingResumos))
+	for _, resumo := range pendingResumos {
+		pending[resumo.ChaveAcesso] = true
+		if !inBatch[resumo.ChaveAcesso] {
+			resumos = append(resumos, resumo)
+		}
+	}
+	return resumos, pending
+}
+
+// saveRetry registra a falha de gravação de uma NFe no job, com o resumo para
+// que a próxima sincronização a processe novamente, e encerra as pendências
+// anteriores da mesma NFe. Retorna false quando o registro não foi gravado.
+func (s *nfeService) saveRetry(job *domain.SyncJob, cnpj string, resumo domain.NFeResumo, pending bool, err error) bool {
+	jobErr := &domain.SyncJobError{
+		JobID:       job.ID,
+		CNPJ:        cnpj,
+		ChaveAcesso: resumo.ChaveAcesso,
+		Error:       err.Error(),
+		CreatedAt:   time.Now(),
+		Resumo:      &resumo,
+	}
+	if err := s.jobRepo.SaveError(jobErr); err != nil {
+		s.logger.Error("Não foi possível registrar a NFe para nova tentativa", "job_id", job.ID, "chave", resumo.ChaveAcesso, "error", err)
+		return false
+	}
+
+	s.resolveRetry(job, cnpj, resumo.ChaveAcesso, pending)
+	return true
+}
+
+// resolveRetry encerra as pendências de execuções anteriores de uma NFe
+// pendente. Uma falha apenas faz a NFe ser processada mais uma vez.
+func (s *nfeService) resolveRetry(job *domain.SyncJob, cnpj, chave string, pending bool) {
+	if !pending {
+		return
+	}
+	if err := s.jobRepo.ResolveErrors(job.ID, cnpj, chave); err != nil {
+		s.logger.Warn("Não foi possível encerrar as pendências da NFe", "job_id", job.ID, "chave", chave, "error", err)
+	}
+}
+
+// GetSyncJobErrors lista as NFes que falharam no job informado
+func (s *nfeService) GetSyncJobErrors(jobID uuid.UUID) (*domain.SyncJobErrors, error) {
+	job, err := s.jobRepo.FindByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobErrors, err := s.jobRepo.FindErrors(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SyncJobErrors{
+		JobID:  job.ID,
+		Status: job.Status,
+		Total:  len(jobErrors),
+		Errors: jobErrors,
+	}, nil
+}
+
+// GetSyncHealth retorna a idade da última sincronização bem-sucedida e o
+// status da última execução
+func (s *nfeService) GetSyncHealth() (*domain.SyncHealth, error) {
+	health := &domain.SyncHealth{}
+
+	last, err := s.jobRepo.FindLast()
+	if err != nil && err != domain.ErrSyncJobNotFound {
+		return nil, err
+	}
+	if last != nil {
+		health.LastStatus = last.Status
+		health.LastStartedAt = &last.StartedAt
+	}
+
+	success, err := s.jobRepo.FindLastSuccess()
+	if err != nil && err != domain.ErrSyncJobNotFound {
+		return nil, err
+	}
+	if success != nil && success.EndedAt != nil {
+		age := int64(time.Since(*success.EndedAt).Seconds())
+		health.LastSuccessAt = success.EndedAt
+		health.AgeSeconds = &age
+	}
+
+	return health, nil
+}
+
+// ListNFes lista NFes com filtros e paginação
+func (s *nfeService) ListNFes(filter domain.NFeFilter) (*domain.PaginatedResponse[domain.NFe], error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	nfes, total, err := s.repo.FindByFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.IncludeItens {
+		if err := s.loadItens(nfes); err != nil {
+			return nil, err
+		}
+	}
+
+	return domain.NewPaginatedResponse(nfes, filter.Page, filter.Limit, total), nil
+}
+
+// ListEmitentes lista os emitentes distintos das NFes armazenadas com paginação
+func (s *nfeService) ListEmitentes(filter domain.EmitenteFilter) (*domain.PaginatedResponse[domain.Emitente], error) {
+	filter.Normalize()
+
+	emitentes, total, err := s.repo.FindEmitentes(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(emitentes, filter.Page, filter.Limit, total), nil
+}
+
+// GetNumeroGaps lista as lacunas na numeração das NFes armazenadas do emitente
+// na série, indicando as que estão cobertas por inutilizações homologadas
+func (s *nfeService) GetNumeroGaps(filter domain.NumeroGapFilter) (*domain.NumeroGaps, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	gaps, err := s.repo.FindNumeroGaps(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(gaps) > 0 {
+		serie, _ := strconv.Atoi(filter.Serie)
+		inutilizacoes, err := s.inutRepo.FindHomologadas(filter.CNPJ, serie)
+		if err != nil {
+			return nil, err
+		}
+		for i := range gaps {
+			gaps[i].Inutilizada = gaps[i].CoveredBy(inutilizacoes)
+		}
+	}
+
+	return &domain.NumeroGaps{CNPJ: filter.CNPJ, Serie: filter.Serie, Gaps: gaps}, nil
+}
+
+// ListQuarantine lista as NFes em quarentena por divergência de ambiente
+func (s *nfeService) ListQuarantine(filter domain.QuarantineFilter) (*domain.PaginatedResponse[domain.QuarantinedNFe], error) {
+	filter.Normalize()
+
+	nfes, total, err := s.quarantineRepo.FindAll(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(nfes, filter.Page, filter.Limit, total), nil
+}
+
+// loadItens preenche os itens das NFes com uma única consulta ao repositório
+func (s *nfeService) loadItens(nfes []domain.NFe) error {
+	ids := make([]uuid.UUID, len(nfes))
+	for i := range nfes {
+		ids[i] = nfes[i].ID
+	}
+
+	itens, err := s.repo.FindItensByNFeIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range nfes {
+		nfes[i].Itens = itens[nfes[i].ID]
+	}
+
+	return nil
+}
+
+// ListIncompleteNFes lista as NFes registradas apenas pelo resumo, que ainda
+// aguardam o download do XML completo
+func (s *nfeService) ListIncompleteNFes(filter domain.NFeFilter) (*domain.PaginatedResponse[domain.NFe], error) {
+	resumoOnly := true
+	filter.Status = domain.NFeStatusProcessando
+	filter.ResumoOnly = &resumoOnly
+
+	return s.ListNFes(filter)
+}
+
+// CountNFes retorna o total e a soma dos valores das NFes do filtro
+func (s *nfeService) CountNFes(filter domain.NFeFilter) (*domain.NFeCount, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.CountByFilter(filter)
+}
+
+// GetNFeByChave retorna uma NFe pela chave de acesso
+func (s *nfeService) GetNFeByChave(chaveAcesso string) (*domain.NFe, error) {
+	return s.repo.FindByChaveAcesso(chaveAcesso)
+}
+
+// ConsultarProtocolo consulta a situação atual da NFe na SEFAZ e, quando a
+// chave está cadastrada, informa o status armazenado e se ele diverge do da
+// SEFAZ. Nada é gravado; a sincronização de status registra os cancelamentos.
+func (s *nfeService) ConsultarProtocolo(chaveAcesso string) (*domain.ProtocoloNFe, error) {
+	if !sefaz.ChaveValida(chaveAcesso) {
+		verr := &domain.ValidationError{}
+		verr.Add("chave", "chave de acesso inválida: "+chaveAcesso, nil)
+		return nil, verr
+	}
+
+	protocolo, err := s.companies[0].Client.ConsultarProtocolo(chaveAcesso)
+	if err != nil {
+		return nil, err
+	}
+
+	nfe, err := s.repo.FindByChaveAcesso(chaveAcesso)
+	if errors.Is(err, domain.ErrNFeNotFound) {
+		return protocolo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	protocolo.StatusArmazenado = nfe.Status
+	protocolo.Divergente = !protocolo.StatusMatches(nfe.Status)
+	if protocolo.Divergente {
+		s.logger.Warn("Status da NFe diverge da SEFAZ", "chave", chaveAcesso, "status", nfe.Status, "status_sefaz", protocolo.Status, "cstat", protocolo.CStat)
+	}
+
+	return protocolo, nil
+}
+
+// LookupNFe retorna a NFe do emitente com o número e a série informados
+func (s *nfeService) LookupNFe(lookup domain.NFeLookup) (*domain.NFe, error) {
+	if err := lookup.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Busca duas para detectar números duplicados, que não deveriam existir
+	nfes, err := s.repo.FindByNumero(lookup, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(nfes) {
+	case 0:
+		return nil, domain.ErrNFeNotFound
+	case 1:
+		return &nfes[0], nil
+	default:
+		s.logger.Warn("Mais de uma NFe encontrada para o emitente, número e série",
+			"cnpj", lookup.CNPJ,
+			"numero", lookup.Numero,
+			"serie", lookup.Serie,
+			"modelo", lookup.Modelo,
+		)
+		return nil, domain.ErrNFeAmbiguous
+	}
+}
+
+// GetNFeReferencias retorna os documentos referenciados pela NFe e as NFes que
+// a referenciam (ex: devoluções)
+func (s *nfeService) GetNFeReferencias(chaveAcesso string) (*domain.NFeReferencias, error) {
+	if _, err := s.repo.FindByChaveAcesso(chaveAcesso); err != nil {
+		return nil, err
+	}
+	return s.repo.FindReferencias(chaveAcesso)
+}
+
+// GetXML retorna o XML armazenado de uma NFe
+func (s *nfeService) GetXML(chaveAcesso string) ([]byte, error) {
+	nfe, err := s.repo.FindByChaveAcesso(chaveAcesso)
+	if err != nil {
+		return nil, err
+	}
+	return s.readStoredXML(nfe)
+}
+
+// GetSefazStatus retorna o estado do circuit breaker das chamadas à SEFAZ de
+// cada CNPJ configurado e o pior estado entre eles
+func (s *nfeService) GetSefazStatus() domain.SefazStatus {
+	status := domain.SefazStatus{
+		State:     domain.CircuitClosed,
+		OpenCNPJs: []string{},
+		Companies: make(map[string]domain.CircuitStatus, len(s.companies)),
+	}
+	for _, company := range s.companies {
+		circuit := company.Client.CircuitStatus()
+		status.Companies[company.CNPJ] = circuit
+
+		switch circuit.State {
+		case domain.CircuitOpen:
+			status.State = domain.CircuitOpen
+			status.OpenCNPJs = append(status.OpenCNPJs, company.CNPJ)
+		case domain.CircuitHalfOpen:
+			if status.State == domain.CircuitClosed {
+				status.State = domain.CircuitHalfOpen
+			}
+		}
+	}
+	return status
+}
+
+// GetStats retorna estatísticas de NFes no período
+func (s *nfeService) GetStats(startDate, endDate time.Time) (*domain.NFeStats, error) {
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("end date must not be before start date")
+	}
+	return s.repo.GetStats(startDate, endDate)
+}
+
+// GetStatsReport reúne as estatísticas do período e os maiores emitentes para o
+// relatório gerencial
+func (s *nfeService) GetStatsReport(startDate, endDate time.Time) (*domain.NFeStatsReport, error) {
+	stats, err := s.GetStats(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	emitentes, err := s.repo.TopEmitentes(startDate, endDate, reportTopEmitentes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.NFeStatsReport{
+		Stats:        *stats,
+		TopEmitentes: emitentes,
+		GeneratedAt:  time.Now(),
+	}, nil
+}
+
+// CheckStorageConsistency cruza os registros do banco com o armazenamento,
+// apontando NFes cujo XML não existe e, em disco, arquivos XML sem NFe cadastrada
+func (s *nfeService) CheckStorageConsistency() (*domain.StorageConsistencyReport, error) {
+	refs, err := s.repo.ListXMLReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := s.missingXMLReferences(refs)
+	if err != nil {
+		return nil, err
+	}
+	s.recordMissingFiles(missing)
+
+	report := &domain.StorageConsistencyReport{
+		MissingFiles: missing,
+		OrphanFiles:  []string{},
+		TotalNFes:    len(refs),
+		CheckedAt:    time.Now(),
+	}
+
+	if s.xmlStorage.Backend() != domain.XMLStorageDisk {
+		s.logger.Info("Verificação de consistência do armazenamento concluída",
+			"total_nfes", report.TotalNFes,
+			"missing_files", len(report.MissingFiles),
+		)
+		return report, nil
+	}
+
+	known := make(map[string]struct{}, len(refs))
+	for _, ref := range refs {
+		if ref.XMLPath != "" {
+			known[absPath(ref.XMLPath)] = struct{}{}
+		}
+	}
+
+	err = filepath.WalkDir(s.xmlStoragePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xml") {
+			return nil
+		}
+
+		report.TotalFiles++
+		if _, ok := known[absPath(path)]; !ok {
+			report.OrphanFiles = append(report.OrphanFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk xml storage: %w", err)
+	}
+
+	s.logger.Info("Verificação de consistência do armazenamento concluída",
+		"total_nfes", report.TotalNFes,
+		"total_files", report.TotalFiles,
+		"missing_files", len(report.MissingFiles),
+		"orphan_files", len(report.OrphanFiles),
+	)
+
+	return report, nil
+}
+
+// RepairStorage baixa novamente da SEFAZ os XMLs ausentes em disco. NFes cujo
+// XML não é mais disponibilizado pela SEFAZ são marcadas como inválidas. Em modo
+// dry run apenas lista as NFes que seriam reparadas.
+func (s *nfeService) RepairStorage(dryRun bool) (*domain.StorageRepairReport, error) {
+	refs, err := s.repo.ListXMLReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := s.missingXMLReferences(refs)
+	if err != nil {
+		return nil, err
+	}
+	s.recordMissingFiles(missing)
+
+	report := &domain.StorageRepairReport{
+		DryRun:      dryRun,
+		Candidates:  missing,
+		Recovered:   []string{},
+		Invalidated: []string{},
+		Failed:      []string{},
+		RepairedAt:  time.Now(),
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, ref := range missing {
+		recovered, err := s.repairXML(ref.ChaveAcesso)
+		switch {
+		case err != nil:
+			s.logger.Error("Erro ao reparar XML", "chave", ref.ChaveAcesso, "error", err)
+			report.Failed = append(report.Failed, ref.ChaveAcesso)
+		case recovered:
+			report.Recovered = append(report.Recovered, ref.ChaveAcesso)
+		default:
+			report.Invalidated = append(report.Invalidated, ref.ChaveAcesso)
+		}
+	}
+
+	s.logger.Info("Reparo do armazenamento concluído",
+		"candidates", len(report.Candidates),
+		"recovered", len(report.Recovered),
+		"invalidated", len(report.Invalidated),
+		"failed", len(report.Failed),
+	)
+
+	return report, nil
+}
+
+// repairXML tenta recuperar o XML de uma NFe na SEFAZ. Retorna false quando a
+// SEFAZ não disponibiliza mais o documento: a NFe é marcada como inválida e o
+// caminho do arquivo inexistente é limpo, para que o reparo não a tente de novo.
+func (s *nfeService) repairXML(chave string) (bool, error) {
+	nfe, err := s.repo.FindByChaveAcesso(chave)
+	if err != nil {
+		return false, err
+	}
+
+	xmlData, err := s.downloadXML(chave, nfe.CNPJEmitente)
+	if err != nil {
+		if !errors.Is(err, domain.ErrXMLUnavailable) {
+			return false, fmt.Errorf("failed to download xml: %w", err)
+		}
+
+		nfe.Status = domain.NFeStatusInvalida
+		nfe.XMLPath = ""
+		nfe.XMLFileMissing = false
+		nfe.UpdatedAt = time.Now()
+		return false, s.repo.Update(nfe)
+	}
+
+	xmlPath, err := s.xmlStorage.Save(nfe.ChaveAcesso, nfe.DataEmissao, nfe.XMLPath, xmlData)
+	if err != nil {
+		return false, err
+	}
+
+	nfe.XMLPath = xmlPath
+	nfe.XMLFileMissing = false
+	nfe.UpdatedAt = time.Now()
+	return true, s.repo.Update(nfe)
+}
+
+// downloadXML baixa o XML pelos CNPJs configurados, começando pelo emitente
+// quando ele é um deles. Como a NFe pode ter sido recebida por qualquer CNPJ,
+// os demais são tentados enquanto a SEFAZ responder que o XML está indisponível.
+func (s *nfeService) downloadXML(chave, cnpjEmitente string) ([]byte, error) {
+	companies := make([]Company, 0, len(s.companies))
+	for _, company := range s.companies {
+		if company.CNPJ == cnpjEmitente {
+			companies = append([]Company{company}, companies...)
+		} else {
+			companies = append(companies, company)
+		}
+	}
+
+	var err error
+	for _, company := range companies {
+		var xmlData []byte
+		if xmlData, err = company.Client.DownloadXML(chave); !errors.Is(err, domain.ErrXMLUnavailable) {
+			return xmlData, err
+		}
+	}
+	return nil, err
+}
+
+// missingXMLReferences retorna as referências cujo XML não existe no armazenamento
+func (s *nfeService) missingXMLReferences(refs []domain.XMLReference) ([]domain.XMLReference, error) {
+	missing := []domain.XMLReference{}
+	for _, ref := range refs {
+		if ref.XMLPath == "" {
+			missing = append(missing, ref)
+			continue
+		}
+
+		exists, err := s.xmlStorage.Exists(ref.XMLPath)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, ref)
+		}
+	}
+	return missing, nil
+}
+
+// recordMissingFiles grava nas NFes o resultado da conferência dos arquivos,
+// usado pelo filtro xml_missing. NFes sem caminho já são encontradas pelo
+// filtro e não são marcadas. Uma falha na gravação é apenas registrada no log.
+func (s *nfeService) recordMissingFiles(missing []domain.XMLReference) {
+	chaves := []string{}
+	for _, ref := range missing {
+		if ref.XMLPath != "" {
+			chaves = append(chaves, ref.ChaveAcesso)
+		}
+	}
+
+	updated, err := s.repo.MarkXMLFilesMissing(chaves)
+	if err != nil {
+		s.logger.Warn("Não foi possível registrar os XMLs ausentes do armazenamento", "error", err)
+		return
+	}
+	if updated > 0 {
+		s.logger.Info("Situação dos arquivos XML atualizada", "nfes", updated, "missing_files", len(chaves))
+	}
+}
+
+// absPath normaliza um caminho para comparação entre banco e disco
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"sync"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// prepareAll executa prepareNFe para os resumos em até parseConcurrency
+// workers, compartilhando o orçamento de novas tentativas da sincronização.
+// O canal retornado entrega os resultados conforme ficam prontos e é fechado
+// quando todos os resumos forem processados; quem consome deve lê-lo até o fim.
+func (s *nfeService) prepareAll(company Company, resumos []domain.NFeResumo, budget *retryBudget) <-chan preparedNFe {
+	workers := s.parseConcurrency
+	if workers > len(resumos) {
+		workers = len(resumos)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan domain.NFeResumo)
+	results := make(chan preparedNFe, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for resumo := range jobs {
+				results <- s.prepareNFe(company, resumo, budget)
+			}
+		}()
+	}
+
+	go func() {
+		for _, resumo := range resumos {
+			jobs <- resumo
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// xmlUploadField é o campo do formulário multipart com o arquivo XML
+const xmlUploadField = "file"
+
+// ValidateXML valida um XML de NFe enviado, sem cadastrá-lo
+// @Summary Validar XML
+// @Description Valida um XML de NFe (nfeProc ou apenas o elemento NFe) com as mesmas regras aplicadas na sincronização: schemas XSD (quando configurados), dígito verificador da chave de acesso, assinatura digital e certificado do emitente. Nada é gravado. O XML pode ser enviado no corpo da requisição ou no campo file de um formulário multipart.
+// @Tags NFe
+// @Accept application/xml
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "Arquivo XML (multipart)"
+// @Success 200 {object} domain.XMLValidation
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/validate [post]
+func (h *NFeHandler) ValidateXML(w http.ResponseWriter, r *http.Request) {
+	data, err := readUploadedXML(r)
+	if err != nil {
+		h.sendUploadError(w, r, err)
+		return
+	}
+	if len(data) == 0 {
+		h.sendError(w, r, http.StatusBadRequest, "Corpo da requisição é obrigatório", nil)
+		return
+	}
+
+	report, err := h.service.ValidateXML(data)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidXML) {
+			h.sendError(w, r, http.StatusBadRequest, "XML inválido", err)
+			return
+		}
+		h.logger.Error("Erro ao validar XML", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao validar XML", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, report)
+}
+
+// readUploadedXML lê o XML do campo file de um formulário multipart ou,
+// nos demais tipos de conteúdo, do corpo da requisição
+func readUploadedXML(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+
+	file, _, err := r.FormFile(xmlUploadField)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// sendUploadError responde a falha de leitura do XML enviado
+func (h *NFeHandler) sendUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		h.sendError(w, r, http.StatusRequestEntityTooLarge, "Corpo da requisição excede o tamanho máximo permitido", err)
+	case errors.Is(err, http.ErrMissingFile):
+		h.sendError(w, r, http.StatusBadRequest, "Arquivo XML obrigatório no campo file", err)
+	default:
+		h.sendError(w, r, http.StatusBadRequest, "Erro ao ler corpo da requisição", err)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
+	"nfe-sefaz-sync/pkg/xmlsign"
+)
+
+// VerifyNFe verifica novamente os schemas XSD e a assinatura digital do XML
+// armazenado da NFe. Falhas de verificação são reportadas em Errors; apenas
+// erros de acesso ao banco, ao arquivo ou ao validador são retornados como erro.
+func (s *nfeService) VerifyNFe(chaveAcesso string) (*domain.NFeVerification, error) {
+	nfe, err := s.repo.FindByChaveAcesso(chaveAcesso)
+	if err != nil {
+		return nil, err
+	}
+	if nfe.FullXMLUnavailable {
+		return nil, domain.ErrFullXMLUnavailable
+	}
+	data, err := s.readStoredXML(nfe)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.NFeVerification{
+		ChaveAcesso: nfe.ChaveAcesso,
+		Errors:      []string{},
+	}
+
+	cert, err := xmlsign.VerifyByID(data, "NFe"+nfe.ChaveAcesso)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		report.Signer = cert.Subject.CommonName
+		report.Errors = append(report.Errors, certificateErrors(cert, nfe)...)
+		report.SignatureValid = len(report.Errors) == 0
+	}
+
+	if report.XSDValid, err = s.validateSchema(data, &report.Errors); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Verificação de integridade da NFe concluída",
+		"chave", nfe.ChaveAcesso,
+		"xsd_valid", report.XSDValid,
+		"signature_valid", report.SignatureValid,
+		"errors", report.Errors,
+	)
+
+	return report, nil
+}
+
+// ValidateXML valida um XML de NFe (nfeProc ou apenas o elemento NFe) com as
+// mesmas regras aplicadas na sincronização, sem gravar nada. Problemas no
+// documento, inclusive as violações dos schemas XSD, são reportados em Errors;
+// um XML que não pode ser interpretado retorna domain.ErrInvalidXML.
+func (s *nfeService) ValidateXML(data []byte) (*domain.XMLValidation, error) {
+	report, _, err := s.validateXML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Validação de XML enviado concluída",
+		"chave", report.ChaveAcesso,
+		"valid", report.Valid,
+		"xsd_valid", report.XSDValid,
+		"errors", report.Errors,
+	)
+
+	return report, nil
+}
+
+// validateXML executa as validações de ValidateXML e retorna também o
+// documento decodificado, com o protNFe vazio quando o XML não o traz
+func (s *nfeService) validateXML(data []byte) (*domain.XMLValidation, *nfeProcXML, error) {
+	rawNFe, hasProt, err := splitNFeProc(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proc := &nfeProcXML{}
+	if hasProt {
+		if proc, err = unmarshalNFeProc(data); err != nil {
+			return nil, nil, err
+		}
+	} else if err := xml.Unmarshal(rawNFe, &proc.NFe); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+	}
+
+	chave := strings.TrimPrefix(proc.NFe.InfNFe.ID, "NFe")
+	report := &domain.XMLValidation{
+		ChaveAcesso: chave,
+		ChaveValid:  sefaz.ChaveValida(chave),
+		Errors:      []string{},
+	}
+	if !report.ChaveValid {
+		report.Errors = append(report.Errors, fmt.Sprintf("invalid chave de acesso %q: expected 44 digits with a valid check digit", chave))
+	}
+
+	nfe, err := nfeFromProc(proc)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	cert, err := xmlsign.VerifyByID(data, proc.NFe.InfNFe.ID)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		report.Signer = cert.Subject.CommonName
+		report.SignatureValid = true
+		if nfe != nil {
+			certErrs := certificateErrors(cert, nfe)
+			report.Errors = append(report.Errors, certErrs...)
+			report.SignatureValid = len(certErrs) == 0
+		}
+	}
+
+	if report.XSDValid, err = s.validateSchema(data, &report.Errors); err != nil {
+		return nil, nil, err
+	}
+
+	report.Valid = report.ChaveValid && report.SignatureValid && len(report.Errors) == 0
+
+	return report, proc, nil
+}
+
+// validateSchema valida o XML com os schemas XSD da NFe e acrescenta as
+// violações a errs. Retorna nil, sem validar, quando os schemas não estão
+// configurados.
+func (s *nfeService) validateSchema(data []byte, errs *[]string) (*bool, error) {
+	if s.xsdValidator == nil {
+		return nil, nil
+	}
+
+	violations, err := s.xsdValidator.Validate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate xml schema: %w", err)
+	}
+	*errs = append(*errs, violations...)
+
+	valid := len(violations) == 0
+	return &valid, nil
+}
+
+// certificateErrors confere se o certificado era válido na emissão e, quando o
+// nome do titular segue o padrão ICP-Brasil (RAZAO SOCIAL:CNPJ), se pertence ao emitente
+func certificateErrors(cert *x509.Certificate, nfe *domain.NFe) []string {
+	errs := []string{}
+
+	if nfe.DataEmissao.Before(cert.NotBefore) || nfe.DataEmissao.After(cert.NotAfter) {
+		errs = append(errs, fmt.Sprintf("certificate not valid at emission date %s (valid from %s to %s)",
+			nfe.DataEmissao.Format("2006-01-02"), cert.NotBefore.Format("2006-01-02"), cert.NotAfter.Format("2006-01-02")))
+	}
+
+	if i := strings.LastIndex(cert.Subject.CommonName, ":"); i >= 0 {
+		if cnpj := cert.Subject.CommonName[i+1:]; cnpj != nfe.CNPJEmitente {
+			errs = append(errs, fmt.Sprintf("certificate cnpj %s does not match emitente %s", cnpj, nfe.CNPJEmitente))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
+)
+
+// nfeProcXML representa o envelope nfeProc (NFe + protocolo de autorização)
+type nfeProcXML struct {
+	XMLName xml.Name   `xml:"nfeProc"`
+	NFe     nfeXML     `xml:"NFe"`
+	ProtNFe protNFeXML `xml:"protNFe"`
+}
+
+type nfeXML struct {
+	InfNFe    infNFeXML    `xml:"infNFe"`
+	Signature signatureXML `xml:"Signature"`
+}
+
+// signatureXML traz apenas o digest da assinatura, conferido com o digVal do
+// protocolo
+type signatureXML struct {
+	DigestValue string `xml:"SignedInfo>Reference>DigestValue"`
+}
+
+type infNFeXML struct {
+	ID    string   `xml:"Id,attr"`
+	Ide   ideXML   `xml:"ide"`
+	Emit  emitXML  `xml:"emit"`
+	Det   []detXML `xml:"det"`
+	Total totalXML `xml:"total"`
+}
+
+type ideXML struct {
+	Serie  string     `xml:"serie"`
+	NNF    string     `xml:"nNF"`
+	DhEmi  string     `xml:"dhEmi"`
+	TpAmb  string     `xml:"tpAmb"`
+	TpEmis string     `xml:"tpEmis"`
+	TpNF   string     `xml:"tpNF"`
+	NFref  []nfRefXML `xml:"NFref"`
+}
+
+// nfRefXML representa um documento referenciado; apenas refNFe (NFe modelo 55/65)
+// é considerado, as demais referências (NF modelo 1, CT-e, ECF) são ignoradas
+type nfRefXML struct {
+	RefNFe string `xml:"refNFe"`
+}
+
+type detXML struct {
+	NItem   string     `xml:"nItem,attr"`
+	Prod    prodXML    `xml:"prod"`
+	Imposto impostoXML `xml:"imposto"`
+}
+
+type impostoXML struct {
+	// ISSQN é preenchido nos itens de serviço da NFe conjugada
+	ISSQN *struct{} `xml:"ISSQN"`
+}
+
+type prodXML struct {
+	CProd  string `xml:"cProd"`
+	XProd  string `xml:"xProd"`
+	NCM    string `xml:"NCM"`
+	CFOP   string `xml:"CFOP"`
+	UCom   string `xml:"uCom"`
+	QCom   string `xml:"qCom"`
+	VProd  string `xml:"vProd"`
+	VFrete string `xml:"vFrete"`
+	VSeg   string `xml:"vSeg"`
+	VDesc  string `xml:"vDesc"`
+	VOutro string `xml:"vOutro"`
+	// IndTot 0 indica que o vProd do item não compõe o valor total da NFe
+	IndTot string `xml:"indTot"`
+}
+
+type emitXML struct {
+	CNPJ  string `xml:"CNPJ"`
+	XNome string `xml:"xNome"`
+	CRT   string `xml:"CRT"`
+}
+
+type totalXML struct {
+	ICMSTot  icmsTotXML  `xml:"ICMSTot"`
+	ISSQNTot issqnTotXML `xml:"ISSQNtot"`
+}
+
+type issqnTotXML struct {
+	VServ string `xml:"vServ"`
+}
+
+type icmsTotXML struct {
+	VICMSDeson string `xml:"vICMSDeson"`
+	VST        string `xml:"vST"`
+	VFCPST     string `xml:"vFCPST"`
+	VII        string `xml:"vII"`
+	VIPI       string `xml:"vIPI"`
+	VIPIDevol  string `xml:"vIPIDevol"`
+	VNF        string `xml:"vNF"`
+}
+
+type protNFeXML struct {
+	InfProt infProtXML `xml:"infProt"`
+}
+
+type infProtXML struct {
+	ChNFe    string `xml:"chNFe"`
+	DhRecbto string `xml:"dhRecbto"`
+	NProt    string `xml:"nProt"`
+	CStat    string `xml:"cStat"`
+	XMotivo  string `xml:"xMotivo"`
+	// DigVal é o digest do infNFe recebido pela SEFAZ na autorização
+	DigVal string `xml:"digVal"`
+}
+
+// parseNFeXML extrai os dados da NFe a partir do XML nfeProc
+func parseNFeXML(data []byte) (*domain.NFe, error) {
+	proc, err := unmarshalNFeProc(data)
+	if err != nil {
+		return nil, err
+	}
+	return nfeFromProc(proc)
+}
+
+// unmarshalNFeProc decodifica o XML nfeProc
+func unmarshalNFeProc(data []byte) (*nfeProcXML, error) {
+	var proc nfeProcXML
+	if err := xml.Unmarshal(data, &proc); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+	}
+	return &proc, nil
+}
+
+// nfeFromProc converte o nfeProc decodificado em domain.NFe
+func nfeFromProc(proc *nfeProcXML) (*domain.NFe, error) {
+	inf := proc.NFe.InfNFe
+
+	chave := strings.TrimPrefix(inf.ID, "NFe")
+	if chave == "" {
+		chave = proc.ProtNFe.InfProt.ChNFe
+	}
+	if len(chave) != 44 {
+		return nil, fmt.Errorf("%w: invalid chave de acesso %q", domain.ErrInvalidXML, chave)
+	}
+
+	dataEmissao, err := time.Parse(time.RFC3339, inf.Ide.DhEmi)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid dhEmi %q", domain.ErrInvalidXML, inf.Ide.DhEmi)
+	}
+
+	valorTotal, err := domain.ParseMoney(inf.Total.ICMSTot.VNF)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid vNF %q", domain.ErrInvalidXML, inf.Total.ICMSTot.VNF)
+	}
+
+	prot := proc.ProtNFe.InfProt
+
+	var dataAutorizacao *time.Time
+	if prot.DhRecbto != "" {
+		dh, err := time.Parse(time.RFC3339, prot.DhRecbto)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid dhRecbto %q", domain.ErrInvalidXML, prot.DhRecbto)
+		}
+		dataAutorizacao = &dh
+	}
+
+	itens := make([]domain.NFeItem, 0, len(inf.Det))
+	for _, det := range inf.Det {
+		item, err := parseItem(det)
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+
+	nfe := &domain.NFe{
+		ChaveAcesso:          chave,
+		Numero:               inf.Ide.NNF,
+		Serie:                inf.Ide.Serie,
+		CNPJEmitente:         inf.Emit.CNPJ,
+		NomeEmitente:         inf.Emit.XNome,
+		UFEmitente:           ufFromChave(chave),
+		CRT:                  domain.CRT(strings.TrimSpace(inf.Emit.CRT)),
+		TipoOperacao:         tipoOperacaoFromTpNF(inf.Ide.TpNF),
+		DataEmissao:          dataEmissao,
+		ValorTotal:           valorTotal,
+		Status:               statusFromCStat(prot.CStat, inf.Ide.TpEmis),
+		ProtocoloAutorizacao: prot.NProt,
+		DataAutorizacao:      dataAutorizacao,
+		Itens:                itens,
+		Referencias:          referencias(inf.Ide.NFref),
+	}
+
+	// Guarda o motivo informado pela SEFAZ para que o emitente corrija e reemita
+	if nfe.Status == domain.NFeStatusRejeitada {
+		nfe.CodigoRejeicao = strings.TrimSpace(prot.CStat)
+		nfe.MotivoRejeicao = strings.TrimSpace(prot.XMotivo)
+	}
+
+	return nfe, nil
+}
+
+// referencias extrai as chaves refNFe dos documentos referenciados, sem repetições
+func referencias(refs []nfRefXML) []string {
+	chaves := []string{}
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		chave := strings.TrimSpace(ref.RefNFe)
+		if chave == "" || seen[chave] {
+			continue
+		}
+		seen[chave] = true
+		chaves = append(chaves, chave)
+	}
+	return chaves
+}
+
+// parseItem converte um item (det) do XML em domain.NFeItem
+func parseItem(det detXML) (domain.NFeItem, error) {
+	numero, err := strconv.Atoi(det.NItem)
+	if err != nil {
+		return domain.NFeItem{}, fmt.Errorf("%w: invalid nItem %q", domain.ErrInvalidXML, det.NItem)
+	}
+
+	quantidade, err := strconv.ParseFloat(det.Prod.QCom, 64)
+	if err != nil {
+		return domain.NFeItem{}, fmt.Errorf("%w: invalid qCom %q", domain.ErrInvalidXML, det.Prod.QCom)
+	}
+
+	valor, err := domain.ParseMoney(det.Prod.VProd)
+	if err != nil {
+		return domain.NFeItem{}, fmt.Errorf("%w: invalid vProd %q", domain.ErrInvalidXML, det.Prod.VProd)
+	}
+
+	return domain.NFeItem{
+		NumeroItem:    numero,
+		CodigoProduto: det.Prod.CProd,
+		Descricao:     det.Prod.XProd,
+		NCM:           det.Prod.NCM,
+		CFOP:          det.Prod.CFOP,
+		Unidade:       det.Prod.UCom,
+		Quantidade:    quantidade,
+		ValorTotal:    valor,
+	}, nil
+}
+
+// valorCalculado recompõe o valor da NFe a partir dos itens: produtos, frete,
+// seguro e outras despesas menos descontos, somados aos tributos cobrados à
+// parte (ICMS ST, FCP ST, II, IPI e IPI devolvido) e aos serviços da NFe
+// conjugada (vServ do ISSQNtot), e menos o ICMS desonerado informados nos
+// totais. O vProd dos itens com indTot 0 não compõe o valor da NFe, e o dos
+// itens de serviço já está no vServ.
+func valorCalculado(inf infNFeXML) (domain.Money, error) {
+	var total domain.Money
+	for _, det := range inf.Det {
+		vProd := det.Prod.VProd
+		if strings.TrimSpace(det.Prod.IndTot) == "0" || det.Imposto.ISSQN != nil {
+			vProd = ""
+		}
+		for _, v := range []struct {
+			tag      string
+			value    string
+			subtract bool
+		}{
+			{"vProd", vProd, false},
+			{"vFrete", det.Prod.VFrete, false},
+			{"vSeg", det.Prod.VSeg, false},
+			{"vOutro", det.Prod.VOutro, false},
+			{"vDesc", det.Prod.VDesc, true},
+		} {
+			value, err := parseOptionalMoney(v.value)
+			if err != nil {
+				return 0, fmt.Errorf("%w: invalid %s %q in item %s", domain.ErrInvalidXML, v.tag, v.value, det.NItem)
+			}
+			if v.subtract {
+				total = total.Sub(value)
+			} else {
+				total = total.Add(value)
+			}
+		}
+	}
+
+	tot := inf.Total.ICMSTot
+	for _, value := range []string{tot.VST, tot.VFCPST, tot.VII, tot.VIPI, tot.VIPIDevol, inf.Total.ISSQNTot.VServ} {
+		tributo, err := parseOptionalMoney(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid tax total %q", domain.ErrInvalidXML, value)
+		}
+		total = total.Add(tributo)
+	}
+
+	desonerado, err := parseOptionalMoney(tot.VICMSDeson)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid vICMSDeson %q", domain.ErrInvalidXML, tot.VICMSDeson)
+	}
+
+	return total.Sub(desonerado), nil
+}
+
+// parseOptionalMoney converte um valor monetário do XML; tags ausentes valem zero
+func parseOptionalMoney(s string) (domain.Money, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	return domain.ParseMoney(s)
+}
+
+// numeroSerieFromChave extrai o número e a série da NFe a partir da chave de acesso
+// (cUF, AAMM, CNPJ, modelo, série com 3 dígitos, número com 9 dígitos, ...)
+func numeroSerieFromChave(chave string) (string, string) {
+	if len(chave) != 44 {
+		return "", ""
+	}
+	return trimLeadingZeros(chave[25:34]), trimLeadingZeros(chave[22:25])
+}
+
+// ufFromChave retorna a sigla da UF do emitente a partir do cUF, os dois
+// primeiros dígitos da chave de acesso; vazio quando o código não é conhecido
+func ufFromChave(chave string) string {
+	if len(chave) != 44 {
+		return ""
+	}
+	uf, _ := sefaz.SiglaUF(chave[:2])
+	return uf
+}
+
+// tipoOperacaoFromTpNF converte o tpNF do leiaute (0 entrada, 1 saída) no tipo
+// de operação; vazio quando o código não é conhecido
+func tipoOperacaoFromTpNF(tpNF string) domain.TipoOperacao {
+	switch strings.TrimSpace(tpNF) {
+	case "0":
+		return domain.TipoOperacaoEntrada
+	case "1":
+		return domain.TipoOperacaoSaida
+	}
+	return ""
+}
+
+// trimLeadingZeros remove zeros à esquerda mantendo ao menos um dígito
+func trimLeadingZeros(s string) string {
+	trimmed := strings.TrimLeft(s, "0")
+	if trimmed == "" {
+		return "0"
+	}
+	return trimmed
+}
+
+// tpEventoCancelamento é o tipo do evento de cancelamento da NFe
+const tpEventoCancelamento = "110111"
+
+// cancelamentoEvento reúne os dados do evento de cancelamento de uma NFe
+type cancelamentoEvento struct {
+	ChaveAcesso      string
+	DataCancelamento time.Time
+	Motivo           string
+}
+
+// parseCancelamentoXML extrai o cancelamento de um procEventoNFe. Outros
+// eventos retornam domain.ErrInvalidXML.
+func parseCancelamentoXML(data []byte) (*cancelamentoEvento, error) {
+	var proc procEventoNFeXML
+	if err := xml.Unmarshal(data, &proc); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+	}
+	return cancelamentoFromProcEvento(&proc)
+}
+
+// cancelamentoFromProcEvento extrai o cancelamento de um procEventoNFe já
+// interpretado, como os retornados pela consulta protocolo
+func cancelamentoFromProcEvento(proc *procEventoNFeXML) (*cancelamentoEvento, error) {
+	inf := proc.Evento.InfEvento
+	if strings.TrimSpace(inf.TpEvento) != tpEventoCancelamento {
+		return nil, fmt.Errorf("%w: tpEvento %q is not a cancellation", domain.ErrInvalidXML, inf.TpEvento)
+	}
+
+	// O registro do evento na SEFAZ é a data oficial do cancelamento
+	dh := proc.RetEvento.DhRegEvento
+	if dh == "" {
+		dh = inf.DhEvento
+	}
+	dataCancelamento, err := time.Parse(time.RFC3339, dh)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid dhRegEvento %q", domain.ErrInvalidXML, dh)
+	}
+
+	return &cancelamentoEvento{
+		ChaveAcesso:      inf.ChNFe,
+		DataCancelamento: dataCancelamento,
+		Motivo:           strings.TrimSpace(inf.DetEvento.XJust),
+	}, nil
+}
+
+// tpEmisEPEC é o tipo de emissão das NFes emitidas em contingência EPEC
+const tpEmisEPEC = "4"
+
+// statusFromCStat converte o código de status do protocolo em status da NFe.
+// Sem protocolo, a NFe emitida em contingência EPEC aguarda a autorização da
+// SEFAZ do emitente, que a distribui novamente com o protNFe.
+func statusFromCStat(cStat, tpEmis string) domain.NFeStatus {
+	if strings.TrimSpace(cStat) == "" {
+		if strings.TrimSpace(tpEmis) == tpEmisEPEC {
+			return domain.NFeStatusEPEC
+		}
+		return domain.NFeStatusProcessando
+	}
+
+	code, err := sefaz.ParseCStat(cStat)
+	if err != nil {
+		return domain.NFeStatusRejeitada
+	}
+	return code.NFeStatus()
+}
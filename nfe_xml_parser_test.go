@@ -0,0 +1,88 @@
+package service
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+func parseInfNFe(t *testing.T, data string) infNFeXML {
+	t.Helper()
+	var nfe nfeXML
+	require.NoError(t, xml.Unmarshal([]byte(data), &nfe))
+	return nfe.InfNFe
+}
+
+func TestValorCalculado(t *testing.T) {
+	inf := parseInfNFe(t, `<NFe><infNFe>
+		<det nItem="1"><prod><vProd>100.00</vProd><vFrete>10.00</vFrete><vDesc>5.00</vDesc><indTot>1</indTot></prod></det>
+		<det nItem="2"><prod><vProd>50.00</vProd><vSeg>2.50</vSeg><indTot>1</indTot></prod></det>
+		<total><ICMSTot><vST>7.00</vST><vIPI>3.00</vIPI><vNF>167.50</vNF></ICMSTot></total>
+	</infNFe></NFe>`)
+
+	valor, err := valorCalculado(inf)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money(16750), valor)
+}
+
+func TestValorCalculado_ICMSDesonerado(t *testing.T) {
+	inf := parseInfNFe(t, `<NFe><infNFe>
+		<det nItem="1"><prod><vProd>1000.00</vProd><indTot>1</indTot></prod></det>
+		<total><ICMSTot><vICMSDeson>180.00</vICMSDeson><vNF>820.00</vNF></ICMSTot></total>
+	</infNFe></NFe>`)
+
+	valor, err := valorCalculado(inf)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money(82000), valor)
+}
+
+func TestValorCalculado_ItemForaDoTotal(t *testing.T) {
+	// O vProd do item com indTot 0 não compõe o vNF; o frete do item continua
+	inf := parseInfNFe(t, `<NFe><infNFe>
+		<det nItem="1"><prod><vProd>200.00</vProd><indTot>1</indTot></prod></det>
+		<det nItem="2"><prod><vProd>80.00</vProd><vFrete>4.00</vFrete><indTot>0</indTot></prod></det>
+		<total><ICMSTot><vNF>204.00</vNF></ICMSTot></total>
+	</infNFe></NFe>`)
+
+	valor, err := valorCalculado(inf)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money(20400), valor)
+}
+
+func TestValorCalculado_IPIDevolvido(t *testing.T) {
+	inf := parseInfNFe(t, `<NFe><infNFe>
+		<det nItem="1"><prod><vProd>100.00</vProd><indTot>1</indTot></prod></det>
+		<total><ICMSTot><vIPIDevol>5.00</vIPIDevol><vNF>105.00</vNF></ICMSTot></total>
+	</infNFe></NFe>`)
+
+	valor, err := valorCalculado(inf)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money(10500), valor)
+}
+
+func TestValorCalculado_Servicos(t *testing.T) {
+	// NFe conjugada: o vProd do item de serviço está no vServ do ISSQNtot
+	inf := parseInfNFe(t, `<NFe><infNFe>
+		<det nItem="1"><prod><vProd>100.00</vProd><indTot>1</indTot></prod></det>
+		<det nItem="2"><prod><vProd>50.00</vProd><indTot>1</indTot></prod><imposto><ISSQN><vISSQN>2.50</vISSQN></ISSQN></imposto></det>
+		<total><ICMSTot><vNF>150.00</vNF></ICMSTot><ISSQNtot><vServ>50.00</vServ></ISSQNtot></total>
+	</infNFe></NFe>`)
+
+	valor, err := valorCalculado(inf)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money(15000), valor)
+}
+
+func TestValorCalculado_InvalidICMSDesonerado(t *testing.T) {
+	inf := parseInfNFe(t, `<NFe><infNFe>
+		<det nItem="1"><prod><vProd>10.00</vProd></prod></det>
+		<total><ICMSTot><vICMSDeson>abc</vICMSDeson></ICMSTot></total>
+	</infNFe></NFe>`)
+
+	_, err := valorCalculado(inf)
+	assert.ErrorIs(t, err, domain.ErrInvalidXML)
+}
@@ -1,14 +1,17 @@
 package certificate
 
 import (
+	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"os"
 
 	"software.sslmate.com/src/go-pkcs12"
 )
 
-// LoadCertificate carrega um certificado digital A1 (.pfx)
+// LoadCertificate carrega um certificado digital A1 (.pfx) com a cadeia de
+// certificados intermediários ICP-Brasil contida no arquivo
 func LoadCertificate(certPath, password string) (tls.Certificate, error) {
 	// Lê o arquivo do certificado
 	certData, err := os.ReadFile(certPath)
@@ -17,17 +20,47 @@ func LoadCertificate(certPath, password string) (tls.Certificate, error) {
 	}
 
 	// Decodifica o certificado PKCS#12
-	privateKey, certificate, err := pkcs12.Decode(certData, password)
+	privateKey, certificate, caCerts, err := pkcs12.DecodeChain(certData, password)
 	if err != nil {
 		return tls.Certificate{}, fmt.Errorf("failed to decode certificate: %w", err)
 	}
 
-	// Cria o certificado TLS
+	// Cria o certificado TLS. Algumas UFs recusam o handshake quando apenas o
+	// certificado final é apresentado, por isso os intermediários são enviados
+	// em seguida
 	tlsCert := tls.Certificate{
-		Certificate: [][]byte{certificate.Raw},
+		Certificate: buildChain(certificate, caCerts),
 		PrivateKey:  privateKey,
 		Leaf:        certificate,
 	}
 
 	return tlsCert, nil
-}
\ No newline at end of file
+}
+
+// buildChain ordena os intermediários do certificado final até a AC raiz, como
+// exigido no handshake TLS. A raiz autoassinada fica de fora (o servidor já a
+// possui), assim como certificados do arquivo que não fazem parte da cadeia.
+func buildChain(leaf *x509.Certificate, caCerts []*x509.Certificate) [][]byte {
+	chain := [][]byte{leaf.Raw}
+	used := make([]bool, len(caCerts))
+
+	current := leaf
+	for len(chain) <= len(caCerts) {
+		next := -1
+		for i, ca := range caCerts {
+			if !used[i] && bytes.Equal(ca.RawSubject, current.RawIssuer) {
+				next = i
+				break
+			}
+		}
+		if next < 0 || bytes.Equal(caCerts[next].RawSubject, caCerts[next].RawIssuer) {
+			break
+		}
+
+		used[next] = true
+		current = caCerts[next]
+		chain = append(chain, current.Raw)
+	}
+
+	return chain
+}
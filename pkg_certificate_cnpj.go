@@ -0,0 +1,116 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// oidSubjectAltName identifica a extensão Subject Alternative Name
+	oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+	// oidCNPJ identifica o CNPJ da pessoa jurídica titular nos certificados ICP-Brasil
+	oidCNPJ = asn1.ObjectIdentifier{2, 16, 76, 1, 3, 3}
+
+	// ErrCNPJNotFound é retornado quando o certificado não informa o CNPJ do titular
+	ErrCNPJNotFound = errors.New("certificate does not contain a cnpj")
+)
+
+// otherName representa um nome alternativo do tipo otherName (RFC 5280)
+type otherName struct {
+	TypeID asn1.ObjectIdentifier
+	Value  asn1.RawValue
+}
+
+// CNPJ extrai o CNPJ do titular de um certificado ICP-Brasil. O CNPJ fica no
+// otherName 2.16.76.1.3.3 do Subject Alternative Name; certificados que não o
+// trazem são aceitos se o CN terminar em ":CNPJ", formato usado pelas ACs.
+func CNPJ(cert *x509.Certificate) (string, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		cnpj, err := cnpjFromSubjectAltName(ext.Value)
+		if err != nil {
+			return "", err
+		}
+		if cnpj != "" {
+			return cnpj, nil
+		}
+	}
+
+	if _, suffix, ok := strings.Cut(cert.Subject.CommonName, ":"); ok {
+		if cnpj := digits(suffix); len(cnpj) == 14 {
+			return cnpj, nil
+		}
+	}
+
+	return "", ErrCNPJNotFound
+}
+
+// CheckCNPJ verifica se o certificado pertence ao CNPJ informado
+func CheckCNPJ(cert *x509.Certificate, cnpj string) error {
+	certCNPJ, err := CNPJ(cert)
+	if err != nil {
+		return err
+	}
+	if certCNPJ != cnpj {
+		return fmt.Errorf("certificate belongs to cnpj %s, expected %s", certCNPJ, cnpj)
+	}
+	return nil
+}
+
+// cnpjFromSubjectAltName procura o otherName do CNPJ na extensão Subject
+// Alternative Name; retorna vazio quando ele não está presente
+func cnpjFromSubjectAltName(value []byte) (string, error) {
+	var names asn1.RawValue
+	if _, err := asn1.Unmarshal(value, &names); err != nil {
+		return "", fmt.Errorf("failed to parse subject alternative name: %w", err)
+	}
+
+	rest := names.Bytes
+	for len(rest) > 0 {
+		var name asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &name); err != nil {
+			return "", fmt.Errorf("failed to parse subject alternative name: %w", err)
+		}
+		// otherName é o GeneralName [0]
+		if name.Class != asn1.ClassContextSpecific || name.Tag != 0 {
+			continue
+		}
+
+		var other otherName
+		if _, err := asn1.UnmarshalWithParams(name.FullBytes, &other, "tag:0"); err != nil {
+			return "", fmt.Errorf("failed to parse subject alternative name: %w", err)
+		}
+		if !other.TypeID.Equal(oidCNPJ) {
+			continue
+		}
+
+		// O valor é [0] EXPLICIT envolvendo uma string (PrintableString ou OCTET STRING)
+		var str asn1.RawValue
+		if _, err := asn1.Unmarshal(other.Value.Bytes, &str); err != nil {
+			return "", fmt.Errorf("failed to parse certificate cnpj: %w", err)
+		}
+		if cnpj := digits(string(str.Bytes)); cnpj != "" {
+			return cnpj, nil
+		}
+	}
+
+	return "", nil
+}
+
+// digits mantém apenas os dígitos de s
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
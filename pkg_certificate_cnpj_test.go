@@ -0,0 +1,97 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificate gera um certificado autoassinado com o CN e, quando
+// informado, o CNPJ no otherName 2.16.76.1.3.3
+func newTestCertificate(t *testing.T, commonName, cnpj string) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if cnpj != "" {
+		value, err := asn1.Marshal(asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      mustMarshal(t, cnpj, "printable"),
+		})
+		require.NoError(t, err)
+		other, err := asn1.MarshalWithParams(otherName{
+			TypeID: oidCNPJ,
+			Value:  asn1.RawValue{FullBytes: value},
+		}, "tag:0")
+		require.NoError(t, err)
+		email := mustMarshal(t, "contato@empresa.com.br", "tag:1,ia5")
+		san, err := asn1.Marshal(asn1.RawValue{
+			Class:      asn1.ClassUniversal,
+			Tag:        asn1.TagSequence,
+			IsCompound: true,
+			Bytes:      append(email, other...),
+		})
+		require.NoError(t, err)
+		template.ExtraExtensions = []pkix.Extension{{Id: oidSubjectAltName, Value: san}}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func mustMarshal(t *testing.T, value interface{}, params string) []byte {
+	t.Helper()
+	data, err := asn1.MarshalWithParams(value, params)
+	require.NoError(t, err)
+	return data
+}
+
+func TestCNPJ_SubjectAltName(t *testing.T) {
+	cert := newTestCertificate(t, "EMPRESA LTDA:99999999000191", "12345678000100")
+
+	cnpj, err := CNPJ(cert)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678000100", cnpj)
+}
+
+func TestCNPJ_CommonNameFallback(t *testing.T) {
+	cert := newTestCertificate(t, "EMPRESA LTDA:12345678000100", "")
+
+	cnpj, err := CNPJ(cert)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678000100", cnpj)
+}
+
+func TestCNPJ_NotFound(t *testing.T) {
+	cert := newTestCertificate(t, "EMPRESA LTDA", "")
+
+	_, err := CNPJ(cert)
+	assert.ErrorIs(t, err, ErrCNPJNotFound)
+}
+
+func TestCheckCNPJ(t *testing.T) {
+	cert := newTestCertificate(t, "EMPRESA LTDA", "12345678000100")
+
+	assert.NoError(t, CheckCNPJ(cert, "12345678000100"))
+	assert.Error(t, CheckCNPJ(cert, "98765432000199"))
+}
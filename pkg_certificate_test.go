@@ -0,0 +1,61 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueCertificate emite um certificado assinado por parent; sem parent o
+// certificado é autoassinado
+func issueCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestBuildChain(t *testing.T) {
+	root, rootKey := issueCertificate(t, "AC Raiz Brasileira", nil, nil)
+	ac, acKey := issueCertificate(t, "AC Intermediaria", root, rootKey)
+	acFinal, acFinalKey := issueCertificate(t, "AC Final", ac, acKey)
+	leaf, _ := issueCertificate(t, "EMPRESA LTDA:12345678000100", acFinal, acFinalKey)
+	other, _ := issueCertificate(t, "Outra AC", nil, nil)
+
+	chain := buildChain(leaf, []*x509.Certificate{root, other, ac, acFinal})
+
+	assert.Equal(t, [][]byte{leaf.Raw, acFinal.Raw, ac.Raw}, chain)
+}
+
+func TestBuildChain_LeafOnly(t *testing.T) {
+	leaf, _ := issueCertificate(t, "EMPRESA LTDA:12345678000100", nil, nil)
+
+	assert.Equal(t, [][]byte{leaf.Raw}, buildChain(leaf, nil))
+}
@@ -2,14 +2,21 @@ package database
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
-// NewPostgresConnection cria uma nova conexão com o PostgreSQL
-func NewPostgresConnection(dsn string, maxConnections int) (*sqlx.DB, error) {
+// NewPostgresConnection cria uma nova conexão com o PostgreSQL. applicationName
+// é enviado como application_name em cada conexão do pool, permitindo que os
+// DBAs identifiquem a aplicação em pg_stat_activity; vazio mantém o padrão do driver.
+func NewPostgresConnection(dsn string, maxConnections int, applicationName string) (*sqlx.DB, error) {
+	if applicationName != "" {
+		dsn += " application_name=" + quoteDSNValue(applicationName)
+	}
+
 	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -27,4 +34,11 @@ func NewPostgresConnection(dsn string, maxConnections int) (*sqlx.DB, error) {
 	}
 
 	return db, nil
-}
\ No newline at end of file
+}
+
+// quoteDSNValue escapa um valor para a string de conexão no formato chave=valor
+func quoteDSNValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger encapsula o logger estruturado do zap
+type Logger struct {
+	sugar *zap.SugaredLogger
+}
+
+// New cria um novo logger com o nível informado (debug, info, warn, error)
+func New(level string) *Logger {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = zapcore.InfoLevel
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+	cfg.EncoderConfig.TimeKey = "timestamp"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	base, err := cfg.Build(zap.AddCallerSkip(1))
+	if err != nil {
+		base = zap.NewNop()
+	}
+
+	return &Logger{sugar: base.Sugar()}
+}
+
+// Debug registra uma mensagem de debug com pares chave/valor
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	l.sugar.Debugw(msg, keysAndValues...)
+}
+
+// Info registra uma mensagem informativa com pares chave/valor
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.sugar.Infow(msg, keysAndValues...)
+}
+
+// Warn registra um alerta com pares chave/valor
+func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	l.sugar.Warnw(msg, keysAndValues...)
+}
+
+// Error registra um erro com pares chave/valor
+func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
+	l.sugar.Errorw(msg, keysAndValues...)
+}
+
+// Fatal registra um erro e encerra a aplicação
+func (l *Logger) Fatal(msg string, keysAndValues ...interface{}) {
+	l.sugar.Fatalw(msg, keysAndValues...)
+}
+
+// With retorna um logger com campos fixos adicionados
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	return &Logger{sugar: l.sugar.With(keysAndValues...)}
+}
+
+// Sync descarrega os buffers do logger
+func (l *Logger) Sync() error {
+	return l.sugar.Sync()
+}
@@ -0,0 +1,134 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Dimensões de uma página A4 em pontos
+	pageWidth  = 595.0
+	pageHeight = 842.0
+
+	// Margin é a margem de todos os lados da página, em pontos
+	Margin = 50.0
+
+	// lineSpacing é a altura da linha como múltiplo do tamanho da fonte
+	lineSpacing = 1.4
+)
+
+// Column é um texto posicionado a X pontos da margem esquerda em uma linha
+type Column struct {
+	X    float64
+	Text string
+}
+
+// Document monta um PDF de texto simples em páginas A4, com as fontes padrão
+// Helvetica e Helvetica-Bold. O texto é codificado em WinAnsi, que cobre os
+// acentos do português; caracteres fora dele são trocados por "?".
+type Document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// New cria um documento com uma página em branco
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+// Text escreve uma linha de texto na margem esquerda
+func (d *Document) Text(text string, size float64, bold bool) {
+	d.Row(size, bold, Column{Text: text})
+}
+
+// Row escreve uma linha com várias colunas, quebrando a página quando não há espaço
+func (d *Document) Row(size float64, bold bool, columns ...Column) {
+	height := size * lineSpacing
+	if d.y-height < Margin {
+		d.newPage()
+	}
+	d.y -= height
+
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	page := d.pages[len(d.pages)-1]
+	for _, col := range columns {
+		fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, Margin+col.X, d.y, escape(col.Text))
+	}
+}
+
+// Space avança verticalmente a posição de escrita
+func (d *Document) Space(height float64) {
+	d.y -= height
+}
+
+// PageCount retorna o número de páginas do documento
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Bytes gera o arquivo PDF
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catálogo, 2: árvore de páginas, 3 e 4: fontes, depois página e conteúdo
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// newPage inicia uma nova página com a posição de escrita no topo
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - Margin
+}
+
+// escape codifica o texto em WinAnsi e escapa os caracteres especiais das strings PDF
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	doc := New()
+	doc.Text("Relatório (mensal)", 16, true)
+	doc.Row(10, false, Column{Text: "Autorizada"}, Column{X: 200, Text: "10"})
+
+	data := doc.Bytes()
+
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+	assert.Contains(t, string(data), "(Relat\xf3rio \\(mensal\\)) Tj")
+	assert.Contains(t, string(data), "/F2 16.0 Tf")
+	assert.Contains(t, string(data), "250.00")
+
+	// startxref deve apontar para a tabela xref
+	trailer := string(data[bytes.LastIndex(data, []byte("startxref\n"))+len("startxref\n"):])
+	offset, err := strconv.Atoi(strings.SplitN(trailer, "\n", 2)[0])
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data[offset:], []byte("xref\n0 7\n")))
+}
+
+func TestDocument_PageBreak(t *testing.T) {
+	doc := New()
+	for i := 0; i < 100; i++ {
+		doc.Text("linha", 12, false)
+	}
+
+	assert.Equal(t, 3, doc.PageCount())
+	assert.Contains(t, string(doc.Bytes()), "/Count 3")
+}
+
+func TestEscape_UnsupportedCharacters(t *testing.T) {
+	assert.Equal(t, "a?b", escape("a€b"))
+}
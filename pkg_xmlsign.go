@@ -0,0 +1,453 @@
+package xmlsign
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// xmlNamespace é o namespace implícito do prefixo xml
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+	// dsigNamespace é o namespace da assinatura XML (XMLDSig)
+	dsigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+)
+
+// ErrElementNotFound é retornado quando o elemento referenciado pelo Id não existe
+var ErrElementNotFound = errors.New("referenced element not found")
+
+// node representa um nó da árvore usada na canonicalização
+type node struct {
+	// elemento
+	prefix   string
+	local    string
+	attrs    []xml.Attr
+	nsDecls  map[string]string
+	children []*node
+	parent   *node
+
+	// texto ou instrução de processamento (quando isElement é false)
+	isElement bool
+	text      string
+	procInst  *xml.ProcInst
+}
+
+// Canonicalize retorna a forma canônica (Canonical XML 1.0, sem comentários)
+// do elemento raiz do documento
+func Canonicalize(data []byte) ([]byte, error) {
+	root, err := parseTree(data)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeSubtree(root, false), nil
+}
+
+// CanonicalizeByID retorna a forma canônica do elemento com o atributo Id
+// informado (ex: o infNFe referenciado pela assinatura da NFe). Os namespaces e
+// atributos xml:* herdados dos ancestrais são incluídos no elemento, como exige
+// a canonicalização inclusiva. Com envelopedSignature, elementos Signature
+// dentro do elemento são removidos (transformação enveloped-signature).
+func CanonicalizeByID(data []byte, id string, envelopedSignature bool) ([]byte, error) {
+	root, err := parseTree(data)
+	if err != nil {
+		return nil, err
+	}
+
+	target := findByID(root, id)
+	if target == nil {
+		return nil, fmt.Errorf("%w: Id %q", ErrElementNotFound, id)
+	}
+
+	return canonicalizeSubtree(target, envelopedSignature), nil
+}
+
+// parseTree monta a árvore do documento preservando prefixos e declarações de namespace
+func parseTree(data []byte) (*node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(normalizeAttrWhitespace(data)))
+	decoder.Strict = true
+
+	var root, current *node
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &node{
+				isElement: true,
+				prefix:    t.Name.Space,
+				local:     t.Name.Local,
+				nsDecls:   map[string]string{},
+				parent:    current,
+			}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					el.nsDecls[""] = attr.Value
+				case attr.Name.Space == "xmlns":
+					el.nsDecls[attr.Name.Local] = attr.Value
+				default:
+					el.attrs = append(el.attrs, attr)
+				}
+			}
+
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("failed to parse xml: multiple root elements")
+				}
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+
+		case xml.EndElement:
+			if current == nil || current.prefix != t.Name.Space || current.local != t.Name.Local {
+				return nil, fmt.Errorf("failed to parse xml: unexpected end element %s", t.Name.Local)
+			}
+			current = current.parent
+
+		case xml.CharData:
+			// Conteúdo fora do elemento raiz não faz parte da forma canônica
+			if current != nil {
+				current.children = append(current.children, &node{text: string(t), parent: current})
+			}
+
+		case xml.ProcInst:
+			if current != nil && t.Target != "xml" {
+				pi := t.Copy()
+				current.children = append(current.children, &node{procInst: &pi, parent: current})
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("failed to parse xml: no root element")
+	}
+	if current != nil {
+		return nil, errors.New("failed to parse xml: unclosed elements")
+	}
+
+	return root, nil
+}
+
+// normalizeAttrWhitespace aplica a normalização de valores de atributo do XML
+// 1.0, que substitui tabulações e quebras de linha literais por espaços. É
+// feita sobre o documento original, antes da decodificação, porque as
+// referências de caractere (&#x9;, &#xA;, &#xD;) não são normalizadas e
+// voltam escapadas na forma canônica. Comentários, CDATA, instruções de
+// processamento e declarações são copiados sem alteração.
+func normalizeAttrWhitespace(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		if data[i] != '<' {
+			out = append(out, data[i])
+			i++
+			continue
+		}
+
+		end := markupEnd(data, i)
+		if end < 0 {
+			return append(out, data[i:]...)
+		}
+		if i+1 < len(data) && (data[i+1] == '!' || data[i+1] == '?' || data[i+1] == '/') {
+			out = append(out, data[i:end]...)
+			i = end
+			continue
+		}
+
+		var quote byte
+		for j := i; j < end; j++ {
+			b := data[j]
+			switch {
+			case quote == 0:
+				if b == '"' || b == '\'' {
+					quote = b
+				}
+			case b == quote:
+				quote = 0
+			case b == '\r' && j+1 < end && data[j+1] == '\n':
+				// \r\n é uma única quebra de linha (fim de linha do XML 1.0)
+				continue
+			case b == '\t' || b == '\n' || b == '\r':
+				b = ' '
+			}
+			out = append(out, b)
+		}
+		i = end
+	}
+	return out
+}
+
+// markupEnd retorna a posição seguinte ao fim da marcação iniciada em start,
+// ou -1 quando ela não termina
+func markupEnd(data []byte, start int) int {
+	rest := data[start:]
+	terminator := ">"
+	switch {
+	case bytes.HasPrefix(rest, []byte("<!--")):
+		terminator = "-->"
+	case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+		terminator = "]]>"
+	case bytes.HasPrefix(rest, []byte("<?")):
+		terminator = "?>"
+	case bytes.HasPrefix(rest, []byte("<!")):
+		// Declarações podem ter um subconjunto interno entre colchetes
+		depth := 0
+		for j, b := range rest {
+			switch b {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			case '>':
+				if depth <= 0 {
+					return start + j + 1
+				}
+			}
+		}
+		return -1
+	}
+
+	if terminator == ">" {
+		// Em uma tag, '>' pode aparecer dentro de um valor de atributo
+		var quote byte
+		for j, b := range rest {
+			switch {
+			case quote != 0:
+				if b == quote {
+					quote = 0
+				}
+			case b == '"' || b == '\'':
+				quote = b
+			case b == '>':
+				return start + j + 1
+			}
+		}
+		return -1
+	}
+
+	idx := bytes.Index(rest, []byte(terminator))
+	if idx < 0 {
+		return -1
+	}
+	return start + idx + len(terminator)
+}
+
+// findByID busca em profundidade o elemento com atributo Id (ou ID/id) igual a id
+func findByID(n *node, id string) *node {
+	if !n.isElement {
+		return nil
+	}
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "" && strings.EqualFold(attr.Name.Local, "id") && attr.Value == id {
+			return n
+		}
+	}
+	for _, child := range n.children {
+		if found := findByID(child, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// inScopeNamespaces retorna os namespaces em escopo no elemento, incluindo os
+// declarados pelos ancestrais
+func inScopeNamespaces(n *node) map[string]string {
+	chain := []*node{}
+	for el := n; el != nil; el = el.parent {
+		chain = append(chain, el)
+	}
+
+	ns := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for prefix, uri := range chain[i].nsDecls {
+			ns[prefix] = uri
+		}
+	}
+	return ns
+}
+
+// inheritedXMLAttrs retorna os atributos xml:* dos ancestrais que não são
+// redefinidos pelo elemento
+func inheritedXMLAttrs(n *node) []xml.Attr {
+	own := map[string]bool{}
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "xml" {
+			own[attr.Name.Local] = true
+		}
+	}
+
+	inherited := []xml.Attr{}
+	for el := n.parent; el != nil; el = el.parent {
+		for _, attr := range el.attrs {
+			if attr.Name.Space == "xml" && !own[attr.Name.Local] {
+				own[attr.Name.Local] = true
+				inherited = append(inherited, attr)
+			}
+		}
+	}
+	return inherited
+}
+
+// canonicalizeSubtree serializa o elemento e seus descendentes na forma canônica
+func canonicalizeSubtree(apex *node, envelopedSignature bool) []byte {
+	c := &canonicalizer{envelopedSignature: envelopedSignature}
+
+	// O elemento apex é renderizado com todo o contexto herdado, como se o
+	// ancestral de saída não tivesse declarado nenhum namespace
+	c.writeElement(apex, inScopeNamespaces(apex), map[string]string{}, inheritedXMLAttrs(apex))
+	return c.buf.Bytes()
+}
+
+type canonicalizer struct {
+	buf                bytes.Buffer
+	envelopedSignature bool
+}
+
+// writeElement escreve um elemento. inScope são os namespaces em escopo no
+// elemento e rendered os namespaces já renderizados pelo ancestral de saída.
+func (c *canonicalizer) writeElement(n *node, inScope, rendered map[string]string, extraAttrs []xml.Attr) {
+	name := n.local
+	if n.prefix != "" {
+		name = n.prefix + ":" + n.local
+	}
+
+	c.buf.WriteString("<" + name)
+
+	// Declarações de namespace ainda não renderizadas com o mesmo valor,
+	// ordenadas pelo prefixo (o namespace padrão primeiro)
+	prefixes := make([]string, 0, len(inScope))
+	for prefix := range inScope {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	nextRendered := make(map[string]string, len(rendered)+len(prefixes))
+	for prefix, uri := range rendered {
+		nextRendered[prefix] = uri
+	}
+
+	for _, prefix := range prefixes {
+		uri := inScope[prefix]
+		if prefix == "xml" {
+			continue
+		}
+		if prefix == "" {
+			// xmlns="" só é necessário para desfazer um namespace padrão renderizado
+			if uri == "" && rendered[""] == "" {
+				continue
+			}
+		} else if uri == "" {
+			continue
+		}
+		if renderedURI, ok := rendered[prefix]; ok && renderedURI == uri {
+			continue
+		}
+
+		if prefix == "" {
+			c.buf.WriteString(` xmlns="`)
+		} else {
+			c.buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		c.buf.WriteString(escapeAttr(uri))
+		c.buf.WriteString(`"`)
+		nextRendered[prefix] = uri
+	}
+
+	// Atributos ordenados pelo URI do namespace e depois pelo nome local;
+	// atributos sem namespace vêm primeiro
+	attrs := append(append([]xml.Attr{}, n.attrs...), extraAttrs...)
+	sort.SliceStable(attrs, func(i, j int) bool {
+		ui, uj := attrNamespace(attrs[i], inScope), attrNamespace(attrs[j], inScope)
+		if ui != uj {
+			return ui < uj
+		}
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	for _, attr := range attrs {
+		c.buf.WriteString(" ")
+		if attr.Name.Space != "" {
+			c.buf.WriteString(attr.Name.Space + ":")
+		}
+		c.buf.WriteString(attr.Name.Local + `="` + escapeAttr(attr.Value) + `"`)
+	}
+	c.buf.WriteString(">")
+
+	for _, child := range n.children {
+		switch {
+		case child.isElement:
+			childScope := inScope
+			if len(child.nsDecls) > 0 {
+				childScope = make(map[string]string, len(inScope)+len(child.nsDecls))
+				for prefix, uri := range inScope {
+					childScope[prefix] = uri
+				}
+				for prefix, uri := range child.nsDecls {
+					childScope[prefix] = uri
+				}
+			}
+			if c.envelopedSignature && child.local == "Signature" && childScope[child.prefix] == dsigNamespace {
+				continue
+			}
+			c.writeElement(child, childScope, nextRendered, nil)
+
+		case child.procInst != nil:
+			c.buf.WriteString("<?" + child.procInst.Target)
+			if len(child.procInst.Inst) > 0 {
+				c.buf.WriteString(" " + string(child.procInst.Inst))
+			}
+			c.buf.WriteString("?>")
+
+		default:
+			c.buf.WriteString(escapeText(child.text))
+		}
+	}
+
+	c.buf.WriteString("</" + name + ">")
+}
+
+// attrNamespace resolve o URI do namespace de um atributo
+func attrNamespace(attr xml.Attr, inScope map[string]string) string {
+	switch attr.Name.Space {
+	case "":
+		return ""
+	case "xml":
+		return xmlNamespace
+	}
+	return inScope[attr.Name.Space]
+}
+
+// escapeText aplica o escape de conteúdo de texto da forma canônica
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		"\r", "&#xD;",
+	).Replace(s)
+}
+
+// escapeAttr aplica o escape de valores de atributo da forma canônica
+func escapeAttr(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		`"`, "&quot;",
+		"\t", "&#x9;",
+		"\n", "&#xA;",
+		"\r", "&#xD;",
+	).Replace(s)
+}
@@ -0,0 +1,80 @@
+package xmlsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SignByID assina o elemento com o Id informado (ex: o infInut da inutilização)
+// no perfil exigido pela SEFAZ: assinatura enveloped, C14N 1.0, RSA-SHA1 e o
+// certificado X.509 no KeyInfo. O elemento deve ser filho do elemento raiz; a
+// assinatura é inserida como último filho da raiz, logo após ele.
+func SignByID(data []byte, id string, cert tls.Certificate) ([]byte, error) {
+	root, err := parseTree(data)
+	if err != nil {
+		return nil, err
+	}
+
+	target := findByID(root, id)
+	if target == nil {
+		return nil, fmt.Errorf("%w: Id %q", ErrElementNotFound, id)
+	}
+	if target.parent != root {
+		return nil, fmt.Errorf("element with Id %q must be a child of the root element", id)
+	}
+
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("certificate has no x509 data")
+	}
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: private key cannot sign", ErrUnsupportedAlgorithm)
+	}
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("%w: certificate key is not rsa", ErrUnsupportedAlgorithm)
+	}
+
+	digest := sha1.Sum(canonicalizeSubtree(target, true))
+	references := `<CanonicalizationMethod Algorithm="` + algC14N + `"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="` + algRSASHA1 + `"></SignatureMethod>` +
+		`<Reference URI="#` + escapeAttr(id) + `"><Transforms>` +
+		`<Transform Algorithm="` + algEnvelopedSig + `"></Transform>` +
+		`<Transform Algorithm="` + algC14N + `"></Transform></Transforms>` +
+		`<DigestMethod Algorithm="` + algSHA1 + `"></DigestMethod>` +
+		`<DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</DigestValue></Reference>`
+
+	// Dentro do Signature o SignedInfo herda apenas o namespace XMLDSig, então
+	// sua forma canônica é a do elemento isolado com essa declaração
+	canonicalSignedInfo, err := Canonicalize([]byte(`<SignedInfo xmlns="` + dsigNamespace + `">` + references + `</SignedInfo>`))
+	if err != nil {
+		return nil, err
+	}
+	hashed := sha1.Sum(canonicalSignedInfo)
+	signatureValue, err := signer.Sign(rand.Reader, hashed[:], crypto.SHA1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign xml: %w", err)
+	}
+
+	signature := `<Signature xmlns="` + dsigNamespace + `"><SignedInfo>` + references + `</SignedInfo>` +
+		`<SignatureValue>` + base64.StdEncoding.EncodeToString(signatureValue) + `</SignatureValue>` +
+		`<KeyInfo><X509Data><X509Certificate>` + base64.StdEncoding.EncodeToString(cert.Certificate[0]) +
+		`</X509Certificate></X509Data></KeyInfo></Signature>`
+
+	end := bytes.LastIndex(data, []byte("</"))
+	if end < 0 {
+		return nil, errors.New("failed to sign xml: root element has no end tag")
+	}
+
+	signed := make([]byte, 0, len(data)+len(signature))
+	signed = append(signed, data[:end]...)
+	signed = append(signed, signature...)
+	signed = append(signed, data[end:]...)
+	return signed, nil
+}
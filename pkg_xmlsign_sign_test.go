@@ -0,0 +1,54 @@
+package xmlsign
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInutNFe = `<inutNFe xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00">` +
+	`<infInut Id="ID35251234567800010055001000000010000000020"><tpAmb>2</tpAmb><xServ>INUTILIZAR</xServ>` +
+	`<xJust>Falha na numeração do sistema emissor</xJust></infInut></inutNFe>`
+
+// testCertificate gera um certificado autoassinado com chave RSA
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "EMPRESA TESTE LTDA:12345678000100"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSignByID_Verifies(t *testing.T) {
+	signed, err := SignByID([]byte(testInutNFe), "ID35251234567800010055001000000010000000020", testCertificate(t))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(signed), "</Signature></inutNFe>"))
+
+	cert, err := VerifyByID(signed, "ID35251234567800010055001000000010000000020")
+	require.NoError(t, err)
+	assert.Equal(t, "EMPRESA TESTE LTDA:12345678000100", cert.Subject.CommonName)
+}
+
+func TestSignByID_ElementNotFound(t *testing.T) {
+	_, err := SignByID([]byte(testInutNFe), "ID00", testCertificate(t))
+	assert.ErrorIs(t, err, ErrElementNotFound)
+}
@@ -0,0 +1,90 @@
+package xmlsign
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Exemplo 3.3 da recomendação Canonical XML 1.0 (sem o DTD)
+func TestCanonicalize_StartAndEndTags(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<!-- comentário fora do documento -->
+<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e4   name="elem4"   id="elem4"   ></e4>
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+
+	expected := `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e4 id="elem4" name="elem4"></e4>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6 xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9 xmlns:a="http://www.ietf.org"></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+
+	out, err := Canonicalize([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(out))
+}
+
+func TestCanonicalize_TextEscaping(t *testing.T) {
+	input := `<doc><t>&lt;a &amp; b&gt; "q" <![CDATA[<x>]]><?pi  data ?><!-- removido --></t></doc>`
+
+	out, err := Canonicalize([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, `<doc><t>&lt;a &amp; b&gt; "q" &lt;x&gt;<?pi data ?></t></doc>`, string(out))
+}
+
+func TestCanonicalize_AttributeWhitespace(t *testing.T) {
+	// Espaços literais são normalizados; referências de caractere são mantidas
+	input := "<doc a=\"x&#xA;y&#x9;z&#xD;\" b=\"1\n2\t3\r\n4\"><t>a\tb</t></doc>"
+
+	out, err := Canonicalize([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, "<doc a=\"x&#xA;y&#x9;z&#xD;\" b=\"1 2 3 4\"><t>a\tb</t></doc>", string(out))
+}
+
+func TestCanonicalizeByID_InheritsNamespaceAndRemovesSignature(t *testing.T) {
+	input := `<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00">` +
+		`<NFe xmlns="http://www.portalfiscal.inf.br/nfe">` +
+		`<infNFe versao="4.00" Id="NFe35251234567890123456789012345678901234567890">` +
+		`<ide><cUF>35</cUF></ide>` +
+		`<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo/></Signature>` +
+		`</infNFe>` +
+		`</NFe></nfeProc>`
+
+	out, err := CanonicalizeByID([]byte(input), "NFe35251234567890123456789012345678901234567890", true)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`<infNFe xmlns="http://www.portalfiscal.inf.br/nfe" Id="NFe35251234567890123456789012345678901234567890" versao="4.00">`+
+			`<ide><cUF>35</cUF></ide></infNFe>`,
+		string(out))
+}
+
+func TestCanonicalizeByID_NotFound(t *testing.T) {
+	_, err := CanonicalizeByID([]byte(`<NFe><infNFe Id="NFe1"/></NFe>`), "NFe2", false)
+	assert.True(t, errors.Is(err, ErrElementNotFound))
+}
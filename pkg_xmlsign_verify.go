@@ -0,0 +1,243 @@
+package xmlsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1"   // registra crypto.SHA1
+	_ "crypto/sha256" // registra crypto.SHA256
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	algC14N         = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algEnvelopedSig = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA1         = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algSHA256       = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algRSASHA1      = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algRSASHA256    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+)
+
+var (
+	// ErrSignatureNotFound é retornado quando não há assinatura referenciando o elemento
+	ErrSignatureNotFound = errors.New("signature not found")
+
+	// ErrUnsupportedAlgorithm é retornado para algoritmos fora do padrão da NFe
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+
+	// ErrDigestMismatch indica que o elemento assinado foi alterado após a assinatura
+	ErrDigestMismatch = errors.New("digest does not match signed content")
+
+	// ErrInvalidSignature indica que o SignatureValue não confere com o certificado
+	ErrInvalidSignature = errors.New("invalid signature value")
+)
+
+// VerifyByID verifica a assinatura XMLDSig enveloped que referencia o elemento
+// com o Id informado (ex: "NFe3525..." para o infNFe), no perfil usado pela NFe:
+// canonicalização C14N 1.0, RSA com SHA-1 ou SHA-256 e certificado X.509 no
+// KeyInfo. Retorna o certificado do signatário quando a assinatura é válida; a
+// cadeia de certificação não é verificada.
+func VerifyByID(data []byte, id string) (*x509.Certificate, error) {
+	root, err := parseTree(data)
+	if err != nil {
+		return nil, err
+	}
+
+	target := findByID(root, id)
+	if target == nil {
+		return nil, fmt.Errorf("%w: Id %q", ErrElementNotFound, id)
+	}
+
+	signature, reference := findSignature(root, "#"+id)
+	if signature == nil {
+		return nil, fmt.Errorf("%w: reference #%s", ErrSignatureNotFound, id)
+	}
+	signedInfo := dsigChild(signature, "SignedInfo")
+
+	if alg := algorithm(dsigChild(signedInfo, "CanonicalizationMethod")); alg != algC14N {
+		return nil, fmt.Errorf("%w: canonicalization %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	enveloped := false
+	for _, transform := range dsigChildren(dsigChild(reference, "Transforms"), "Transform") {
+		switch alg := algorithm(transform); alg {
+		case algEnvelopedSig:
+			enveloped = true
+		case algC14N:
+		default:
+			return nil, fmt.Errorf("%w: transform %q", ErrUnsupportedAlgorithm, alg)
+		}
+	}
+
+	digestHash, err := hashFor(algorithm(dsigChild(reference, "DigestMethod")))
+	if err != nil {
+		return nil, err
+	}
+	expectedDigest, err := decodeBase64(textContent(dsigChild(reference, "DigestValue")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode digest value: %w", err)
+	}
+
+	h := digestHash.New()
+	h.Write(canonicalizeSubtree(target, enveloped))
+	if !bytes.Equal(h.Sum(nil), expectedDigest) {
+		return nil, ErrDigestMismatch
+	}
+
+	cert, err := signerCertificate(signature)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: certificate key is not rsa", ErrUnsupportedAlgorithm)
+	}
+
+	signatureHash, err := signatureHashFor(algorithm(dsigChild(signedInfo, "SignatureMethod")))
+	if err != nil {
+		return nil, err
+	}
+	signatureValue, err := decodeBase64(textContent(dsigChild(signature, "SignatureValue")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature value: %w", err)
+	}
+
+	h = signatureHash.New()
+	h.Write(canonicalizeSubtree(signedInfo, false))
+	if err := rsa.VerifyPKCS1v15(publicKey, signatureHash, h.Sum(nil), signatureValue); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	return cert, nil
+}
+
+// findSignature busca o elemento Signature cujo Reference aponta para uri
+func findSignature(n *node, uri string) (signature, reference *node) {
+	if !n.isElement {
+		return nil, nil
+	}
+	if isDsig(n, "Signature") {
+		for _, ref := range dsigChildren(dsigChild(n, "SignedInfo"), "Reference") {
+			if attrValue(ref, "URI") == uri {
+				return n, ref
+			}
+		}
+	}
+	for _, child := range n.children {
+		if signature, reference := findSignature(child, uri); signature != nil {
+			return signature, reference
+		}
+	}
+	return nil, nil
+}
+
+// isDsig indica se o nó é o elemento local do namespace XMLDSig
+func isDsig(n *node, local string) bool {
+	return n != nil && n.isElement && n.local == local && inScopeNamespaces(n)[n.prefix] == dsigNamespace
+}
+
+// dsigChild retorna o primeiro filho XMLDSig com o nome local informado
+func dsigChild(n *node, local string) *node {
+	if children := dsigChildren(n, local); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// dsigChildren retorna os filhos XMLDSig com o nome local informado
+func dsigChildren(n *node, local string) []*node {
+	if n == nil {
+		return nil
+	}
+	children := []*node{}
+	for _, child := range n.children {
+		if isDsig(child, local) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// attrValue retorna o valor de um atributo sem namespace
+func attrValue(n *node, name string) string {
+	if n == nil {
+		return ""
+	}
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// algorithm retorna o atributo Algorithm do elemento
+func algorithm(n *node) string {
+	return attrValue(n, "Algorithm")
+}
+
+// textContent concatena o texto dos descendentes do elemento
+func textContent(n *node) string {
+	if n == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, child := range n.children {
+		if child.isElement {
+			sb.WriteString(textContent(child))
+		} else if child.procInst == nil {
+			sb.WriteString(child.text)
+		}
+	}
+	return sb.String()
+}
+
+// decodeBase64 decodifica um valor base64 ignorando quebras de linha e espaços
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+// signerCertificate extrai o certificado X.509 do KeyInfo da assinatura
+func signerCertificate(signature *node) (*x509.Certificate, error) {
+	certNode := dsigChild(dsigChild(dsigChild(signature, "KeyInfo"), "X509Data"), "X509Certificate")
+	if certNode == nil {
+		return nil, fmt.Errorf("%w: X509Certificate not found in KeyInfo", ErrInvalidSignature)
+	}
+
+	der, err := decodeBase64(textContent(certNode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// hashFor resolve o algoritmo do DigestMethod
+func hashFor(alg string) (crypto.Hash, error) {
+	switch alg {
+	case algSHA1:
+		return crypto.SHA1, nil
+	case algSHA256:
+		return crypto.SHA256, nil
+	}
+	return 0, fmt.Errorf("%w: digest %q", ErrUnsupportedAlgorithm, alg)
+}
+
+// signatureHashFor resolve o hash do SignatureMethod
+func signatureHashFor(alg string) (crypto.Hash, error) {
+	switch alg {
+	case algRSASHA1:
+		return crypto.SHA1, nil
+	case algRSASHA256:
+		return crypto.SHA256, nil
+	}
+	return 0, fmt.Errorf("%w: signature method %q", ErrUnsupportedAlgorithm, alg)
+}
@@ -0,0 +1,86 @@
+package xmlsign
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInfNFe = `<infNFe Id="NFe35251234567890123456789012345678901234567890" versao="4.00"><ide><nNF>123</nNF></ide><total><ICMSTot><vNF>1500.50</vNF></ICMSTot></total></infNFe>`
+
+// signedTestNFe monta uma NFe assinada com um certificado autoassinado
+func signedTestNFe(t *testing.T) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "EMPRESA TESTE LTDA:12345678000100"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	doc := `<NFe xmlns="http://www.portalfiscal.inf.br/nfe">` + testInfNFe + `</NFe>`
+	canonical, err := CanonicalizeByID([]byte(doc), "NFe35251234567890123456789012345678901234567890", true)
+	require.NoError(t, err)
+	digest := sha1.Sum(canonical)
+
+	signedInfo := `<SignedInfo xmlns="http://www.w3.org/2000/09/xmldsig#">` +
+		`<CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"></SignatureMethod>` +
+		`<Reference URI="#NFe35251234567890123456789012345678901234567890"><Transforms>` +
+		`<Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"></Transform>` +
+		`<Transform Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"></Transform></Transforms>` +
+		`<DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"></DigestMethod>` +
+		`<DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</DigestValue></Reference></SignedInfo>`
+
+	canonicalSignedInfo, err := Canonicalize([]byte(signedInfo))
+	require.NoError(t, err)
+	hashed := sha1.Sum(canonicalSignedInfo)
+	signatureValue, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, hashed[:])
+	require.NoError(t, err)
+
+	return `<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><NFe xmlns="http://www.portalfiscal.inf.br/nfe">` +
+		testInfNFe +
+		`<Signature xmlns="http://www.w3.org/2000/09/xmldsig#">` + strings.Replace(signedInfo, ` xmlns="http://www.w3.org/2000/09/xmldsig#"`, "", 1) +
+		`<SignatureValue>` + base64.StdEncoding.EncodeToString(signatureValue) + `</SignatureValue>` +
+		`<KeyInfo><X509Data><X509Certificate>` + base64.StdEncoding.EncodeToString(der) + `</X509Certificate></X509Data></KeyInfo>` +
+		`</Signature></NFe></nfeProc>`
+}
+
+func TestVerifyByID_Valid(t *testing.T) {
+	doc := signedTestNFe(t)
+
+	cert, err := VerifyByID([]byte(doc), "NFe35251234567890123456789012345678901234567890")
+	require.NoError(t, err)
+	assert.Equal(t, "EMPRESA TESTE LTDA:12345678000100", cert.Subject.CommonName)
+}
+
+func TestVerifyByID_TamperedContent(t *testing.T) {
+	doc := strings.Replace(signedTestNFe(t), "<vNF>1500.50</vNF>", "<vNF>150.50</vNF>", 1)
+
+	_, err := VerifyByID([]byte(doc), "NFe35251234567890123456789012345678901234567890")
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+}
+
+func TestVerifyByID_WithoutSignature(t *testing.T) {
+	doc := `<NFe xmlns="http://www.portalfiscal.inf.br/nfe">` + testInfNFe + `</NFe>`
+
+	_, err := VerifyByID([]byte(doc), "NFe35251234567890123456789012345678901234567890")
+	assert.ErrorIs(t, err, ErrSignatureNotFound)
+}
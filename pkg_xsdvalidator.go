@@ -0,0 +1,132 @@
+package xsdvalidator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// SchemaNFe e SchemaProcNFe são os schemas de entrada do pacote de
+	// liberação da NFe 4.00, para o elemento NFe e para o nfeProc
+	SchemaNFe     = "nfe_v4.00.xsd"
+	SchemaProcNFe = "procNFe_v4.00.xsd"
+
+	// validateTimeout limita cada execução do xmllint
+	validateTimeout = 30 * time.Second
+
+	// exitValidationError é o código de saída do xmllint quando o documento
+	// não atende ao schema
+	exitValidationError = 3
+)
+
+// ErrUnknownRoot é retornado quando o elemento raiz não é NFe nem nfeProc
+var ErrUnknownRoot = errors.New("unknown root element")
+
+// Validator valida documentos da NFe com os schemas XSD oficiais, publicados
+// no Portal da NFe. Os schemas não fazem parte da aplicação: o diretório do
+// pacote de liberação é informado na configuração. A validação usa o xmllint
+// da libxml2, sem acesso à rede.
+type Validator struct {
+	dir     string
+	xmllint string
+}
+
+// New cria um validador para os schemas em dir. Retorna erro quando o xmllint
+// não está no PATH ou quando um dos schemas de entrada não existe.
+func New(dir string) (*Validator, error) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		return nil, fmt.Errorf("xmllint not found: %w", err)
+	}
+
+	for _, schema := range []string{SchemaNFe, SchemaProcNFe} {
+		if _, err := os.Stat(filepath.Join(dir, schema)); err != nil {
+			return nil, fmt.Errorf("schema %s not found: %w", schema, err)
+		}
+	}
+
+	return &Validator{dir: dir, xmllint: xmllint}, nil
+}
+
+// Validate valida o documento com o schema do seu elemento raiz e retorna as
+// violações encontradas, vazias quando o documento é válido. Um erro indica
+// que a validação não pôde ser executada.
+func (v *Validator) Validate(data []byte) ([]string, error) {
+	schema, err := schemaFor(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, v.xmllint, "--noout", "--nonet", "--schema", filepath.Join(v.dir, schema), "-")
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err == nil {
+		return []string{}, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == exitValidationError {
+		return parseViolations(stderr.String()), nil
+	}
+	return nil, fmt.Errorf("xmllint failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+}
+
+// schemaFor escolhe o schema pelo elemento raiz do documento
+func schemaFor(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to read root element: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "NFe":
+			return SchemaNFe, nil
+		case "nfeProc":
+			return SchemaProcNFe, nil
+		default:
+			return "", fmt.Errorf("%w: %s", ErrUnknownRoot, start.Name.Local)
+		}
+	}
+}
+
+// parseViolations extrai as violações da saída do xmllint, no formato
+// "-:LINHA: Schemas validity error : MENSAGEM", descartando o resumo final
+func parseViolations(output string) []string {
+	violations := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-:") {
+			continue
+		}
+
+		location, message, ok := strings.Cut(strings.TrimPrefix(line, "-:"), ": ")
+		if !ok {
+			continue
+		}
+		if _, detail, ok := strings.Cut(message, "error : "); ok {
+			message = detail
+		}
+		violations = append(violations, fmt.Sprintf("line %s: %s", location, message))
+	}
+	return violations
+}
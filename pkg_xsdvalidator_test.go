@@ -0,0 +1,81 @@
+package xsdvalidator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchema é um schema mínimo no namespace da NFe, gravado com os nomes dos
+// schemas de entrada
+const testSchema = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+	targetNamespace="http://www.portalfiscal.inf.br/nfe" xmlns="http://www.portalfiscal.inf.br/nfe"
+	elementFormDefault="qualified">
+	<xs:element name="NFe"><xs:complexType><xs:sequence>
+		<xs:element name="nNF" type="xs:int"/>
+	</xs:sequence></xs:complexType></xs:element>
+	<xs:element name="nfeProc"><xs:complexType><xs:sequence>
+		<xs:element ref="NFe"/>
+	</xs:sequence></xs:complexType></xs:element>
+</xs:schema>`
+
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint not installed")
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{SchemaNFe, SchemaProcNFe} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(testSchema), 0o600))
+	}
+
+	v, err := New(dir)
+	require.NoError(t, err)
+	return v
+}
+
+func TestNew_MissingSchema(t *testing.T) {
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint not installed")
+	}
+
+	_, err := New(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	v := newTestValidator(t)
+
+	violations, err := v.Validate([]byte(`<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe"><NFe><nNF>123</nNF></NFe></nfeProc>`))
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestValidate_Violations(t *testing.T) {
+	v := newTestValidator(t)
+
+	violations, err := v.Validate([]byte(`<NFe xmlns="http://www.portalfiscal.inf.br/nfe">
+<nNF>abc</nNF>
+</NFe>`))
+	assert.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0], "line 2: Element '{http://www.portalfiscal.inf.br/nfe}nNF'")
+}
+
+func TestValidate_UnknownRoot(t *testing.T) {
+	v := &Validator{}
+
+	_, err := v.Validate([]byte(`<resNFe/>`))
+	assert.ErrorIs(t, err, ErrUnknownRoot)
+}
+
+func TestParseViolations(t *testing.T) {
+	output := "-:3: Schemas validity error : Element 'vNF': This element is not expected.\n- fails to validate\n"
+
+	assert.Equal(t, []string{"line 3: Element 'vNF': This element is not expected."}, parseViolations(output))
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ListQuarantine lista as NFes em quarentena por divergência de ambiente
+// @Summary Listar NFes em quarentena
+// @Description Lista as NFes baixadas cujo tpAmb difere do ambiente configurado (ex: notas de teste em homologação recebidas em produção). Elas não são cadastradas nem entram nas consultas e relatórios.
+// @Tags NFe
+// @Produce json
+// @Param page query int false "Número da página" default(1)
+// @Param limit query int false "Itens por página" default(20)
+// @Success 200 {object} domain.PaginatedResponse[domain.QuarantinedNFe]
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/quarantine [get]
+func (h *NFeHandler) ListQuarantine(w http.ResponseWriter, r *http.Request) {
+	var filter domain.QuarantineFilter
+	if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+		filter.Page = page
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	response, err := h.service.ListQuarantine(filter)
+	if err != nil {
+		h.logger.Error("Erro ao listar NFes em quarentena", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao listar NFes em quarentena", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, response)
+}
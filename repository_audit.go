@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// createAuditEntries grava as alterações manuais de campos das NFes
+func createAuditEntries(exec sqlx.Execer, auditTable string, entries []domain.NFeAuditEntry) error {
+	query := `
+		INSERT INTO ` + auditTable + ` (
+			id, nfe_id, chave_acesso, field, old_value, new_value, request_id, changed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+	`
+
+	for _, entry := range entries {
+		_, err := exec.Exec(query,
+			entry.ID,
+			entry.NFeID,
+			entry.ChaveAcesso,
+			entry.Field,
+			entry.OldValue,
+			entry.NewValue,
+			entry.RequestID,
+			entry.ChangedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save audit entry for %s: %w", entry.Field, err)
+		}
+	}
+
+	return nil
+}
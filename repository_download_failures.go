@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// downloadFailureRepository implementa domain.DownloadFailureRepository usando PostgreSQL
+type downloadFailureRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewDownloadFailureRepository cria o repositório dos documentos da
+// distribuição DFe que falharam
+func NewDownloadFailureRepository(db *sqlx.DB, schema string) domain.DownloadFailureRepository {
+	return &downloadFailureRepository{
+		db:    db,
+		table: qualifiedTable(schema, "download_failures"),
+	}
+}
+
+// Save grava a falha do documento. Um documento recebido novamente (ex: o
+// cursor foi mantido por outros erros) tem o registro atualizado.
+func (r *downloadFailureRepository) Save(failure *domain.DownloadFailure) error {
+	query := `
+		INSERT INTO ` + r.table + ` (id, job_id, cnpj, nsu, doc_schema, content, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (cnpj, nsu) DO UPDATE SET
+			job_id = EXCLUDED.job_id,
+			doc_schema = EXCLUDED.doc_schema,
+			content = EXCLUDED.content,
+			error = EXCLUDED.error,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.db.Exec(query,
+		failure.ID,
+		failure.JobID,
+		failure.CNPJ,
+		failure.NSU,
+		failure.Schema,
+		failure.Content,
+		failure.Error,
+		failure.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save download failure: %w", err)
+	}
+
+	return nil
+}
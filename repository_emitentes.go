@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// likeEscaper escapa os curingas do LIKE em um termo de busca
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// FindEmitentes lista os emitentes distintos das NFes armazenadas, ordenados por
+// nome, com a quantidade e o valor total das suas NFes. A busca considera todos
+// os nomes já usados pelo emitente, não apenas o mais recente.
+func (r *nfeRepository) FindEmitentes(filter domain.EmitenteFilter) ([]domain.Emitente, int64, error) {
+	having := ""
+	args := []interface{}{}
+	if filter.Search != "" {
+		search := likeEscaper.Replace(filter.Search)
+		args = append(args, "%"+search+"%", search+"%")
+		having = `HAVING BOOL_OR(nome_emitente ILIKE $1) OR cnpj_emitente LIKE $2`
+	}
+
+	countQuery := `SELECT COUNT(*) FROM (SELECT cnpj_emitente FROM ` + r.table + ` GROUP BY cnpj_emitente ` + having + `) emitentes`
+
+	var total int64
+	if err := r.db.Get(&total, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count emitentes: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT cnpj_emitente AS cnpj,
+			(ARRAY_AGG(nome_emitente ORDER BY data_emissao DESC))[1] AS nome,
+			COUNT(*) AS nfe_count,
+			COALESCE(SUM(valor_total), 0) AS total_valor
+		FROM %s
+		GROUP BY cnpj_emitente
+		%s
+		ORDER BY nome, cnpj
+		LIMIT $%d OFFSET $%d`, r.table, having, len(args)+1, len(args)+2)
+
+	emitentes := []domain.Emitente{}
+	args = append(args, filter.Limit, filter.GetOffset())
+	if err := r.db.Select(&emitentes, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to find emitentes: %w", err)
+	}
+
+	return emitentes, total, nil
+}
+
+// FindNumeroGaps retorna as faixas de números ausentes entre as NFes armazenadas
+// do emitente na série, comparando cada número com o anterior (LAG) em ordem numérica
+func (r *nfeRepository) FindNumeroGaps(filter domain.NumeroGapFilter) ([]domain.NumeroGap, error) {
+	query := `
+		SELECT anterior + 1 AS numero_inicial, numero - 1 AS numero_final, numero - anterior - 1 AS quantidade
+		FROM (
+			SELECT numero, LAG(numero) OVER (ORDER BY numero) AS anterior
+			FROM (
+				SELECT DISTINCT CAST(numero AS BIGINT) AS numero
+				FROM ` + r.table + `
+				WHERE cnpj_emitente = $1 AND serie = $2 AND numero ~ '^[0-9]+$'
+			) numeros
+		) sequencia
+		WHERE numero - anterior > 1
+		ORDER BY numero_inicial`
+
+	gaps := []domain.NumeroGap{}
+	if err := r.db.Select(&gaps, query, filter.CNPJ, filter.Serie); err != nil {
+		return nil, fmt.Errorf("failed to find numero gaps: %w", err)
+	}
+
+	return gaps, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// idempotencyColumns lista as colunas de idempotency_keys na ordem de domain.IdempotencyRecord
+const idempotencyColumns = `idempotency_key, fingerprint, COALESCE(status_code, 0) AS status_code,
+	COALESCE(content_type, '') AS content_type, body, created_at, completed_at`
+
+// idempotencyRepository implementa domain.IdempotencyRepository usando PostgreSQL
+type idempotencyRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewIdempotencyRepository cria o repositório das chaves de idempotência
+func NewIdempotencyRepository(db *sqlx.DB, schema string) domain.IdempotencyRepository {
+	return &idempotencyRepository{
+		db:    db,
+		table: qualifiedTable(schema, "idempotency_keys"),
+	}
+}
+
+// Reserve grava a chave como em processamento, substituindo um registro
+// expirado. Quando a chave já está reservada desde notBefore, retorna o
+// registro existente.
+func (r *idempotencyRepository) Reserve(record *domain.IdempotencyRecord, notBefore time.Time) (*domain.IdempotencyRecord, error) {
+	query := `
+		INSERT INTO ` + r.table + ` (idempotency_key, fingerprint, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (idempotency_key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			body = NULL,
+			created_at = EXCLUDED.created_at,
+			completed_at = NULL
+		WHERE ` + r.table + `.created_at < $4
+		RETURNING idempotency_key`
+
+	var key string
+	err := r.db.Get(&key, query, record.Key, record.Fingerprint, record.CreatedAt, notBefore)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var existing domain.IdempotencyRecord
+	selectQuery := `SELECT ` + idempotencyColumns + ` FROM ` + r.table + ` WHERE idempotency_key = $1`
+	if err := r.db.Get(&existing, selectQuery, record.Key); err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+// Complete grava a resposta da requisição original
+func (r *idempotencyRepository) Complete(record *domain.IdempotencyRecord) error {
+	query := `
+		UPDATE ` + r.table + ` SET status_code = $2, content_type = $3, body = $4, completed_at = $5
+		WHERE idempotency_key = $1`
+
+	_, err := r.db.Exec(query, record.Key, record.StatusCode, record.ContentType, record.Body, record.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteBefore remove as chaves criadas antes de cutoff
+func (r *idempotencyRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM `+r.table+` WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idempotency keys: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deleted idempotency keys: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// expectedIndex descreve um índice necessário para as consultas do repositório
+type expectedIndex struct {
+	column string
+	name   string
+	expr   string
+}
+
+// expectedIndexes lista os índices usados por FindByFilter e pelas buscas por chave
+var expectedIndexes = []expectedIndex{
+	{column: "chave_acesso", name: "idx_nfes_chave_acesso", expr: "chave_acesso"},
+	{column: "data_emissao", name: "idx_nfes_data_emissao", expr: "data_emissao DESC"},
+	{column: "cnpj_emitente", name: "idx_nfes_cnpj_emitente", expr: "cnpj_emitente"},
+	{column: "status", name: "idx_nfes_status", expr: "status"},
+	{column: "numero", name: "idx_nfes_numero_serie", expr: "numero, serie"},
+	{column: "uf_emitente", name: "idx_nfes_uf_emitente", expr: "uf_emitente"},
+}
+
+// MissingIndexes verifica se os índices esperados existem na tabela nfes e
+// retorna os comandos CREATE INDEX dos que estiverem faltando. Um índice é
+// considerado presente quando existe algum índice cuja primeira coluna é a esperada.
+func MissingIndexes(db *sqlx.DB, schema string) ([]string, error) {
+	query := `
+		SELECT DISTINCT a.attname
+		FROM pg_index i
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = i.indkey[0]
+		WHERE t.relname = 'nfes' AND n.nspname = COALESCE(NULLIF($1, ''), current_schema())`
+
+	var columns []string
+	if err := db.Select(&columns, query, schema); err != nil {
+		return nil, fmt.Errorf("failed to list nfes indexes: %w", err)
+	}
+
+	indexed := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		indexed[c] = true
+	}
+
+	table := qualifiedTable(schema, "nfes")
+	missing := []string{}
+	for _, idx := range expectedIndexes {
+		if !indexed[idx.column] {
+			missing = append(missing, fmt.Sprintf("CREATE INDEX %s ON %s(%s);", idx.name, table, idx.expr))
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// inutilizacaoRepository implementa domain.InutilizacaoRepository usando PostgreSQL
+type inutilizacaoRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewInutilizacaoRepository cria o repositório dos pedidos de inutilização
+func NewInutilizacaoRepository(db *sqlx.DB, schema string) domain.InutilizacaoRepository {
+	return &inutilizacaoRepository{
+		db:    db,
+		table: qualifiedTable(schema, "inutilizacoes"),
+	}
+}
+
+// Save grava o resultado de um pedido de inutilização
+func (r *inutilizacaoRepository) Save(inutilizacao *domain.Inutilizacao) error {
+	query := `
+		INSERT INTO ` + r.table + ` (
+			id, cnpj, ano, modelo, serie, numero_inicial, numero_final, justificativa,
+			status, cstat, motivo, protocolo, data_recebimento, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), $13, $14)
+	`
+
+	_, err := r.db.Exec(query,
+		inutilizacao.ID,
+		inutilizacao.CNPJ,
+		inutilizacao.Ano,
+		inutilizacao.Modelo,
+		inutilizacao.Serie,
+		inutilizacao.NumeroInicial,
+		inutilizacao.NumeroFinal,
+		inutilizacao.Justificativa,
+		inutilizacao.Status,
+		inutilizacao.CStat,
+		inutilizacao.Motivo,
+		inutilizacao.Protocolo,
+		inutilizacao.DataRecebimento,
+		inutilizacao.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save inutilizacao: %w", err)
+	}
+
+	return nil
+}
+
+// FindHomologadas retorna as inutilizações homologadas do CNPJ na série,
+// ordenadas pelo número inicial
+func (r *inutilizacaoRepository) FindHomologadas(cnpj string, serie int) ([]domain.Inutilizacao, error) {
+	query := `
+		SELECT id, cnpj, ano, modelo, serie, numero_inicial, numero_final, justificativa,
+			status, cstat, motivo, COALESCE(protocolo, '') AS protocolo, data_recebimento, created_at
+		FROM ` + r.table + `
+		WHERE cnpj = $1 AND serie = $2 AND status = $3
+		ORDER BY numero_inicial`
+
+	inutilizacoes := []domain.Inutilizacao{}
+	if err := r.db.Select(&inutilizacoes, query, cnpj, serie, domain.InutilizacaoHomologada); err != nil {
+		return nil, fmt.Errorf("failed to find inutilizacoes: %w", err)
+	}
+
+	return inutilizacoes, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// nfeItemColumns lista as colunas retornadas nas consultas de itens
+const nfeItemColumns = `nfe_id, numero_item, codigo_produto, descricao, ncm, cfop,
+	unidade, quantidade, valor_total`
+
+// FindItensByNFeIDs busca em uma única consulta os itens das NFes informadas,
+// agrupados pelo id da NFe e ordenados pelo número do item
+func (r *nfeRepository) FindItensByNFeIDs(ids []uuid.UUID) (map[uuid.UUID][]domain.NFeItem, error) {
+	itens := map[uuid.UUID][]domain.NFeItem{}
+	if len(ids) == 0 {
+		return itens, nil
+	}
+
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+
+	query := `SELECT ` + nfeItemColumns + ` FROM ` + r.itensTable + `
+		WHERE nfe_id = ANY($1::uuid[]) ORDER BY nfe_id, numero_item`
+
+	rows := []domain.NFeItem{}
+	if err := r.db.Select(&rows, query, pq.Array(values)); err != nil {
+		return nil, fmt.Errorf("failed to find nfe itens: %w", err)
+	}
+
+	for _, item := range rows {
+		itens[item.NFeID] = append(itens[item.NFeID], item)
+	}
+
+	return itens, nil
+}
+
+// itemBatchSize limita as linhas de cada INSERT de itens, mantendo o número de
+// parâmetros abaixo do limite do PostgreSQL (65535)
+const itemBatchSize = 1000
+
+// CreateItemsBatch insere os itens, já vinculados às NFes por NFeID, com
+// INSERTs de várias linhas em vez de um comando por item
+func (r *nfeRepository) CreateItemsBatch(itens []domain.NFeItem) error {
+	return insertItens(r.db, r.itensTable, "", nil, itens, "")
+}
+
+// replaceItens substitui os itens da NFe identificada pela chave de acesso.
+// Os itens são vinculados ao id cadastrado, que pode diferir de nfe.ID quando
+// Create sobrescreve uma NFe existente.
+func replaceItens(exec sqlx.Execer, nfeTable, itensTable, chaveAcesso string, itens []domain.NFeItem) error {
+	deleteQuery := `DELETE FROM ` + itensTable + ` WHERE nfe_id = (SELECT id FROM ` + nfeTable + ` WHERE chave_acesso = $1)`
+	if _, err := exec.Exec(deleteQuery, chaveAcesso); err != nil {
+		return fmt.Errorf("failed to delete nfe itens: %w", err)
+	}
+
+	nfeID := `(SELECT id FROM ` + nfeTable + ` WHERE chave_acesso = $1)`
+	return insertItens(exec, itensTable, nfeID, []interface{}{chaveAcesso}, itens, "")
+}
+
+// itemUpsertClause atualiza o item já cadastrado com o mesmo número
+const itemUpsertClause = `ON CONFLICT (nfe_id, numero_item) DO UPDATE SET
+			codigo_produto = EXCLUDED.codigo_produto,
+			descricao = EXCLUDED.descricao,
+			ncm = EXCLUDED.ncm,
+			cfop = EXCLUDED.cfop,
+			unidade = EXCLUDED.unidade,
+			quantidade = EXCLUDED.quantidade,
+			valor_total = EXCLUDED.valor_total`
+
+// upsertItens grava os itens da NFe identificada pela chave de acesso
+// atualizando os já cadastrados pelo número do item, e remove os itens que não
+// constam mais na lista
+func upsertItens(exec sqlx.Execer, nfeTable, itensTable, chaveAcesso string, itens []domain.NFeItem) error {
+	nfeID := `(SELECT id FROM ` + nfeTable + ` WHERE chave_acesso = $1)`
+	if err := insertItens(exec, itensTable, nfeID, []interface{}{chaveAcesso}, itens, itemUpsertClause); err != nil {
+		return err
+	}
+
+	numeros := make([]int64, len(itens))
+	for i, item := range itens {
+		numeros[i] = int64(item.NumeroItem)
+	}
+
+	deleteQuery := `DELETE FROM ` + itensTable + ` WHERE nfe_id = ` + nfeID + ` AND numero_item <> ALL($2)`
+	if _, err := exec.Exec(deleteQuery, chaveAcesso, pq.Array(numeros)); err != nil {
+		return fmt.Errorf("failed to delete stale nfe itens: %w", err)
+	}
+
+	return nil
+}
+
+// insertItens grava os itens em lotes de itemBatchSize linhas por INSERT.
+// nfeIDExpr é a expressão SQL do nfe_id de todas as linhas, com seus parâmetros
+// em args; vazia, cada linha usa o NFeID do próprio item. onConflict é anexado
+// a cada INSERT (vazio para falhar em itens duplicados).
+func insertItens(exec sqlx.Execer, itensTable, nfeIDExpr string, args []interface{}, itens []domain.NFeItem, onConflict string) error {
+	for start := 0; start < len(itens); start += itemBatchSize {
+		batch := itens[start:min(start+itemBatchSize, len(itens))]
+
+		batchArgs := append([]interface{}{}, args...)
+		placeholder := func(value interface{}) string {
+			batchArgs = append(batchArgs, value)
+			return "$" + strconv.Itoa(len(batchArgs))
+		}
+
+		rows := make([]string, 0, len(batch))
+		for _, item := range batch {
+			nfeID := nfeIDExpr
+			if nfeID == "" {
+				nfeID = placeholder(item.NFeID)
+			}
+			rows = append(rows, "("+strings.Join([]string{
+				nfeID,
+				placeholder(item.NumeroItem),
+				placeholder(item.CodigoProduto),
+				placeholder(item.Descricao),
+				placeholder(item.NCM),
+				placeholder(item.CFOP),
+				placeholder(item.Unidade),
+				placeholder(item.Quantidade),
+				placeholder(item.ValorTotal),
+			}, ", ")+")")
+		}
+
+		query := `
+		INSERT INTO ` + itensTable + ` (
+			nfe_id, numero_item, codigo_produto, descricao, ncm, cfop,
+			unidade, quantidade, valor_total
+		) VALUES ` + strings.Join(rows, ", ") + ` ` + onConflict
+
+		if _, err := exec.Exec(query, batchArgs...); err != nil {
+			return fmt.Errorf("failed to insert nfe itens %d-%d: %w", batch[0].NumeroItem, batch[len(batch)-1].NumeroItem, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// nsuCursorRepository implementa domain.NSUCursorRepository usando PostgreSQL
+type nsuCursorRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewNSUCursorRepository cria o repositório do cursor de NSU da distribuição DFe
+func NewNSUCursorRepository(db *sqlx.DB, schema string) domain.NSUCursorRepository {
+	return &nsuCursorRepository{
+		db:    db,
+		table: qualifiedTable(schema, "dfe_nsu_cursors"),
+	}
+}
+
+// GetUltNSU retorna o último NSU processado para o CNPJ ("0" quando ainda não há cursor)
+func (r *nsuCursorRepository) GetUltNSU(cnpj string) (string, error) {
+	query := `SELECT ult_nsu FROM ` + r.table + ` WHERE cnpj = $1`
+
+	var ultNSU string
+	if err := r.db.Get(&ultNSU, query, cnpj); err != nil {
+		if err == sql.ErrNoRows {
+			return "0", nil
+		}
+		return "", fmt.Errorf("failed to get nsu cursor: %w", err)
+	}
+
+	return ultNSU, nil
+}
+
+// SaveUltNSU grava o último NSU processado para o CNPJ
+func (r *nsuCursorRepository) SaveUltNSU(cnpj, ultNSU string) error {
+	query := `
+		INSERT INTO ` + r.table + ` (cnpj, ult_nsu, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (cnpj) DO UPDATE SET ult_nsu = EXCLUDED.ult_nsu, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.Exec(query, cnpj, ultNSU, time.Now()); err != nil {
+		return fmt.Errorf("failed to save nsu cursor: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// quarantineRepository implementa domain.QuarantineRepository usando PostgreSQL
+type quarantineRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewQuarantineRepository cria o repositório das NFes em quarentena por
+// divergência de ambiente
+func NewQuarantineRepository(db *sqlx.DB, schema string) domain.QuarantineRepository {
+	return &quarantineRepository{
+		db:    db,
+		table: qualifiedTable(schema, "nfe_quarantine"),
+	}
+}
+
+// Save grava a NFe em quarentena. Uma NFe recebida novamente (ex: o cursor foi
+// mantido por outros erros) tem o registro atualizado.
+func (r *quarantineRepository) Save(nfe *domain.QuarantinedNFe) error {
+	query := `
+		INSERT INTO ` + r.table + ` (id, job_id, cnpj, chave_acesso, cnpj_emitente, tp_amb, expected_tp_amb, content, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (chave_acesso) DO UPDATE SET
+			job_id = EXCLUDED.job_id,
+			cnpj = EXCLUDED.cnpj,
+			tp_amb = EXCLUDED.tp_amb,
+			expected_tp_amb = EXCLUDED.expected_tp_amb,
+			content = EXCLUDED.content,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.db.Exec(query,
+		nfe.ID,
+		nfe.JobID,
+		nfe.CNPJ,
+		nfe.ChaveAcesso,
+		nfe.CNPJEmitente,
+		nfe.TpAmb,
+		nfe.ExpectedTpAmb,
+		nfe.Content,
+		nfe.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save quarantined nfe: %w", err)
+	}
+
+	return nil
+}
+
+// FindAll lista as NFes em quarentena, das mais recentes para as mais antigas
+func (r *quarantineRepository) FindAll(filter domain.QuarantineFilter) ([]domain.QuarantinedNFe, int64, error) {
+	var total int64
+	if err := r.db.Get(&total, `SELECT COUNT(*) FROM `+r.table); err != nil {
+		return nil, 0, fmt.Errorf("failed to count quarantined nfes: %w", err)
+	}
+
+	query := `
+		SELECT id, job_id, cnpj, chave_acesso, cnpj_emitente, tp_amb, expected_tp_amb, created_at
+		FROM ` + r.table + `
+		ORDER BY created_at DESC, chave_acesso
+		LIMIT $1 OFFSET $2`
+
+	nfes := []domain.QuarantinedNFe{}
+	if err := r.db.Select(&nfes, query, filter.Limit, filter.GetOffset()); err != nil {
+		return nil, 0, fmt.Errorf("failed to find quarantined nfes: %w", err)
+	}
+
+	return nfes, total, nil
+}
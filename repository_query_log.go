@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/pkg/logger"
+)
+
+// maxLoggedQueryLength limita o tamanho do comando SQL registrado no log
+const maxLoggedQueryLength = 2000
+
+// SlowQueryLogger registra as consultas que demoram mais que o limite
+// configurado. Um SlowQueryLogger nil não registra nada.
+type SlowQueryLogger struct {
+	logger    *logger.Logger
+	threshold time.Duration
+}
+
+// NewSlowQueryLogger cria o registro de consultas lentas. Retorna nil quando
+// threshold não é positivo, desativando o registro.
+func NewSlowQueryLogger(log *logger.Logger, threshold time.Duration) *SlowQueryLogger {
+	if threshold <= 0 {
+		return nil
+	}
+	return &SlowQueryLogger{logger: log, threshold: threshold}
+}
+
+// observe registra a consulta iniciada em start se ela excedeu o limite. Os
+// argumentos não são registrados, pois podem conter dados das NFes.
+func (l *SlowQueryLogger) observe(query string, start time.Time) {
+	if l == nil {
+		return
+	}
+	if elapsed := time.Since(start); elapsed >= l.threshold {
+		l.logger.Warn("Consulta SQL lenta",
+			"duration_ms", elapsed.Milliseconds(),
+			"threshold_ms", l.threshold.Milliseconds(),
+			"query", sanitizeQuery(query),
+		)
+	}
+}
+
+// execer retorna exec medindo o tempo de cada comando
+func (l *SlowQueryLogger) execer(exec sqlx.Execer) sqlx.Execer {
+	if l == nil {
+		return exec
+	}
+	return &timedExecer{Execer: exec, slow: l}
+}
+
+// queryer retorna q medindo o tempo de cada consulta
+func (l *SlowQueryLogger) queryer(q sqlx.Queryer) sqlx.Queryer {
+	if l == nil {
+		return q
+	}
+	return &timedQueryer{Queryer: q, slow: l}
+}
+
+// sanitizeQuery compacta os espaços do comando SQL em uma única linha e o trunca
+func sanitizeQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxLoggedQueryLength {
+		query = query[:maxLoggedQueryLength] + "..."
+	}
+	return query
+}
+
+// timedDB mede o tempo das consultas executadas diretamente no pool de conexões
+type timedDB struct {
+	*sqlx.DB
+	slow *SlowQueryLogger
+}
+
+// Get executa a consulta medindo o tempo
+func (db *timedDB) Get(dest interface{}, query string, args ...interface{}) error {
+	defer db.slow.observe(query, time.Now())
+	return db.DB.Get(dest, query, args...)
+}
+
+// Select executa a consulta medindo o tempo
+func (db *timedDB) Select(dest interface{}, query string, args ...interface{}) error {
+	defer db.slow.observe(query, time.Now())
+	return db.DB.Select(dest, query, args...)
+}
+
+// Exec executa o comando medindo o tempo
+func (db *timedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer db.slow.observe(query, time.Now())
+	return db.DB.Exec(query, args...)
+}
+
+// QueryRow executa a consulta medindo o tempo até a primeira linha
+func (db *timedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	defer db.slow.observe(query, time.Now())
+	return db.DB.QueryRow(query, args...)
+}
+
+// QueryRowx executa a consulta medindo o tempo até a primeira linha
+func (db *timedDB) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	defer db.slow.observe(query, time.Now())
+	return db.DB.QueryRowx(query, args...)
+}
+
+// timedExecer mede o tempo dos comandos executados dentro de uma transação
+type timedExecer struct {
+	sqlx.Execer
+	slow *SlowQueryLogger
+}
+
+// timedQueryer mede o tempo das consultas executadas dentro de uma transação
+type timedQueryer struct {
+	sqlx.Queryer
+	slow *SlowQueryLogger
+}
+
+// Query executa a consulta medindo o tempo até as primeiras linhas
+func (q *timedQueryer) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	defer q.slow.observe(query, time.Now())
+	return q.Queryer.Query(query, args...)
+}
+
+// Queryx executa a consulta medindo o tempo até as primeiras linhas
+func (q *timedQueryer) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	defer q.slow.observe(query, time.Now())
+	return q.Queryer.Queryx(query, args...)
+}
+
+// QueryRowx executa a consulta medindo o tempo até a primeira linha
+func (q *timedQueryer) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	defer q.slow.observe(query, time.Now())
+	return q.Queryer.QueryRowx(query, args...)
+}
+
+// Exec executa o comando medindo o tempo
+func (e *timedExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer e.slow.observe(query, time.Now())
+	return e.Execer.Exec(query, args...)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// FindReferencias busca as chaves referenciadas pela NFe e as chaves das NFes
+// que a referenciam, ordenadas pela data de emissão
+func (r *nfeRepository) FindReferencias(chaveAcesso string) (*domain.NFeReferencias, error) {
+	refs := &domain.NFeReferencias{
+		ChaveAcesso:     chaveAcesso,
+		Referenciadas:   []string{},
+		ReferenciadaPor: []string{},
+	}
+
+	referenciadasQuery := `
+		SELECT ref.chave_referenciada FROM ` + r.referenciasTable + ` ref
+		JOIN ` + r.table + ` n ON n.id = ref.nfe_id
+		WHERE n.chave_acesso = $1 ORDER BY ref.chave_referenciada`
+	if err := r.db.Select(&refs.Referenciadas, referenciadasQuery, chaveAcesso); err != nil {
+		return nil, fmt.Errorf("failed to find referenced nfes: %w", err)
+	}
+
+	referenciadaPorQuery := `
+		SELECT n.chave_acesso FROM ` + r.referenciasTable + ` ref
+		JOIN ` + r.table + ` n ON n.id = ref.nfe_id
+		WHERE ref.chave_referenciada = $1 ORDER BY n.data_emissao, n.chave_acesso`
+	if err := r.db.Select(&refs.ReferenciadaPor, referenciadaPorQuery, chaveAcesso); err != nil {
+		return nil, fmt.Errorf("failed to find referencing nfes: %w", err)
+	}
+
+	return refs, nil
+}
+
+// replaceReferencias substitui as chaves referenciadas pela NFe identificada
+// pela chave de acesso, como em replaceItens
+func replaceReferencias(exec sqlx.Execer, nfeTable, referenciasTable, chaveAcesso string, chaves []string) error {
+	deleteQuery := `DELETE FROM ` + referenciasTable + ` WHERE nfe_id = (SELECT id FROM ` + nfeTable + ` WHERE chave_acesso = $1)`
+	if _, err := exec.Exec(deleteQuery, chaveAcesso); err != nil {
+		return fmt.Errorf("failed to delete nfe referencias: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO ` + referenciasTable + ` (nfe_id, chave_referenciada)
+		SELECT id, $2 FROM ` + nfeTable + ` WHERE chave_acesso = $1
+		ON CONFLICT DO NOTHING`
+
+	for _, chave := range chaves {
+		if _, err := exec.Exec(insertQuery, chaveAcesso, chave); err != nil {
+			return fmt.Errorf("failed to insert nfe referencia %s: %w", chave, err)
+		}
+	}
+
+	return nil
+}
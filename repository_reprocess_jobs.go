@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// reprocessJobRepository implementa domain.ReprocessJobRepository usando PostgreSQL
+type reprocessJobRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// reprocessJobRow é a linha de reprocess_jobs; fields é gravado como TEXT[]
+type reprocessJobRow struct {
+	ID        uuid.UUID            `db:"id"`
+	Status    domain.SyncJobStatus `db:"status"`
+	Fields    pq.StringArray       `db:"fields"`
+	StartedAt time.Time            `db:"started_at"`
+	EndedAt   *time.Time           `db:"ended_at"`
+	Total     int                  `db:"total"`
+	Processed int                  `db:"processed"`
+	Updated   int                  `db:"updated"`
+	Failed    int                  `db:"failed"`
+	Error     string               `db:"error"`
+}
+
+// NewReprocessJobRepository cria o repositório dos jobs de reprocessamento
+func NewReprocessJobRepository(db *sqlx.DB, schema string) domain.ReprocessJobRepository {
+	return &reprocessJobRepository{
+		db:    db,
+		table: qualifiedTable(schema, "reprocess_jobs"),
+	}
+}
+
+// Save grava o job, atualizando o progresso do registro existente com o mesmo id
+func (r *reprocessJobRepository) Save(job *domain.ReprocessJob) error {
+	fields := make([]string, 0, len(job.Fields))
+	for _, field := range job.Fields {
+		fields = append(fields, string(field))
+	}
+
+	query := `
+		INSERT INTO ` + r.table + ` (id, status, fields, started_at, ended_at, total, processed, updated, failed, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			ended_at = EXCLUDED.ended_at,
+			processed = EXCLUDED.processed,
+			updated = EXCLUDED.updated,
+			failed = EXCLUDED.failed,
+			error = EXCLUDED.error
+	`
+
+	_, err := r.db.Exec(query,
+		job.ID,
+		job.Status,
+		pq.StringArray(fields),
+		job.StartedAt,
+		job.EndedAt,
+		job.Total,
+		job.Processed,
+		job.Updated,
+		job.Failed,
+		job.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save reprocess job: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID busca um job de reprocessamento pelo id
+func (r *reprocessJobRepository) FindByID(id uuid.UUID) (*domain.ReprocessJob, error) {
+	query := `SELECT id, status, fields, started_at, ended_at, total, processed, updated, failed,
+		COALESCE(error, '') AS error
+		FROM ` + r.table + ` WHERE id = $1`
+
+	var row reprocessJobRow
+	if err := r.db.Get(&row, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrReprocessJobNotFound
+		}
+		return nil, fmt.Errorf("failed to find reprocess job: %w", err)
+	}
+
+	job := &domain.ReprocessJob{
+		ID:        row.ID,
+		Status:    row.Status,
+		Fields:    make([]domain.ReprocessField, 0, len(row.Fields)),
+		StartedAt: row.StartedAt,
+		EndedAt:   row.EndedAt,
+		Total:     row.Total,
+		Processed: row.Processed,
+		Updated:   row.Updated,
+		Failed:    row.Failed,
+		Error:     row.Error,
+	}
+	for _, field := range row.Fields {
+		job.Fields = append(job.Fields, domain.ReprocessField(field))
+	}
+	return job, nil
+}
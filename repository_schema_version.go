@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// undefinedTable é o código do PostgreSQL para tabela inexistente
+const undefinedTable = "42P01"
+
+// schemaVersionRepository implementa domain.SchemaVersionRepository lendo a
+// tabela schema_migrations mantida pelo golang-migrate
+type schemaVersionRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewSchemaVersionRepository cria o repositório da versão do schema
+func NewSchemaVersionRepository(db *sqlx.DB, schema string) domain.SchemaVersionRepository {
+	return &schemaVersionRepository{
+		db:    db,
+		table: qualifiedTable(schema, "schema_migrations"),
+	}
+}
+
+// Current retorna a última migration aplicada, ou nil quando a tabela de
+// migrations ainda não existe ou está vazia
+func (r *schemaVersionRepository) Current() (*domain.SchemaVersion, error) {
+	query := `SELECT version, dirty FROM ` + r.table + ` LIMIT 1`
+
+	var version domain.SchemaVersion
+	if err := r.db.Get(&version, query); err != nil {
+		var pqErr *pq.Error
+		if err == sql.ErrNoRows || (errors.As(err, &pqErr) && pqErr.Code == undefinedTable) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return &version, nil
+}
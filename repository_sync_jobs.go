@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// syncJobColumns lista as colunas retornadas nas consultas de jobs
+const syncJobColumns = `id, status, started_at, ended_at, nfes_found, nfes_error,
+	nfes_quarantined, COALESCE(error, '') AS error`
+
+// syncJobRepository implementa domain.SyncJobRepository usando PostgreSQL
+type syncJobRepository struct {
+	db    *sqlx.DB
+	table string
+	// errorsTable guarda as NFes que falharam em cada job
+	errorsTable string
+}
+
+// NewSyncJobRepository cria o repositório do histórico de sincronizações
+func NewSyncJobRepository(db *sqlx.DB, schema string) domain.SyncJobRepository {
+	return &syncJobRepository{
+		db:          db,
+		table:       qualifiedTable(schema, "sync_jobs"),
+		errorsTable: qualifiedTable(schema, "sync_job_errors"),
+	}
+}
+
+// Save grava o job, atualizando o registro existente com o mesmo id
+func (r *syncJobRepository) Save(job *domain.SyncJob) error {
+	query := `
+		INSERT INTO ` + r.table + ` (id, status, started_at, ended_at, nfes_found, nfes_error, nfes_quarantined, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			ended_at = EXCLUDED.ended_at,
+			nfes_found = EXCLUDED.nfes_found,
+			nfes_error = EXCLUDED.nfes_error,
+			nfes_quarantined = EXCLUDED.nfes_quarantined,
+			error = EXCLUDED.error
+	`
+
+	_, err := r.db.Exec(query,
+		job.ID,
+		job.Status,
+		job.StartedAt,
+		job.EndedAt,
+		job.NFesFound,
+		job.NFesError,
+		job.NFesQuarantined,
+		job.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sync job: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID busca um job pelo id
+func (r *syncJobRepository) FindByID(id uuid.UUID) (*domain.SyncJob, error) {
+	query := `SELECT ` + syncJobColumns + ` FROM ` + r.table + ` WHERE id = $1`
+	return r.get(query, id)
+}
+
+// FindLast retorna o job iniciado mais recentemente
+func (r *syncJobRepository) FindLast() (*domain.SyncJob, error) {
+	query := `SELECT ` + syncJobColumns + ` FROM ` + r.table + ` ORDER BY started_at DESC LIMIT 1`
+	return r.get(query)
+}
+
+// FindLastSuccess retorna o último job concluído com sucesso
+func (r *syncJobRepository) FindLastSuccess() (*domain.SyncJob, error) {
+	query := `SELECT ` + syncJobColumns + ` FROM ` + r.table + `
+		WHERE status = $1 ORDER BY ended_at DESC LIMIT 1`
+	return r.get(query, domain.SyncJobStatusCompleted)
+}
+
+// SaveError registra a falha de uma NFe no job. O resumo, quando informado, é
+// gravado em JSON para o reprocessamento na próxima sincronização.
+func (r *syncJobRepository) SaveError(jobErr *domain.SyncJobError) error {
+	resumo := ""
+	if jobErr.Resumo != nil {
+		data, err := json.Marshal(jobErr.Resumo)
+		if err != nil {
+			return fmt.Errorf("failed to encode sync job error resumo: %w", err)
+		}
+		resumo = string(data)
+	}
+
+	query := `
+		INSERT INTO ` + r.errorsTable + ` (job_id, cnpj, chave_acesso, error, resumo, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::jsonb, $6)
+	`
+
+	_, err := r.db.Exec(query, jobErr.JobID, jobErr.CNPJ, jobErr.ChaveAcesso, jobErr.Error, resumo, jobErr.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save sync job error: %w", err)
+	}
+
+	return nil
+}
+
+// FindPendingResumos lista os resumos das NFes do CNPJ com falha de gravação
+// ainda pendente, um por chave de acesso, do registro mais recente
+func (r *syncJobRepository) FindPendingResumos(cnpj string) ([]domain.NFeResumo, error) {
+	query := `SELECT DISTINCT ON (chave_acesso) resumo FROM ` + r.errorsTable + `
+		WHERE cnpj = $1 AND resumo IS NOT NULL AND resolved_at IS NULL
+		ORDER BY chave_acesso, id DESC`
+
+	var raw [][]byte
+	if err := r.db.Select(&raw, query, cnpj); err != nil {
+		return nil, fmt.Errorf("failed to find pending sync job errors: %w", err)
+	}
+
+	resumos := make([]domain.NFeResumo, 0, len(raw))
+	for _, data := range raw {
+		var resumo domain.NFeResumo
+		if err := json.Unmarshal(data, &resumo); err != nil {
+			return nil, fmt.Errorf("failed to decode sync job error resumo: %w", err)
+		}
+		resumos = append(resumos, resumo)
+	}
+	return resumos, nil
+}
+
+// ResolveErrors marca como resolvidas as pendências da NFe gravadas por jobs
+// diferentes de jobID
+func (r *syncJobRepository) ResolveErrors(jobID uuid.UUID, cnpj, chaveAcesso string) error {
+	query := `UPDATE ` + r.errorsTable + ` SET resolved_at = $1
+		WHERE cnpj = $2 AND chave_acesso = $3 AND job_id <> $4
+		AND resumo IS NOT NULL AND resolved_at IS NULL`
+
+	if _, err := r.db.Exec(query, time.Now(), cnpj, chaveAcesso, jobID); err != nil {
+		return fmt.Errorf("failed to resolve sync job errors: %w", err)
+	}
+	return nil
+}
+
+// DeleteStartedBefore remove os jobs finalizados iniciados antes de cutoff. Os
+// erros registrados nos jobs são removidos em cascata; jobs com NFes ainda
+// pendentes de reprocessamento são mantidos.
+func (r *syncJobRepository) DeleteStartedBefore(cutoff time.Time) (int64, error) {
+	query := `DELETE FROM ` + r.table + ` WHERE started_at < $1 AND status <> $2
+		AND NOT EXISTS (
+			SELECT 1 FROM ` + r.errorsTable + ` e
+			WHERE e.job_id = ` + r.table + `.id AND e.resumo IS NOT NULL AND e.resolved_at IS NULL
+		)`
+
+	result, err := r.db.Exec(query, cutoff, domain.SyncJobStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sync jobs: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deleted sync jobs: %w", err)
+	}
+	return deleted, nil
+}
+
+// FindErrors lista as falhas registradas no job, na ordem em que ocorreram
+func (r *syncJobRepository) FindErrors(jobID uuid.UUID) ([]domain.SyncJobError, error) {
+	query := `SELECT job_id, cnpj, chave_acesso, error, created_at FROM ` + r.errorsTable + `
+		WHERE job_id = $1 ORDER BY id`
+
+	jobErrors := []domain.SyncJobError{}
+	if err := r.db.Select(&jobErrors, query, jobID); err != nil {
+		return nil, fmt.Errorf("failed to find sync job errors: %w", err)
+	}
+	return jobErrors, nil
+}
+
+// get busca um único job, retornando domain.ErrSyncJobNotFound quando não há registro
+func (r *syncJobRepository) get(query string, args ...interface{}) (*domain.SyncJob, error) {
+	var job domain.SyncJob
+	if err := r.db.Get(&job, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrSyncJobNotFound
+		}
+		return nil, fmt.Errorf("failed to find sync job: %w", err)
+	}
+	return &job, nil
+}
@@ -1,12 +1,18 @@
 package repository
 
 import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -25,7 +31,7 @@ func TestCreate(t *testing.T) {
 	db, mock := setupMockDB(t)
 	defer db.Close()
 
-	repo := NewNFeRepository(db)
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
 
 	nfe := &domain.NFe{
 		ID:           uuid.New(),
@@ -35,14 +41,15 @@ func TestCreate(t *testing.T) {
 		CNPJEmitente: "12345678000100",
 		NomeEmitente: "Empresa Teste LTDA",
 		DataEmissao:  time.Now(),
-		ValorTotal:   1500.50,
+		ValorTotal:   domain.Money(150050),
 		XMLPath:      "/storage/xmls/2025/12/35251234567890123456789012345678901234567890.xml",
 		Status:       domain.NFeStatusAutorizada,
+		Origem:       domain.NFeOrigemEmitida,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	mock.ExpectExec("INSERT INTO nfes").
+	mock.ExpectExec(`INSERT INTO nfes (.+) ON CONFLICT \(chave_acesso\) DO NOTHING`).
 		WithArgs(
 			nfe.ID,
 			nfe.ChaveAcesso,
@@ -54,8 +61,86 @@ func TestCreate(t *testing.T) {
 			nfe.ValorTotal,
 			nfe.XMLPath,
 			nfe.Status,
+			nfe.ProtocoloAutorizacao,
+			nfe.DataAutorizacao,
+			nfe.CodigoRejeicao,
+			nfe.MotivoRejeicao,
+			nfe.ResumoOnly,
+			nfe.Origem,
 			nfe.CreatedAt,
 			nfe.UpdatedAt,
+			nfe.UFEmitente,
+			nfe.CRT,
+			nfe.DataCancelamento,
+			nfe.MotivoCancelamento,
+			nfe.FullXMLUnavailable,
+			nfe.TipoOperacao,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(nfe)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_UpdateOnConflict(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictUpdate, domain.TimestampApp, nil)
+
+	mock.ExpectExec(`INSERT INTO nfes (.+) ON CONFLICT \(chave_acesso\) DO UPDATE SET (.+) origem = EXCLUDED.origem`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(&domain.NFe{
+		ID:          uuid.New(),
+		ChaveAcesso: "35251234567890123456789012345678901234567890",
+		Status:      domain.NFeStatusCancelada,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_DatabaseTimestamps(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampDatabase, nil)
+
+	nfe := &domain.NFe{
+		ID:          uuid.New(),
+		ChaveAcesso: "35251234567890123456789012345678901234567890",
+		Status:      domain.NFeStatusAutorizada,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	mock.ExpectExec(`INSERT INTO nfes (.+) COALESCE\(\$17, LOCALTIMESTAMP\), COALESCE\(\$18, LOCALTIMESTAMP\)`).
+		WithArgs(
+			nfe.ID,
+			nfe.ChaveAcesso,
+			nfe.Numero,
+			nfe.Serie,
+			nfe.CNPJEmitente,
+			nfe.NomeEmitente,
+			nfe.DataEmissao,
+			nfe.ValorTotal,
+			nfe.XMLPath,
+			nfe.Status,
+			nfe.ProtocoloAutorizacao,
+			nfe.DataAutorizacao,
+			nfe.CodigoRejeicao,
+			nfe.MotivoRejeicao,
+			nfe.ResumoOnly,
+			nfe.Origem,
+			nil,
+			nil,
+			nfe.UFEmitente,
+			nfe.CRT,
+			nfe.DataCancelamento,
+			nfe.MotivoCancelamento,
+			nfe.FullXMLUnavailable,
+			nfe.TipoOperacao,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -68,7 +153,7 @@ func TestFindByChaveAcesso_Success(t *testing.T) {
 	db, mock := setupMockDB(t)
 	defer db.Close()
 
-	repo := NewNFeRepository(db)
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
 
 	chaveAcesso := "35251234567890123456789012345678901234567890"
 	expectedNFe := &domain.NFe{
@@ -79,7 +164,7 @@ func TestFindByChaveAcesso_Success(t *testing.T) {
 		CNPJEmitente: "12345678000100",
 		NomeEmitente: "Empresa Teste LTDA",
 		DataEmissao:  time.Now(),
-		ValorTotal:   1500.50,
+		ValorTotal:   domain.Money(150050),
 		XMLPath:      "/storage/xmls/2025/12/35251234567890123456789012345678901234567890.xml",
 		Status:       domain.NFeStatusAutorizada,
 		CreatedAt:    time.Now(),
@@ -89,8 +174,10 @@ func TestFindByChaveAcesso_Success(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"id", "chave_acesso", "numero", "serie", "cnpj_emitente",
 		"nome_emitente", "data_emissao", "valor_total", "xml_path",
-		"status", "data_cancelamento", "motivo_cancelamento",
-		"created_at", "updated_at",
+		"status", "protocolo_autorizacao", "data_autorizacao",
+		"data_cancelamento", "motivo_cancelamento",
+		"codigo_rejeicao", "motivo_rejeicao",
+		"resumo_only", "origem", "created_at", "updated_at",
 	}).AddRow(
 		expectedNFe.ID,
 		expectedNFe.ChaveAcesso,
@@ -99,11 +186,17 @@ func TestFindByChaveAcesso_Success(t *testing.T) {
 		expectedNFe.CNPJEmitente,
 		expectedNFe.NomeEmitente,
 		expectedNFe.DataEmissao,
-		expectedNFe.ValorTotal,
+		expectedNFe.ValorTotal.String(),
 		expectedNFe.XMLPath,
 		expectedNFe.Status,
+		"135250000000001",
+		time.Now(),
 		nil,
 		"",
+		"",
+		"",
+		false,
+		domain.NFeOrigemRecebida,
 		expectedNFe.CreatedAt,
 		expectedNFe.UpdatedAt,
 	)
@@ -123,7 +216,7 @@ func TestFindByChaveAcesso_NotFound(t *testing.T) {
 	db, mock := setupMockDB(t)
 	defer db.Close()
 
-	repo := NewNFeRepository(db)
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
 
 	chaveAcesso := "35251234567890123456789012345678901234567890"
 
@@ -142,7 +235,7 @@ func TestExistsByChaveAcesso(t *testing.T) {
 	db, mock := setupMockDB(t)
 	defer db.Close()
 
-	repo := NewNFeRepository(db)
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
 
 	chaveAcesso := "35251234567890123456789012345678901234567890"
 
@@ -162,7 +255,7 @@ func TestFindByFilter(t *testing.T) {
 	db, mock := setupMockDB(t)
 	defer db.Close()
 
-	repo := NewNFeRepository(db)
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
 
 	filter := domain.NFeFilter{
 		Page:  1,
@@ -177,8 +270,10 @@ func TestFindByFilter(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"id", "chave_acesso", "numero", "serie", "cnpj_emitente",
 		"nome_emitente", "data_emissao", "valor_total", "xml_path",
-		"status", "data_cancelamento", "motivo_cancelamento",
-		"created_at", "updated_at",
+		"status", "protocolo_autorizacao", "data_autorizacao",
+		"data_cancelamento", "motivo_cancelamento",
+		"codigo_rejeicao", "motivo_rejeicao",
+		"resumo_only", "origem", "created_at", "updated_at",
 	}).AddRow(
 		uuid.New(),
 		"35251234567890123456789012345678901234567890",
@@ -190,8 +285,14 @@ func TestFindByFilter(t *testing.T) {
 		1500.50,
 		"/storage/xmls/2025/12/35251234567890123456789012345678901234567890.xml",
 		domain.NFeStatusAutorizada,
+		"135250000000001",
+		time.Now(),
 		nil,
 		"",
+		"",
+		"",
+		false,
+		domain.NFeOrigemEmitida,
 		time.Now(),
 		time.Now(),
 	)
@@ -204,4 +305,1189 @@ func TestFindByFilter(t *testing.T) {
 	assert.Equal(t, int64(1), total)
 	assert.Len(t, nfes, 1)
 	assert.NoError(t, mock.ExpectationsWereMet())
-}
\ No newline at end of file
+}
+
+func TestFindByFilter_ResumoOnly(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	resumoOnly := true
+	filter := domain.NFeFilter{
+		Status:     domain.NFeStatusProcessando,
+		ResumoOnly: &resumoOnly,
+		Page:       1,
+		Limit:      20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND status = \$1 AND resumo_only = \$2`).
+		WithArgs(domain.NFeStatusProcessando, true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	nfes, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Len(t, nfes, 0)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountByFilter_XMLMissing(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	xmlMissing := true
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total, (.+) FROM nfes WHERE 1=1 AND \(\(COALESCE\(xml_path, ''\) = '' OR xml_file_missing\) AND xml_removed_at IS NULL AND status <> 'rejeitada'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "valor_total"}).AddRow(2, "300.00"))
+
+	count, err := repo.CountByFilter(domain.NFeFilter{XMLMissing: &xmlMissing})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountByFilter_Cancelled(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	cancelled := true
+	emissionAfter := false
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total, (.+) FROM nfes WHERE 1=1 AND \(data_cancelamento IS NOT NULL OR status = 'cancelada'\) AND NOT \(data_cancelamento IS NOT NULL AND data_emissao > data_cancelamento\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "valor_total"}).AddRow(1, "150.00"))
+
+	count, err := repo.CountByFilter(domain.NFeFilter{Cancelled: &cancelled, EmissionAfterCancellation: &emissionAfter})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTopEmitentes(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT cnpj_emitente, (.+) FROM nfes WHERE data_emissao >= \$1 AND data_emissao < \$2 GROUP BY cnpj_emitente ORDER BY valor_total DESC, cnpj_emitente LIMIT \$3`).
+		WithArgs(start, end.AddDate(0, 0, 1), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"cnpj_emitente", "nome_emitente", "total_nfes", "valor_total"}).
+			AddRow("12345678000100", "Fornecedor A", 3, "1500.00").
+			AddRow("98765432000199", "Fornecedor B", 1, "200.00"))
+
+	emitentes, err := repo.TopEmitentes(start, end, 10)
+	require.NoError(t, err)
+	require.Len(t, emitentes, 2)
+	assert.Equal(t, "Fornecedor A", emitentes[0].NomeEmitente)
+	assert.Equal(t, int64(3), emitentes[0].TotalNFes)
+	assert.Equal(t, domain.Money(150000), emitentes[0].ValorTotal)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindEmitentes_Search(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.EmitenteFilter{Search: "100%", Page: 2, Limit: 10}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT cnpj_emitente FROM nfes GROUP BY cnpj_emitente HAVING BOOL_OR\(nome_emitente ILIKE \$1\) OR cnpj_emitente LIKE \$2\) emitentes`).
+		WithArgs(`%100\%%`, `100\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+	mock.ExpectQuery(`SELECT cnpj_emitente AS cnpj, (.+) FROM nfes GROUP BY cnpj_emitente HAVING (.+) ORDER BY nome, cnpj LIMIT \$3 OFFSET \$4`).
+		WithArgs(`%100\%%`, `100\%%`, 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"cnpj", "nome", "nfe_count", "total_valor"}).
+			AddRow("12345678000100", "Fornecedor 100% Ltda", 4, "800.00"))
+
+	emitentes, total, err := repo.FindEmitentes(filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), total)
+	require.Len(t, emitentes, 1)
+	assert.Equal(t, "Fornecedor 100% Ltda", emitentes[0].Nome)
+	assert.Equal(t, int64(4), emitentes[0].NFeCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindNumeroGaps(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	mock.ExpectQuery(`SELECT anterior \+ 1 AS numero_inicial, (.+) LAG\(numero\) OVER \(ORDER BY numero\) (.+) WHERE cnpj_emitente = \$1 AND serie = \$2 (.+) WHERE numero - anterior > 1`).
+		WithArgs("12345678000100", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"numero_inicial", "numero_final", "quantidade"}).
+			AddRow(11, 12, 2).
+			AddRow(20, 20, 1))
+
+	gaps, err := repo.FindNumeroGaps(domain.NumeroGapFilter{CNPJ: "12345678000100", Serie: "1"})
+	require.NoError(t, err)
+	require.Len(t, gaps, 2)
+	assert.Equal(t, int64(11), gaps[0].NumeroInicial)
+	assert.Equal(t, int64(12), gaps[0].NumeroFinal)
+	assert.Equal(t, int64(1), gaps[1].Quantidade)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_Statuses(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		Statuses: []domain.NFeStatus{domain.NFeStatusAutorizada, domain.NFeStatusCancelada},
+		Page:     1,
+		Limit:    20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND status = ANY\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	nfes, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Len(t, nfes, 0)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_SummaryProjection(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		Projection: domain.NFeProjectionSummary,
+		Page:       1,
+		Limit:      20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, chave_acesso, numero, serie, cnpj_emitente, nome_emitente,\s+data_emissao, valor_total, status FROM nfes (.+) ORDER BY data_emissao DESC`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "chave_acesso", "numero", "serie", "cnpj_emitente",
+			"nome_emitente", "data_emissao", "valor_total", "status",
+		}).AddRow(
+			uuid.New(),
+			"35251234567890123456789012345678901234567890",
+			"123",
+			"1",
+			"12345678000100",
+			"Empresa Teste LTDA",
+			time.Now(),
+			"1500.50",
+			domain.NFeStatusAutorizada,
+		))
+
+	nfes, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, nfes, 1)
+	assert.Empty(t, nfes[0].XMLPath)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_ExcludeStatuses(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		ExcludeStatuses: []domain.NFeStatus{domain.NFeStatusProcessando, domain.NFeStatusRejeitada},
+		Page:            1,
+		Limit:           20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND status <> ALL\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	nfes, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Len(t, nfes, 0)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountByFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{Status: domain.NFeStatusAutorizada}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total, COALESCE\(SUM\(valor_total\), 0\) AS valor_total FROM nfes WHERE 1=1 AND status = \$1`).
+		WithArgs(domain.NFeStatusAutorizada).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "valor_total"}).AddRow(42, 15230.75))
+
+	count, err := repo.CountByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), count.Total)
+	assert.Equal(t, domain.Money(1523075), count.ValorTotal)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindItensByNFeIDs(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	first, second := uuid.New(), uuid.New()
+
+	rows := sqlmock.NewRows([]string{
+		"nfe_id", "numero_item", "codigo_produto", "descricao", "ncm", "cfop",
+		"unidade", "quantidade", "valor_total",
+	}).
+		AddRow(first, 1, "P001", "Produto A", "84713012", "5102", "UN", 2.0, 100.0).
+		AddRow(first, 2, "P002", "Produto B", "84713012", "5102", "UN", 1.0, 50.0).
+		AddRow(second, 1, "P003", "Produto C", "84713012", "6102", "CX", 3.0, 30.0)
+
+	mock.ExpectQuery(`SELECT (.+) FROM nfe_itens WHERE nfe_id = ANY\(\$1::uuid\[\]\) ORDER BY nfe_id, numero_item`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(rows)
+
+	itens, err := repo.FindItensByNFeIDs([]uuid.UUID{first, second})
+	assert.NoError(t, err)
+	assert.Len(t, itens[first], 2)
+	assert.Len(t, itens[second], 1)
+	assert.Equal(t, "P003", itens[second][0].CodigoProduto)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateItemsBatch(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	nfeID := uuid.New()
+	itens := []domain.NFeItem{
+		{NFeID: nfeID, NumeroItem: 1, CodigoProduto: "P001", Descricao: "Produto A", CFOP: "5102", Quantidade: 2, ValorTotal: domain.Money(10000)},
+		{NFeID: nfeID, NumeroItem: 2, CodigoProduto: "P002", Descricao: "Produto B", CFOP: "5102", Quantidade: 1, ValorTotal: domain.Money(5000)},
+	}
+
+	// Um único INSERT com uma linha por item
+	mock.ExpectExec(`INSERT INTO nfe_itens (.+) VALUES \(\$1, (.+), \$9\), \(\$10, (.+), \$18\)$`).
+		WithArgs(
+			nfeID, 1, "P001", "Produto A", "", "5102", "", 2.0, domain.Money(10000),
+			nfeID, 2, "P002", "Produto B", "", "5102", "", 1.0, domain.Money(5000),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := repo.CreateItemsBatch(itens)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_UpsertItens(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	chaveAcesso := "35251234567890123456789012345678901234567890"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO nfe_itens (.+) ON CONFLICT \(nfe_id, numero_item\) DO UPDATE SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM nfe_itens WHERE nfe_id = (.+) AND numero_item <> ALL\(\$2\)`).
+		WithArgs(chaveAcesso, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.WithTx(func(tx domain.RepoTx) error {
+		return tx.UpsertItens(chaveAcesso, []domain.NFeItem{
+			{NumeroItem: 1, CodigoProduto: "P001", Descricao: "Produto A", CFOP: "5102", Quantidade: 1, ValorTotal: domain.Money(1000)},
+		})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_CreateAuditEntries(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	entry := domain.NFeAuditEntry{
+		ID:          uuid.New(),
+		NFeID:       uuid.New(),
+		ChaveAcesso: "35251234567890123456789012345678901234567890",
+		Field:       "nome_emitente",
+		OldValue:    "EMPRESA S?O",
+		NewValue:    "EMPRESA SÃO",
+		RequestID:   "host/abc-000001",
+		ChangedAt:   time.Now(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO nfe_audit_log (.+) VALUES`).
+		WithArgs(entry.ID, entry.NFeID, entry.ChaveAcesso, "nome_emitente", entry.OldValue, "EMPRESA SÃO",
+			"host/abc-000001", entry.ChangedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.WithTx(func(tx domain.RepoTx) error {
+		return tx.CreateAuditEntries([]domain.NFeAuditEntry{entry})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindReferencias(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	chaveAcesso := "35251234567890123456789012345678901234567890"
+	devolucao := "35251234567890123456789012345678901234567891"
+
+	mock.ExpectQuery(`SELECT ref.chave_referenciada FROM nfe_referencias ref JOIN nfes n (.+) WHERE n.chave_acesso = \$1`).
+		WithArgs(chaveAcesso).
+		WillReturnRows(sqlmock.NewRows([]string{"chave_referenciada"}))
+	mock.ExpectQuery(`SELECT n.chave_acesso FROM nfe_referencias ref JOIN nfes n (.+) WHERE ref.chave_referenciada = \$1`).
+		WithArgs(chaveAcesso).
+		WillReturnRows(sqlmock.NewRows([]string{"chave_acesso"}).AddRow(devolucao))
+
+	refs, err := repo.FindReferencias(chaveAcesso)
+	assert.NoError(t, err)
+	assert.Empty(t, refs.Referenciadas)
+	assert.Equal(t, []string{devolucao}, refs.ReferenciadaPor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveSyncJob(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db, "")
+
+	endedAt := time.Now()
+	job := &domain.SyncJob{
+		ID:        uuid.New(),
+		Status:    domain.SyncJobStatusCompleted,
+		StartedAt: endedAt.Add(-time.Minute),
+		EndedAt:   &endedAt,
+		NFesFound: 3,
+	}
+
+	mock.ExpectExec(`INSERT INTO sync_jobs (.+) ON CONFLICT \(id\) DO UPDATE SET`).
+		WithArgs(job.ID, job.Status, job.StartedAt, job.EndedAt, 3, 0, 0, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Save(job)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindSyncJobErrors(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db, "")
+
+	jobID := uuid.New()
+	createdAt := time.Now()
+	rows := sqlmock.NewRows([]string{"job_id", "cnpj", "chave_acesso", "error", "created_at"}).
+		AddRow(jobID, "12345678000100", "35251234567890123456789012345678901234567890", "failed to download xml: timeout", createdAt)
+
+	mock.ExpectQuery(`SELECT (.+) FROM sync_job_errors WHERE job_id = \$1 ORDER BY id`).
+		WithArgs(jobID).
+		WillReturnRows(rows)
+
+	jobErrors, err := repo.FindErrors(jobID)
+	assert.NoError(t, err)
+	assert.Len(t, jobErrors, 1)
+	assert.Equal(t, "35251234567890123456789012345678901234567890", jobErrors[0].ChaveAcesso)
+	assert.Equal(t, "failed to download xml: timeout", jobErrors[0].Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindPendingResumos(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db, "")
+
+	rows := sqlmock.NewRows([]string{"resumo"}).
+		AddRow([]byte(`{"chave_acesso":"35251234567890123456789012345678901234567890","cnpj_emitente":"98765432000199","valor_total":"150.00"}`))
+
+	mock.ExpectQuery(`SELECT DISTINCT ON \(chave_acesso\) resumo FROM sync_job_errors WHERE cnpj = \$1 AND resumo IS NOT NULL AND resolved_at IS NULL`).
+		WithArgs("12345678000100").
+		WillReturnRows(rows)
+
+	resumos, err := repo.FindPendingResumos("12345678000100")
+	assert.NoError(t, err)
+	assert.Len(t, resumos, 1)
+	assert.Equal(t, "35251234567890123456789012345678901234567890", resumos[0].ChaveAcesso)
+	assert.Equal(t, "98765432000199", resumos[0].CNPJEmitente)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSyncJobsStartedBefore(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db, "")
+
+	cutoff := time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`DELETE FROM sync_jobs WHERE started_at < \$1 AND status <> \$2`).
+		WithArgs(cutoff, domain.SyncJobStatusRunning).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	deleted, err := repo.DeleteStartedBefore(cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewIdempotencyRepository(db, "")
+
+	now := time.Now()
+	notBefore := now.Add(-24 * time.Hour)
+	record := &domain.IdempotencyRecord{Key: "abc", Fingerprint: "f1", CreatedAt: now}
+
+	mock.ExpectQuery(`INSERT INTO idempotency_keys (.+) ON CONFLICT \(idempotency_key\) DO UPDATE SET (.+) WHERE idempotency_keys.created_at < \$4 RETURNING idempotency_key`).
+		WithArgs("abc", "f1", now, notBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"idempotency_key"}).AddRow("abc"))
+
+	existing, err := repo.Reserve(record, notBefore)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveIdempotencyKey_Existing(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewIdempotencyRepository(db, "")
+
+	now := time.Now()
+	notBefore := now.Add(-24 * time.Hour)
+	record := &domain.IdempotencyRecord{Key: "abc", Fingerprint: "f1", CreatedAt: now}
+
+	mock.ExpectQuery(`INSERT INTO idempotency_keys`).
+		WithArgs("abc", "f1", now, notBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"idempotency_key"}))
+	completedAt := now.Add(-time.Minute)
+	mock.ExpectQuery(`SELECT (.+) FROM idempotency_keys WHERE idempotency_key = \$1`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"idempotency_key", "fingerprint", "status_code", "content_type", "body", "created_at", "completed_at"}).
+			AddRow("abc", "f1", 201, "application/json", []byte(`{"status":"homologada"}`), now.Add(-2*time.Minute), completedAt))
+
+	existing, err := repo.Reserve(record, notBefore)
+	assert.NoError(t, err)
+	assert.True(t, existing.Completed())
+	assert.Equal(t, 201, existing.StatusCode)
+	assert.Equal(t, `{"status":"homologada"}`, string(existing.Body))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveDownloadFailure(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewDownloadFailureRepository(db, "")
+
+	failure := &domain.DownloadFailure{
+		ID:        uuid.New(),
+		JobID:     uuid.New(),
+		CNPJ:      "12345678000100",
+		NSU:       "000000000000123",
+		Schema:    "resNFe_v1.01.xsd",
+		Content:   "H4sIAAAA",
+		Error:     "failed to open docZip gzip: unexpected EOF",
+		CreatedAt: time.Now(),
+	}
+
+	mock.ExpectExec(`INSERT INTO download_failures (.+) ON CONFLICT \(cnpj, nsu\) DO UPDATE SET`).
+		WithArgs(failure.ID, failure.JobID, "12345678000100", "000000000000123", "resNFe_v1.01.xsd",
+			"H4sIAAAA", failure.Error, failure.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Save(failure)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveQuarantinedNFe(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewQuarantineRepository(db, "")
+
+	nfe := &domain.QuarantinedNFe{
+		ID:            uuid.New(),
+		JobID:         uuid.New(),
+		CNPJ:          "12345678000100",
+		ChaveAcesso:   "35251234567890123456789012345678901234567890",
+		CNPJEmitente:  "98765432000199",
+		TpAmb:         "2",
+		ExpectedTpAmb: "1",
+		Content:       "<nfeProc/>",
+		CreatedAt:     time.Now(),
+	}
+
+	mock.ExpectExec(`INSERT INTO nfe_quarantine (.+) ON CONFLICT \(chave_acesso\) DO UPDATE SET`).
+		WithArgs(nfe.ID, nfe.JobID, "12345678000100", nfe.ChaveAcesso, "98765432000199", "2", "1",
+			"<nfeProc/>", nfe.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Save(nfe)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindAllQuarantined(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewQuarantineRepository(db, "")
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfe_quarantine`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+	rows := sqlmock.NewRows([]string{"id", "job_id", "cnpj", "chave_acesso", "cnpj_emitente", "tp_amb", "expected_tp_amb", "created_at"}).
+		AddRow(uuid.New(), uuid.New(), "12345678000100", "35251234567890123456789012345678901234567890", "98765432000199", "2", "1", time.Now())
+	mock.ExpectQuery(`SELECT (.+) FROM nfe_quarantine ORDER BY created_at DESC, chave_acesso LIMIT \$1 OFFSET \$2`).
+		WithArgs(20, 20).
+		WillReturnRows(rows)
+
+	nfes, total, err := repo.FindAll(domain.QuarantineFilter{Page: 2, Limit: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21), total)
+	assert.Len(t, nfes, 1)
+	assert.Equal(t, "2", nfes[0].TpAmb)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveReprocessJob(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewReprocessJobRepository(db, "")
+
+	job := &domain.ReprocessJob{
+		ID:        uuid.New(),
+		Status:    domain.SyncJobStatusRunning,
+		Fields:    []domain.ReprocessField{domain.ReprocessProtocolo, domain.ReprocessUF},
+		StartedAt: time.Now(),
+		Total:     250,
+		Processed: 100,
+		Updated:   40,
+	}
+
+	mock.ExpectExec(`INSERT INTO reprocess_jobs (.+) ON CONFLICT \(id\) DO UPDATE SET`).
+		WithArgs(job.ID, domain.SyncJobStatusRunning, pq.StringArray{"protocolo", "uf"}, job.StartedAt, job.EndedAt,
+			250, 100, 40, 0, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Save(job)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindReprocessJobByID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewReprocessJobRepository(db, "")
+
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "status", "fields", "started_at", "ended_at", "total", "processed", "updated", "failed", "error"}).
+		AddRow(id, "completed", "{protocolo,itens}", time.Now(), time.Now(), 2, 2, 1, 1, "")
+	mock.ExpectQuery(`SELECT (.+) FROM reprocess_jobs WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnRows(rows)
+
+	job, err := repo.FindByID(id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SyncJobStatusCompleted, job.Status)
+	assert.Equal(t, []domain.ReprocessField{domain.ReprocessProtocolo, domain.ReprocessItens}, job.Fields)
+	assert.Equal(t, 1, job.Failed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindReprocessJobByID_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewReprocessJobRepository(db, "")
+
+	id := uuid.New()
+	mock.ExpectQuery(`SELECT (.+) FROM reprocess_jobs WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.FindByID(id)
+	assert.ErrorIs(t, err, domain.ErrReprocessJobNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveInutilizacao(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewInutilizacaoRepository(db, "")
+
+	inutilizacao := &domain.Inutilizacao{
+		ID:            uuid.New(),
+		CNPJ:          "12345678000100",
+		Ano:           "25",
+		Modelo:        "55",
+		Serie:         1,
+		NumeroInicial: 10,
+		NumeroFinal:   12,
+		Justificativa: "Falha na numeração do sistema emissor",
+		Status:        domain.InutilizacaoRejeitada,
+		CStat:         241,
+		Motivo:        "Rejeicao: Um numero da faixa ja foi utilizado",
+		CreatedAt:     time.Now(),
+	}
+
+	mock.ExpectExec(`INSERT INTO inutilizacoes (.+) VALUES (.+) NULLIF\(\$12, ''\)`).
+		WithArgs(inutilizacao.ID, "12345678000100", "25", "55", 1, 10, 12, inutilizacao.Justificativa,
+			domain.InutilizacaoRejeitada, 241, inutilizacao.Motivo, "", inutilizacao.DataRecebimento, inutilizacao.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Save(inutilizacao)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInutilizacaoFindHomologadas(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewInutilizacaoRepository(db, "")
+
+	mock.ExpectQuery(`SELECT (.+) FROM inutilizacoes WHERE cnpj = \$1 AND serie = \$2 AND status = \$3 ORDER BY numero_inicial`).
+		WithArgs("12345678000100", 1, domain.InutilizacaoHomologada).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cnpj", "serie", "numero_inicial", "numero_final", "status"}).
+			AddRow(uuid.New(), "12345678000100", 1, 11, 12, domain.InutilizacaoHomologada))
+
+	inutilizacoes, err := repo.FindHomologadas("12345678000100", 1)
+	require.NoError(t, err)
+	require.Len(t, inutilizacoes, 1)
+	assert.Equal(t, 12, inutilizacoes[0].NumeroFinal)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSchemaVersionCurrent(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSchemaVersionRepository(db, "")
+
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(22, false))
+
+	version, err := repo.Current()
+	require.NoError(t, err)
+	require.NotNil(t, version)
+	assert.Equal(t, int64(22), version.Version)
+	assert.False(t, version.Dirty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSchemaVersionCurrent_NoMigrations(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSchemaVersionRepository(db, "")
+
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnError(&pq.Error{Code: "42P01"})
+
+	version, err := repo.Current()
+	assert.NoError(t, err)
+	assert.Nil(t, version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestXMLStorageSaveAndRead(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	storage := NewXMLStorageRepository(db, "")
+	xmlData := []byte(`<nfeProc><NFe/></nfeProc>`)
+	chave := "35251234567890123456789012345678901234567890"
+
+	mock.ExpectExec(`INSERT INTO nfe_xmls (.+) ON CONFLICT \(chave_acesso\) DO UPDATE`).
+		WithArgs(chave, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ref, err := storage.Save(chave, time.Now(), "", xmlData)
+	require.NoError(t, err)
+	assert.Equal(t, "db:"+chave, ref)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(xmlData)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	mock.ExpectQuery(`SELECT xml_gzip FROM nfe_xmls WHERE chave_acesso = \$1`).
+		WithArgs(chave).
+		WillReturnRows(sqlmock.NewRows([]string{"xml_gzip"}).AddRow(buf.Bytes()))
+
+	data, err := storage.Read(ref)
+	require.NoError(t, err)
+	assert.Equal(t, xmlData, data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestXMLStorageExists_DiskReference(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	storage := NewXMLStorageRepository(db, "")
+
+	exists, err := storage.Exists("/storage/xmls/2025/12/35251234567890123456789012345678901234567890.xml")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindLastSuccess_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db, "")
+
+	mock.ExpectQuery(`SELECT (.+) FROM sync_jobs WHERE status = \$1 ORDER BY ended_at DESC LIMIT 1`).
+		WithArgs(domain.SyncJobStatusCompleted).
+		WillReturnError(sql.ErrNoRows)
+
+	job, err := repo.FindLastSuccess()
+	assert.Equal(t, domain.ErrSyncJobNotFound, err)
+	assert.Nil(t, job)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListXMLReferences(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	rows := sqlmock.NewRows([]string{"chave_acesso", "xml_path"}).
+		AddRow("35251234567890123456789012345678901234567890", "/storage/xmls/2025/12/35251234567890123456789012345678901234567890.xml").
+		AddRow("35251234567890123456789012345678901234567891", "")
+
+	mock.ExpectQuery("SELECT chave_acesso, xml_path FROM nfes WHERE status NOT IN (.+) AND xml_removed_at IS NULL").
+		WithArgs(domain.NFeStatusRejeitada, domain.NFeStatusInvalida).
+		WillReturnRows(rows)
+
+	refs, err := repo.ListXMLReferences()
+	assert.NoError(t, err)
+	assert.Len(t, refs, 2)
+	assert.Equal(t, "", refs[1].XMLPath)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByChaveAcesso_WithSchema(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "staging", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	chaveAcesso := "35251234567890123456789012345678901234567890"
+
+	mock.ExpectQuery(`SELECT (.+) FROM "staging"\.nfes WHERE chave_acesso`).
+		WithArgs(chaveAcesso).
+		WillReturnError(sql.ErrNoRows)
+
+	nfe, err := repo.FindByChaveAcesso(chaveAcesso)
+	assert.Equal(t, domain.ErrNFeNotFound, err)
+	assert.Nil(t, nfe)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatusBatch(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	chaves := []string{
+		"35251234567890123456789012345678901234567890",
+		"35251234567890123456789012345678901234567891",
+	}
+
+	mock.ExpectExec("UPDATE nfes SET (.+) WHERE chave_acesso = ANY").
+		WithArgs(domain.NFeStatusCancelada, sqlmock.AnyArg(), pq.Array(chaves)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	updated, err := repo.UpdateStatusBatch(chaves, domain.NFeStatusCancelada)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatusBatch_Empty(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	updated, err := repo.UpdateStatusBatch(nil, domain.NFeStatusCancelada)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelBatch(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	dataCancelamento := time.Date(2025, 12, 10, 14, 30, 0, 0, time.UTC)
+	cancelamentos := []domain.Cancelamento{
+		{ChaveAcesso: "35251234567890123456789012345678901234567890", Data: &dataCancelamento, Motivo: "Erro na emissao"},
+		{ChaveAcesso: "35251234567890123456789012345678901234567891"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE nfes AS n SET (.+) FROM \(VALUES \(\$3, \$4::timestamp, \$5\), \(\$6, \$7::timestamp, \$8\)\)`).
+		WithArgs(domain.NFeStatusCancelada, sqlmock.AnyArg(),
+			cancelamentos[0].ChaveAcesso, &dataCancelamento, "Erro na emissao",
+			cancelamentos[1].ChaveAcesso, nil, "").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	var updated int64
+	err := repo.WithTx(func(tx domain.RepoTx) error {
+		var err error
+		updated, err = tx.CancelBatch(cancelamentos)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByChaveAcessoForUpdate_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	chave := "35251234567890123456789012345678901234567890"
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM nfes WHERE chave_acesso = \$1 FOR UPDATE`).
+		WithArgs(chave).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.WithTx(func(tx domain.RepoTx) error {
+		_, err := tx.FindByChaveAcessoForUpdate(chave)
+		return err
+	})
+	assert.ErrorIs(t, err, domain.ErrNFeNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_Commit(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO nfes").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.WithTx(func(tx domain.RepoTx) error {
+		return tx.Create(&domain.NFe{ID: uuid.New(), Status: domain.NFeStatusAutorizada})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_Rollback(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO nfes").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	stepErr := errors.New("falha ao inserir itens")
+	err := repo.WithTx(func(tx domain.RepoTx) error {
+		if err := tx.Create(&domain.NFe{ID: uuid.New(), Status: domain.NFeStatusAutorizada}); err != nil {
+			return err
+		}
+		return stepErr
+	})
+	assert.ErrorIs(t, err, stepErr)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMissingIndexes(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"attname"}).
+		AddRow("chave_acesso").
+		AddRow("cnpj_emitente").
+		AddRow("status").
+		AddRow("numero").
+		AddRow("uf_emitente")
+
+	mock.ExpectQuery("SELECT DISTINCT a.attname FROM pg_index").
+		WithArgs("public").
+		WillReturnRows(rows)
+
+	missing, err := MissingIndexes(db, "public")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`CREATE INDEX idx_nfes_data_emissao ON "public".nfes(data_emissao DESC);`}, missing)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUltNSU_WithoutCursor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNSUCursorRepository(db, "")
+
+	mock.ExpectQuery("SELECT ult_nsu FROM dfe_nsu_cursors WHERE cnpj").
+		WithArgs("12345678000100").
+		WillReturnError(sql.ErrNoRows)
+
+	ultNSU, err := repo.GetUltNSU("12345678000100")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", ultNSU)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveUltNSU(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNSUCursorRepository(db, "")
+
+	mock.ExpectExec("INSERT INTO dfe_nsu_cursors (.+) ON CONFLICT \\(cnpj\\) DO UPDATE").
+		WithArgs("12345678000100", "000000000000150", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.SaveUltNSU("12345678000100", "000000000000150")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkXMLFilesMissing(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	mock.ExpectExec(`UPDATE nfes SET xml_file_missing = \(chave_acesso = ANY\(\$1\)\) WHERE xml_file_missing <> \(chave_acesso = ANY\(\$1\)\)`).
+		WithArgs(pq.Array([]string{})).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	updated, err := repo.MarkXMLFilesMissing(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkXMLRemoved_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	mock.ExpectExec("UPDATE nfes SET xml_path = \\$2, xml_removed_at = \\$3").
+		WithArgs("35251234567890123456789012345678901234567890", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.MarkXMLRemoved("35251234567890123456789012345678901234567890", "")
+	assert.ErrorIs(t, err, domain.ErrNFeNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_AuthorizationPeriod(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	authStart := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	authEnd := time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC)
+	filter := domain.NFeFilter{
+		AuthStartDate: &authStart,
+		AuthEndDate:   &authEnd,
+		Page:          1,
+		Limit:         20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND data_autorizacao >= \$1 AND data_autorizacao < \$2`).
+		WithArgs(authStart, authEnd.AddDate(0, 0, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_NumeroSerie(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		Numero: "123",
+		Serie:  "1",
+		Page:   1,
+		Limit:  20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND numero = \$1 AND serie = \$2`).
+		WithArgs("123", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_UFEmitente(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		UFEmitente: "SP",
+		Page:       1,
+		Limit:      20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND uf_emitente = \$1`).
+		WithArgs("SP").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_CRT(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		CRTs:  []domain.CRT{domain.CRTSimplesNacional, domain.CRTSimplesNacionalMEI},
+		Page:  1,
+		Limit: 20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND crt = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"1", "4"})).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByFilter_TipoOperacao(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	filter := domain.NFeFilter{
+		TipoOperacao: domain.TipoOperacaoEntrada,
+		Page:         1,
+		Limit:        20,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM nfes WHERE 1=1 AND tipo_operacao = \$1`).
+		WithArgs(domain.TipoOperacaoEntrada).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT (.+) FROM nfes (.+) ORDER BY data_emissao DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, total, err := repo.FindByFilter(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindByNumero(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewNFeRepository(db, "", domain.ConflictSkip, domain.TimestampApp, nil)
+
+	lookup := domain.NFeLookup{CNPJ: "12345678000100", Numero: "123", Serie: "1", Modelo: "55"}
+	rows := sqlmock.NewRows([]string{"id", "chave_acesso", "numero", "serie"}).
+		AddRow(uuid.New(), "35251212345678000100550010000001231000001234", "123", "1")
+
+	mock.ExpectQuery(`SELECT (.+) FROM nfes WHERE cnpj_emitente = \$1 AND numero = \$2 AND serie = \$3 AND SUBSTRING\(chave_acesso FROM 21 FOR 2\) = \$4 ORDER BY data_emissao DESC LIMIT \$5`).
+		WithArgs("12345678000100", "123", "1", "55", 2).
+		WillReturnRows(rows)
+
+	nfes, err := repo.FindByNumero(lookup, 2)
+	assert.NoError(t, err)
+	assert.Len(t, nfes, 1)
+	assert.Equal(t, "123", nfes[0].Numero)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	query := `SELECT id
+		FROM nfes
+		WHERE status = $1`
+
+	assert.Equal(t, "SELECT id FROM nfes WHERE status = $1", sanitizeQuery(query))
+	assert.Len(t, sanitizeQuery(strings.Repeat("x", maxLoggedQueryLength+10)), maxLoggedQueryLength+3)
+}
+
+func TestNewSlowQueryLogger_Disabled(t *testing.T) {
+	assert.Nil(t, NewSlowQueryLogger(nil, 0))
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// nfeTx implementa domain.RepoTx sobre uma transação do banco
+type nfeTx struct {
+	tx               sqlx.Execer
+	queryer          sqlx.Queryer
+	table            string
+	itensTable       string
+	referenciasTable string
+	auditTable       string
+	onConflict       domain.ConflictPolicy
+	timestamps       domain.TimestampSource
+}
+
+// FindByChaveAcessoForUpdate busca a NFe pela chave de acesso e bloqueia a
+// linha até o fim da transação (SELECT ... FOR UPDATE)
+func (t *nfeTx) FindByChaveAcessoForUpdate(chaveAcesso string) (*domain.NFe, error) {
+	query := `SELECT ` + nfeColumns + ` FROM ` + t.table + ` WHERE chave_acesso = $1 FOR UPDATE`
+	return findNFe(t.queryer, query, chaveAcesso)
+}
+
+// Create insere uma nova NFe dentro da transação
+func (t *nfeTx) Create(nfe *domain.NFe) error {
+	return createNFe(t.tx, t.table, t.onConflict, t.timestamps, nfe)
+}
+
+// Update atualiza os dados de uma NFe dentro da transação
+func (t *nfeTx) Update(nfe *domain.NFe) error {
+	return updateNFe(t.tx, t.table, t.timestamps, nfe)
+}
+
+// UpdateStatusBatch atualiza o status de várias NFes dentro da transação
+func (t *nfeTx) UpdateStatusBatch(chaves []string, status domain.NFeStatus) (int64, error) {
+	return updateStatusBatch(t.tx, t.table, t.timestamps, chaves, status)
+}
+
+// CancelBatch marca as NFes como canceladas dentro da transação, cada uma com a
+// data e a justificativa do seu evento
+func (t *nfeTx) CancelBatch(cancelamentos []domain.Cancelamento) (int64, error) {
+	return cancelBatch(t.tx, t.table, t.timestamps, cancelamentos)
+}
+
+// ReplaceItens substitui os itens da NFe dentro da transação
+func (t *nfeTx) ReplaceItens(chaveAcesso string, itens []domain.NFeItem) error {
+	return replaceItens(t.tx, t.table, t.itensTable, chaveAcesso, itens)
+}
+
+// UpsertItens atualiza os itens da NFe pelo número do item dentro da transação
+func (t *nfeTx) UpsertItens(chaveAcesso string, itens []domain.NFeItem) error {
+	return upsertItens(t.tx, t.table, t.itensTable, chaveAcesso, itens)
+}
+
+// ReplaceReferencias substitui as chaves referenciadas pela NFe dentro da transação
+func (t *nfeTx) ReplaceReferencias(chaveAcesso string, chaves []string) error {
+	return replaceReferencias(t.tx, t.table, t.referenciasTable, chaveAcesso, chaves)
+}
+
+// CreateAuditEntries grava as entradas de auditoria dentro da transação
+func (t *nfeTx) CreateAuditEntries(entries []domain.NFeAuditEntry) error {
+	return createAuditEntries(t.tx, t.auditTable, entries)
+}
+
+// WithTx executa fn dentro de uma transação. Se fn retornar erro (ou entrar em
+// pânico) todas as alterações são desfeitas; caso contrário a transação é confirmada.
+func (r *nfeRepository) WithTx(fn func(tx domain.RepoTx) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	ntx := &nfeTx{
+		tx:               r.db.slow.execer(tx),
+		queryer:          r.db.slow.queryer(tx),
+		table:            r.table,
+		itensTable:       r.itensTable,
+		referenciasTable: r.referenciasTable,
+		auditTable:       r.auditTable,
+		onConflict:       r.onConflict,
+		timestamps:       r.timestamps,
+	}
+	if err := fn(ntx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// xmlRefPrefix identifica em xml_path os XMLs guardados no banco
+const xmlRefPrefix = "db:"
+
+// xmlStorageRepository implementa domain.XMLStorage guardando os XMLs
+// compactados com gzip na tabela nfe_xmls. A referência é db:<chave>.
+type xmlStorageRepository struct {
+	db    *sqlx.DB
+	table string
+}
+
+// NewXMLStorageRepository cria o armazenamento dos XMLs no banco
+func NewXMLStorageRepository(db *sqlx.DB, schema string) domain.XMLStorage {
+	return &xmlStorageRepository{
+		db:    db,
+		table: qualifiedTable(schema, "nfe_xmls"),
+	}
+}
+
+// Backend retorna domain.XMLStorageDatabase
+func (r *xmlStorageRepository) Backend() domain.XMLStorageBackend {
+	return domain.XMLStorageDatabase
+}
+
+// Save compacta e grava o XML, substituindo o anterior da mesma chave
+func (r *xmlStorageRepository) Save(chave string, _ time.Time, _ string, data []byte) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", fmt.Errorf("failed to compress xml: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress xml: %w", err)
+	}
+
+	query := `
+		INSERT INTO ` + r.table + ` (chave_acesso, xml_gzip, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (chave_acesso) DO UPDATE SET
+			xml_gzip = EXCLUDED.xml_gzip,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := r.db.Exec(query, chave, buf.Bytes(), time.Now()); err != nil {
+		return "", fmt.Errorf("failed to save xml: %w", err)
+	}
+
+	return xmlRefPrefix + chave, nil
+}
+
+// Read lê e descompacta o XML da referência
+func (r *xmlStorageRepository) Read(ref string) ([]byte, error) {
+	chave, err := chaveFromXMLRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var compressed []byte
+	query := `SELECT xml_gzip FROM ` + r.table + ` WHERE chave_acesso = $1`
+	if err := r.db.Get(&compressed, query, chave); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read xml %s: %w", ref, domain.ErrXMLNotStored)
+		}
+		return nil, fmt.Errorf("failed to read xml: %w", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress xml %s: %w", ref, err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress xml %s: %w", ref, err)
+	}
+	return data, nil
+}
+
+// Exists indica se o XML da referência está gravado no banco
+func (r *xmlStorageRepository) Exists(ref string) (bool, error) {
+	chave, err := chaveFromXMLRef(ref)
+	if err != nil {
+		// Referências de outro backend (ex: caminhos de antes da troca para o
+		// banco) não estão neste armazenamento
+		return false, nil
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ` + r.table + ` WHERE chave_acesso = $1)`
+	if err := r.db.Get(&exists, query, chave); err != nil {
+		return false, fmt.Errorf("failed to check xml: %w", err)
+	}
+	return exists, nil
+}
+
+// Check verifica se a tabela nfe_xmls existe e está acessível
+func (r *xmlStorageRepository) Check() error {
+	query := `SELECT 1 FROM ` + r.table + ` LIMIT 1`
+	if _, err := r.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to access xml table: %w", err)
+	}
+	return nil
+}
+
+// chaveFromXMLRef extrai a chave de acesso de uma referência db:<chave>
+func chaveFromXMLRef(ref string) (string, error) {
+	chave, ok := strings.CutPrefix(ref, xmlRefPrefix)
+	if !ok || chave == "" {
+		return "", fmt.Errorf("xml reference %q is not stored in the database", ref)
+	}
+	return chave, nil
+}
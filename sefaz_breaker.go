@@ -0,0 +1,108 @@
+package sefaz
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ErrCircuitOpen é retornado sem chamar a SEFAZ enquanto o circuito está aberto
+var ErrCircuitOpen = errors.New("sefaz circuit breaker is open")
+
+// CircuitBreaker interrompe as chamadas à SEFAZ após falhas consecutivas.
+// Aberto, rejeita as chamadas até o fim do cooldown; depois permite uma única
+// chamada de teste (meio-aberto), que fecha o circuito em caso de sucesso ou o
+// reabre em caso de falha.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state    domain.CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker cria um circuit breaker que abre após threshold falhas
+// consecutivas e permanece aberto pelo cooldown informado
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     domain.CircuitClosed,
+	}
+}
+
+// Allow indica se uma chamada pode ser feita, retornando ErrCircuitOpen caso contrário
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case domain.CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = domain.CircuitHalfOpen
+		b.probing = true
+		return nil
+	case domain.CircuitHalfOpen:
+		// Apenas uma chamada de teste por vez
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// Success registra uma chamada bem-sucedida e fecha o circuito
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = domain.CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure registra uma chamada com falha, abrindo o circuito ao atingir o limite
+// ou imediatamente quando a chamada de teste falha
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == domain.CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = domain.CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Status retorna o estado atual do circuito
+func (b *CircuitBreaker) Status() domain.CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := domain.CircuitStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Threshold:           b.threshold,
+		Cooldown:            b.cooldown.String(),
+	}
+	if b.state != domain.CircuitClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
@@ -0,0 +1,64 @@
+package sefaz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Date(2025, 12, 10, 2, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(3, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, breaker.Allow())
+		breaker.Failure()
+	}
+	assert.Equal(t, domain.CircuitClosed, breaker.Status().State)
+
+	assert.NoError(t, breaker.Allow())
+	breaker.Failure()
+	assert.Equal(t, domain.CircuitOpen, breaker.Status().State)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+}
+
+func TestCircuitBreaker_HalfOpenRecovery(t *testing.T) {
+	now := time.Date(2025, 12, 10, 2, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// Após o cooldown apenas uma chamada de teste é liberada
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, domain.CircuitHalfOpen, breaker.Status().State)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	breaker.Success()
+	assert.Equal(t, domain.CircuitClosed, breaker.Status().State)
+	assert.NoError(t, breaker.Allow())
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	now := time.Date(2025, 12, 10, 2, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(5, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		breaker.Failure()
+	}
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.Allow())
+	breaker.Failure()
+
+	status := breaker.Status()
+	assert.Equal(t, domain.CircuitOpen, status.State)
+	assert.Equal(t, now.Add(time.Minute), *status.RetryAt)
+}
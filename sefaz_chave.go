@@ -0,0 +1,44 @@
+package sefaz
+
+import "strings"
+
+// tamanhoChave é o número de dígitos da chave de acesso, incluindo o dígito verificador
+const tamanhoChave = 44
+
+// DigitoChave calcula o dígito verificador (módulo 11, pesos de 2 a 9 da
+// direita para a esquerda) dos 43 primeiros dígitos da chave de acesso.
+// Retorna false quando a entrada não tem 43 dígitos.
+func DigitoChave(chave43 string) (byte, bool) {
+	if len(chave43) != tamanhoChave-1 {
+		return 0, false
+	}
+
+	soma, peso := 0, 2
+	for i := len(chave43) - 1; i >= 0; i-- {
+		c := chave43[i]
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		soma += int(c-'0') * peso
+		if peso++; peso > 9 {
+			peso = 2
+		}
+	}
+
+	resto := soma % 11
+	if resto < 2 {
+		return '0', true
+	}
+	return byte('0' + 11 - resto), true
+}
+
+// ChaveValida verifica se a chave de acesso tem 44 dígitos e o dígito
+// verificador correto
+func ChaveValida(chave string) bool {
+	chave = strings.TrimSpace(chave)
+	if len(chave) != tamanhoChave {
+		return false
+	}
+	dv, ok := DigitoChave(chave[:tamanhoChave-1])
+	return ok && chave[tamanhoChave-1] == dv
+}
@@ -0,0 +1,26 @@
+package sefaz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigitoChave(t *testing.T) {
+	// Exemplo do Manual de Orientação do Contribuinte
+	dv, ok := DigitoChave("3508059999909091027055001000000001518005127")
+	assert.True(t, ok)
+	assert.Equal(t, byte('3'), dv)
+
+	_, ok = DigitoChave("350805")
+	assert.False(t, ok)
+	_, ok = DigitoChave("350805999990909102705500100000000151800512X")
+	assert.False(t, ok)
+}
+
+func TestChaveValida(t *testing.T) {
+	assert.True(t, ChaveValida("35080599999090910270550010000000015180051273"))
+	assert.True(t, ChaveValida("35251212345678000100550010000001231000001234"))
+	assert.False(t, ChaveValida("35080599999090910270550010000000015180051274"))
+	assert.False(t, ChaveValida("3508059999909091027055001000000001518005127"))
+}
@@ -0,0 +1,848 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
+	"nfe-sefaz-sync/pkg/logger"
+)
+
+const (
+	// Operações informadas nos erros da SEFAZ
+	opConsultaProtocolo = "consulta protocolo"
+	opDistribuicaoDFe   = "distribuicao dfe"
+	opDownloadXML       = "download xml"
+	opInutilizacao      = "inutilizacao"
+	opManifestacao      = "manifestacao"
+	opPing              = "ping"
+
+	// maxDistDFeCalls limita as chamadas da distribuição DFe em uma consulta
+	maxDistDFeCalls = 100
+
+	// defaultDFeBatchSize é o máximo de documentos que a SEFAZ retorna por chamada
+	defaultDFeBatchSize = 50
+
+	// defaultDFeMaxDocsPerRun limita os documentos consumidos em uma sincronização
+	defaultDFeMaxDocsPerRun = 2000
+
+	// defaultBreakerThreshold é o número de falhas consecutivas que abre o circuito
+	defaultBreakerThreshold = 5
+
+	// defaultBreakerCooldown é o tempo que o circuito permanece aberto
+	defaultBreakerCooldown = time.Minute
+
+	// maxResponseSize limita o tamanho da resposta lida da SEFAZ
+	maxResponseSize = 20 << 20
+
+	schemaResNFe     = "resNFe"
+	schemaProcNFe    = "procNFe"
+	schemaProcEvento = "procEventoNFe"
+)
+
+// SefazClientOptions reúne as configurações opcionais de transporte do cliente SEFAZ
+type SefazClientOptions struct {
+	// ProxyURL é o proxy HTTP usado nas chamadas à SEFAZ. Hosts listados em
+	// NO_PROXY são acessados diretamente.
+	ProxyURL string
+
+	// MaxIdleConnsPerHost é o número de conexões TLS mantidas abertas por host
+	// para reaproveitamento entre chamadas
+	MaxIdleConnsPerHost int
+
+	// KeepAlive é o intervalo de keep-alive TCP das conexões
+	KeepAlive time.Duration
+
+	// IdleConnTimeout é o tempo máximo que uma conexão ociosa fica no pool
+	IdleConnTimeout time.Duration
+
+	// DFeBatchSize é o número de documentos esperado por chamada da distribuição.
+	// Uma resposta com menos documentos indica que não há mais pendências.
+	DFeBatchSize int
+
+	// DFeMaxDocsPerRun limita os documentos consumidos em uma consulta. O
+	// restante fica para a próxima sincronização a partir do último NSU.
+	DFeMaxDocsPerRun int
+
+	// MinTLSVersion é a versão mínima de TLS negociada com a SEFAZ ("1.2" por padrão)
+	MinTLSVersion string
+
+	// UserAgent é enviado no cabeçalho User-Agent das chamadas; vazio usa o
+	// padrão do net/http
+	UserAgent string
+
+	// EndpointOverrides substitui endereços do registro padrão de web services,
+	// com chaves no formato UF:SERVICO
+	EndpointOverrides map[string]string
+
+	// ServiceVersions e ServiceNamespaces substituem a versão do leiaute e o
+	// namespace do WSDL dos serviços, com chaves no nome do serviço
+	ServiceVersions   map[string]string
+	ServiceNamespaces map[string]string
+
+	// Timeouts por operação, aplicados a cada chamada ao web service. Quando
+	// zero, vale o timeout geral informado em NewSefazClient.
+	StatusTimeout   time.Duration
+	DownloadTimeout time.Duration
+	ConsultaTimeout time.Duration
+
+	// BreakerThreshold é o número de falhas consecutivas de comunicação que abre
+	// o circuit breaker; BreakerCooldown é o tempo que ele permanece aberto
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// Throttle impõe o intervalo mínimo entre chamadas à SEFAZ. Deve ser o mesmo
+	// para todos os clientes, já que o bloqueio por excesso de consultas não
+	// distingue os workers; nil não limita as chamadas.
+	Throttle *sefaz.Throttle
+
+	// RateLimiter limita o total de chamadas à SEFAZ por minuto e, como o
+	// Throttle, deve ser compartilhado por todos os clientes; nil não limita
+	RateLimiter *sefaz.RateLimiter
+}
+
+// sefazClient implementa domain.SefazClient usando os web services SOAP da SEFAZ
+type sefazClient struct {
+	ambiente      string
+	uf            string
+	cnpj          string
+	batchSize     int
+	maxDocsPerRun int
+	endpoints     *sefaz.Endpoints
+	versions      *sefaz.Versions
+	breaker       *sefaz.CircuitBreaker
+	logger        *logger.Logger
+
+	// httpClient é trocado por inteiro na recarga do certificado; as chamadas
+	// em andamento terminam com o transporte anterior
+	httpClient atomic.Pointer[http.Client]
+	// cert assina os pedidos enviados à SEFAZ (ex: inutilização) e é trocado
+	// junto com o transporte
+	cert atomic.Pointer[tls.Certificate]
+	opts SefazClientOptions
+
+	// timeout é o timeout geral, usado nas operações sem timeout próprio
+	timeout         time.Duration
+	statusTimeout   time.Duration
+	downloadTimeout time.Duration
+	consultaTimeout time.Duration
+}
+
+// NewSefazClient cria um cliente SEFAZ autenticado com o certificado A1
+func NewSefazClient(
+	ambiente string,
+	uf string,
+	cnpj string,
+	cert tls.Certificate,
+	timeout time.Duration,
+	log *logger.Logger,
+	opts SefazClientOptions,
+) (domain.SefazClient, error) {
+	transport, err := newSefazTransport(cert, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := sefaz.NewEndpoints(ambiente, opts.EndpointOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	distDFeURL, overridden, err := endpoints.URL(uf, sefaz.ServiceDistribuicaoDFe)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Endereço da distribuição DFe configurado", "url", distDFeURL, "override", overridden)
+
+	versions, err := sefaz.NewVersions(opts.ServiceVersions, opts.ServiceNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	distDFeVersion := versions.Get(sefaz.ServiceDistribuicaoDFe)
+	log.Info("Versão da distribuição DFe configurada", "versao", distDFeVersion.Versao, "namespace", distDFeVersion.Namespace)
+
+	batchSize := opts.DFeBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultDFeBatchSize
+	}
+	maxDocsPerRun := opts.DFeMaxDocsPerRun
+	if maxDocsPerRun <= 0 {
+		maxDocsPerRun = defaultDFeMaxDocsPerRun
+	}
+	breakerThreshold := opts.BreakerThreshold
+	if breakerThreshold <= 0 {
+		breakerThreshold = defaultBreakerThreshold
+	}
+	breakerCooldown := opts.BreakerCooldown
+	if breakerCooldown <= 0 {
+		breakerCooldown = defaultBreakerCooldown
+	}
+
+	client := &sefazClient{
+		ambiente:        ambiente,
+		uf:              uf,
+		cnpj:            cnpj,
+		batchSize:       batchSize,
+		maxDocsPerRun:   maxDocsPerRun,
+		endpoints:       endpoints,
+		versions:        versions,
+		breaker:         sefaz.NewCircuitBreaker(breakerThreshold, breakerCooldown),
+		logger:          log,
+		opts:            opts,
+		timeout:         timeout,
+		statusTimeout:   operationTimeout(opts.StatusTimeout, timeout),
+		downloadTimeout: operationTimeout(opts.DownloadTimeout, timeout),
+		consultaTimeout: operationTimeout(opts.ConsultaTimeout, timeout),
+	}
+	client.httpClient.Store(&http.Client{Transport: transport})
+	client.cert.Store(&cert)
+
+	return client, nil
+}
+
+// SetCertificate troca o certificado do cliente sem interromper as chamadas em
+// andamento. Um novo transporte é criado porque as conexões e o cache de sessões
+// TLS do anterior continuariam autenticados com o certificado antigo.
+func (c *sefazClient) SetCertificate(cert tls.Certificate) error {
+	transport, err := newSefazTransport(cert, c.opts)
+	if err != nil {
+		return err
+	}
+
+	previous := c.httpClient.Swap(&http.Client{Transport: transport})
+	previous.CloseIdleConnections()
+	c.cert.Store(&cert)
+
+	c.logger.Info("Certificado do cliente SEFAZ substituído", "cnpj", c.cnpj)
+	return nil
+}
+
+// operationTimeout retorna o timeout da operação ou o timeout geral quando não configurado
+func operationTimeout(timeout, fallback time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	return fallback
+}
+
+// ConsultarNFes percorre a distribuição DFe a partir de ultNSU e retorna os
+// resumos de todas as NFes distribuídas, qualquer que seja a data de emissão:
+// o cursor avança sobre todos os documentos consumidos, então nenhum pode ser
+// descartado. Os erros são *sefaz.Error.
+func (c *sefazClient) ConsultarNFes(cnpj, ultNSU string) (*domain.ConsultaNFes, error) {
+	consulta, err := c.consultarNFes(cnpj, ultNSU)
+	return consulta, sefaz.WithOp(opDistribuicaoDFe, err)
+}
+
+// consultarNFes implementa ConsultarNFes
+func (c *sefazClient) consultarNFes(cnpj, ultNSU string) (*domain.ConsultaNFes, error) {
+	resumos := []domain.NFeResumo{}
+	failures := []domain.DownloadFailure{}
+	seen := make(map[string]bool)
+	totalDocs := 0
+
+	if ultNSU == "" {
+		ultNSU = "0"
+	}
+
+	for i := 0; i < maxDistDFeCalls; i++ {
+		ret, err := c.distDFe(c.uf, cnpj, distDFeIntXML{DistNSU: &distNSUXML{UltNSU: padNSU(ultNSU)}}, c.consultaTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		cStat, err := sefaz.ParseCStat(ret.CStat)
+		if err != nil {
+			return nil, err
+		}
+		if cStat == sefaz.CStatNenhumDocumentoLocalizado {
+			if ret.UltNSU != "" {
+				ultNSU = ret.UltNSU
+			}
+			break
+		}
+		if err := sefaz.NewCStatError(opDistribuicaoDFe, cStat, ret.XMotivo); err != nil {
+			return nil, err
+		}
+
+		// Cada documento é interpretado isoladamente: um docZip corrompido é
+		// devolvido como falha e não impede o avanço do lote
+		for _, doc := range ret.Docs {
+			resumo, err := c.docSummary(doc)
+			if err != nil {
+				c.logger.Warn("Documento da distribuição DFe inválido", "cnpj", cnpj, "nsu", doc.NSU, "schema", doc.Schema, "error", err)
+				failures = append(failures, domain.DownloadFailure{
+					NSU:     doc.NSU,
+					Schema:  doc.Schema,
+					Content: strings.TrimSpace(doc.Content),
+					Error:   err.Error(),
+				})
+				continue
+			}
+			if resumo == nil || seen[resumo.ChaveAcesso] {
+				continue
+			}
+			seen[resumo.ChaveAcesso] = true
+			resumos = append(resumos, *resumo)
+		}
+
+		totalDocs += len(ret.Docs)
+		if ret.UltNSU == "" || ret.UltNSU >= ret.MaxNSU || len(ret.Docs) < c.batchSize {
+			if ret.UltNSU != "" {
+				ultNSU = ret.UltNSU
+			}
+			break
+		}
+		ultNSU = ret.UltNSU
+
+		if totalDocs >= c.maxDocsPerRun {
+			c.logger.Info("Limite de documentos por sincronização atingido, restante fica para a próxima execução",
+				"cnpj", cnpj,
+				"documentos", totalDocs,
+				"ult_nsu", ultNSU,
+				"max_nsu", ret.MaxNSU,
+			)
+			break
+		}
+	}
+
+	c.logger.Info("Consulta de NFes na SEFAZ concluída",
+		"cnpj", cnpj,
+		"total", len(resumos),
+		"documentos", totalDocs,
+		"falhas", len(failures),
+		"ult_nsu", ultNSU,
+	)
+
+	return &domain.ConsultaNFes{Resumos: resumos, Failures: failures, UltNSU: ultNSU}, nil
+}
+
+// DownloadXML baixa o XML completo (nfeProc) de uma NFe pela chave de acesso.
+// Os erros são *sefaz.Error; XML indisponível continua identificável por
+// errors.Is(err, domain.ErrXMLUnavailable). Quando a SEFAZ entrega apenas o
+// evento de cancelamento, o erro traz o evento em *domain.CancelledXMLError.
+func (c *sefazClient) DownloadXML(chaveAcesso string) ([]byte, error) {
+	data, err := c.downloadXML(chaveAcesso)
+	return data, sefaz.WithOp(opDownloadXML, err)
+}
+
+// downloadXML implementa DownloadXML
+func (c *sefazClient) downloadXML(chaveAcesso string) ([]byte, error) {
+	// A distribuição DFe é um serviço do Ambiente Nacional e entrega NFes de
+	// qualquer autorizador; o endereço é o mesmo da consulta por NSU
+	ret, err := c.distDFe(c.uf, c.cnpj, distDFeIntXML{ConsChNFe: &consChNFeXML{ChNFe: chaveAcesso}}, c.downloadTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	cStat, err := sefaz.ParseCStat(ret.CStat)
+	if err != nil {
+		return nil, err
+	}
+	if cStat == sefaz.CStatNenhumDocumentoLocalizado {
+		return nil, &sefaz.Error{CStat: cStat, XMotivo: ret.XMotivo, Err: domain.ErrXMLUnavailable}
+	}
+	if err := sefaz.NewCStatError(opDownloadXML, cStat, ret.XMotivo); err != nil {
+		return nil, err
+	}
+
+	var cancelamento []byte
+	for _, doc := range ret.Docs {
+		switch {
+		case strings.HasPrefix(doc.Schema, schemaProcNFe):
+			return decodeDocZip(doc.Content)
+		case strings.HasPrefix(doc.Schema, schemaProcEvento) && cancelamento == nil:
+			data, err := decodeDocZip(doc.Content)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := parseCancelamentoXML(data); err == nil {
+				cancelamento = data
+			}
+		}
+	}
+
+	// NFe cancelada antes do primeiro download: a SEFAZ não entrega mais o XML
+	// autorizado, apenas o evento de cancelamento
+	if cancelamento != nil {
+		return nil, &domain.CancelledXMLError{EventXML: cancelamento}
+	}
+
+	// Apenas o resumo está disponível (ex: falta manifestação do destinatário)
+	return nil, domain.ErrXMLUnavailable
+}
+
+// InutilizarNumeracao envia o pedido de inutilização da faixa ao autorizador
+// da UF. Serviço paralisado e consumo indevido retornam *sefaz.Error; as
+// demais respostas da SEFAZ retornam o resultado, homologado ou rejeitado.
+func (c *sefazClient) InutilizarNumeracao(req domain.InutilizacaoRequest) (*domain.Inutilizacao, error) {
+	result, err := c.inutilizarNumeracao(req)
+	return result, sefaz.WithOp(opInutilizacao, err)
+}
+
+// inutilizarNumeracao implementa InutilizarNumeracao
+func (c *sefazClient) inutilizarNumeracao(req domain.InutilizacaoRequest) (*domain.Inutilizacao, error) {
+	cUF, ok := sefaz.CodigoUF(c.uf)
+	if !ok {
+		return nil, fmt.Errorf("unknown uf %q", c.uf)
+	}
+
+	ano := time.Now().Format("06")
+	msg := inutNFeXML{InfInut: infInutXML{
+		ID:     inutilizacaoID(cUF, ano, c.cnpj, req.Serie, req.NumeroInicial, req.NumeroFinal),
+		TpAmb:  sefaz.TpAmb(c.ambiente),
+		XServ:  "INUTILIZAR",
+		CUF:    cUF,
+		Ano:    ano,
+		CNPJ:   c.cnpj,
+		Mod:    modeloNFe,
+		Serie:  strconv.Itoa(req.Serie),
+		NNFIni: strconv.Itoa(req.NumeroInicial),
+		NNFFin: strconv.Itoa(req.NumeroFinal),
+		XJust:  req.Justificativa,
+	}}
+
+	envelope, err := buildInutNFeEnvelope(msg, *c.cert.Load(), c.versions.Get(sefaz.ServiceInutilizacao))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.post(c.uf, sefaz.ServiceInutilizacao, envelope, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := parseInutNFeResponse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cStat, err := sefaz.ParseCStat(ret.CStat)
+	if err != nil {
+		return nil, err
+	}
+	if cStat.IsRetryable() || cStat == sefaz.CStatConsumoIndevido {
+		return nil, sefaz.NewCStatError(opInutilizacao, cStat, ret.XMotivo)
+	}
+
+	result := &domain.Inutilizacao{
+		CNPJ:          c.cnpj,
+		Ano:           ano,
+		Modelo:        modeloNFe,
+		Serie:         req.Serie,
+		NumeroInicial: req.NumeroInicial,
+		NumeroFinal:   req.NumeroFinal,
+		Justificativa: req.Justificativa,
+		Status:        domain.InutilizacaoRejeitada,
+		CStat:         int(cStat),
+		Motivo:        ret.XMotivo,
+		Protocolo:     ret.NProt,
+	}
+	if cStat == sefaz.CStatInutilizada {
+		result.Status = domain.InutilizacaoHomologada
+	}
+	if dhRecbto, err := time.Parse(time.RFC3339, ret.DhRecbto); err == nil {
+		result.DataRecebimento = &dhRecbto
+	}
+
+	return result, nil
+}
+
+// Manifestar envia ao Ambiente Nacional o lote de eventos de manifestação das
+// chaves, no máximo maxEventosLote. Serviço paralisado e consumo indevido
+// retornam *sefaz.Error; eventos recusados retornam o resultado rejeitado.
+func (c *sefazClient) Manifestar(tipo domain.ManifestacaoTipo, chaves []string, justificativa string) ([]domain.ManifestacaoResult, error) {
+	results, err := c.manifestar(tipo, chaves, justificativa)
+	return results, sefaz.WithOp(opManifestacao, err)
+}
+
+// manifestar implementa Manifestar
+func (c *sefazClient) manifestar(tipo domain.ManifestacaoTipo, chaves []string, justificativa string) ([]domain.ManifestacaoResult, error) {
+	if len(chaves) == 0 || len(chaves) > maxEventosLote {
+		return nil, fmt.Errorf("event batch must have between 1 and %d chaves, got %d", maxEventosLote, len(chaves))
+	}
+
+	version := c.versions.Get(sefaz.ServiceRecepcaoEvento)
+	now := time.Now()
+	eventos := make([]eventoXML, len(chaves))
+	for i, chave := range chaves {
+		eventos[i] = eventoXML{InfEvento: infEventoXML{
+			ID:         eventoID(tipo.TpEvento(), chave, 1),
+			COrgao:     codigoOrgaoAN,
+			TpAmb:      sefaz.TpAmb(c.ambiente),
+			CNPJ:       c.cnpj,
+			ChNFe:      chave,
+			DhEvento:   now.Format("2006-01-02T15:04:05-07:00"),
+			TpEvento:   tipo.TpEvento(),
+			NSeqEvento: "1",
+			VerEvento:  version.Versao,
+			DetEvento: detEventoXML{
+				Versao:     version.Versao,
+				DescEvento: tipo.DescEvento(),
+				XJust:      justificativa,
+			},
+		}}
+	}
+
+	idLote := strconv.FormatInt(now.UnixNano()%1e15, 10)
+	envelope, err := buildEnvEventoEnvelope(idLote, eventos, *c.cert.Load(), version)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.post(ufAmbienteNacional, sefaz.ServiceRecepcaoEvento, envelope, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := parseEnvEventoResponse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cStat, err := sefaz.ParseCStat(ret.CStat)
+	if err != nil {
+		return nil, err
+	}
+	if cStat.IsRetryable() || cStat == sefaz.CStatConsumoIndevido {
+		return nil, sefaz.NewCStatError(opManifestacao, cStat, ret.XMotivo)
+	}
+
+	retornos := make(map[string]retInfEventoXML, len(ret.RetEvento))
+	for _, retorno := range ret.RetEvento {
+		retornos[retorno.ChNFe] = retorno
+	}
+
+	results := make([]domain.ManifestacaoResult, len(chaves))
+	for i, chave := range chaves {
+		results[i] = domain.ManifestacaoResult{ChaveAcesso: chave, Status: domain.ManifestacaoRejeitada}
+
+		// O lote recusado por inteiro (ex: CNPJ sem certificado) vale para todas as chaves
+		if cStat != sefaz.CStatLoteEventoProcessado {
+			results[i].CStat, results[i].Motivo = int(cStat), ret.XMotivo
+			continue
+		}
+
+		retorno, ok := retornos[chave]
+		if !ok {
+			results[i].Motivo = "evento sem retorno no lote"
+			continue
+		}
+
+		eventoCStat, err := sefaz.ParseCStat(retorno.CStat)
+		if err != nil {
+			results[i].Motivo = err.Error()
+			continue
+		}
+		results[i].CStat = int(eventoCStat)
+		results[i].Motivo = retorno.XMotivo
+		if eventoCStat == sefaz.CStatEventoRegistrado || eventoCStat == sefaz.CStatEventoRegistradoNaoVinculado {
+			results[i].Status = domain.ManifestacaoRegistrada
+			results[i].Protocolo = retorno.NProt
+		}
+		if dhRegEvento, err := time.Parse(time.RFC3339, retorno.DhRegEvento); err == nil {
+			results[i].DataRegistro = &dhRegEvento
+		}
+	}
+
+	return results, nil
+}
+
+// ConsultarProtocolo consulta a situação atual da NFe na SEFAZ autorizadora
+func (c *sefazClient) ConsultarProtocolo(chaveAcesso string) (*domain.ProtocoloNFe, error) {
+	protocolo, err := c.consultarProtocolo(chaveAcesso)
+	return protocolo, sefaz.WithOp(opConsultaProtocolo, err)
+}
+
+// consultarProtocolo implementa ConsultarProtocolo. A consulta é leve, sem o
+// XML da NFe, e usa o timeout da consulta de status.
+func (c *sefazClient) consultarProtocolo(chaveAcesso string) (*domain.ProtocoloNFe, error) {
+	uf := ufFromChave(chaveAcesso)
+	if uf == "" {
+		return nil, fmt.Errorf("invalid chave de acesso %q", chaveAcesso)
+	}
+
+	msg := consSitNFeXML{
+		TpAmb: sefaz.TpAmb(c.ambiente),
+		XServ: "CONSULTAR",
+		ChNFe: chaveAcesso,
+	}
+	envelope, err := buildConsSitNFeEnvelope(msg, c.versions.Get(sefaz.ServiceConsultaProtocolo))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.post(uf, sefaz.ServiceConsultaProtocolo, envelope, c.statusTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := parseConsSitNFeResponse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cStat, err := sefaz.ParseCStat(ret.CStat)
+	if err != nil {
+		return nil, err
+	}
+	if cStat == sefaz.CStatNFeNaoConsta {
+		return nil, &sefaz.Error{Op: opConsultaProtocolo, CStat: cStat, XMotivo: ret.XMotivo, Err: domain.ErrNFeNotFoundAtSefaz}
+	}
+	if !cStat.IsSuccess() {
+		return nil, sefaz.NewCStatError(opConsultaProtocolo, cStat, ret.XMotivo)
+	}
+
+	protocolo := &domain.ProtocoloNFe{
+		ChaveAcesso: chaveAcesso,
+		Status:      cStat.NFeStatus(),
+		CStat:       int(cStat),
+		Motivo:      ret.XMotivo,
+	}
+	if ret.ProtNFe != nil {
+		protocolo.Protocolo = ret.ProtNFe.InfProt.NProt
+		protocolo.DigestValue = ret.ProtNFe.InfProt.DigVal
+		if dhRecbto, err := time.Parse(time.RFC3339, ret.ProtNFe.InfProt.DhRecbto); err == nil {
+			protocolo.DataAutorizacao = &dhRecbto
+		}
+	}
+	// Os demais eventos (carta de correção, manifestações) são ignorados
+	for i := range ret.ProcEventoNFe {
+		cancelamento, err := cancelamentoFromProcEvento(&ret.ProcEventoNFe[i])
+		if err != nil {
+			continue
+		}
+		protocolo.DataCancelamento = &cancelamento.DataCancelamento
+		protocolo.MotivoCancelamento = cancelamento.Motivo
+	}
+
+	return protocolo, nil
+}
+
+// distDFe envia uma requisição ao web service NFeDistribuicaoDFe, no endereço
+// resolvido para a UF informada. O cUFAutor é sempre a UF configurada, do
+// interessado nos documentos.
+func (c *sefazClient) distDFe(uf, cnpj string, msg distDFeIntXML, timeout time.Duration) (*retDistDFeIntXML, error) {
+	cUF, ok := sefaz.CodigoUF(c.uf)
+	if !ok {
+		return nil, fmt.Errorf("unknown uf %q", c.uf)
+	}
+
+	msg.TpAmb = sefaz.TpAmb(c.ambiente)
+	msg.CUFAutor = cUF
+	msg.CNPJ = cnpj
+
+	envelope, err := buildDistDFeEnvelope(msg, c.versions.Get(sefaz.ServiceDistribuicaoDFe))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.post(uf, sefaz.ServiceDistribuicaoDFe, envelope, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDistDFeResponse(data)
+}
+
+// serviceURL resolve o endereço do web service para a UF
+func (c *sefazClient) serviceURL(uf string, service sefaz.Service) (string, error) {
+	url, overridden, err := c.endpoints.URL(uf, service)
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug("Endereço do web service SEFAZ", "service", service, "uf", uf, "url", url, "override", overridden)
+	return url, nil
+}
+
+// CircuitStatus retorna o estado do circuit breaker das chamadas à SEFAZ
+func (c *sefazClient) CircuitStatus() domain.CircuitStatus {
+	return c.breaker.Status()
+}
+
+// Ping busca o WSDL da distribuição DFe com o certificado do cliente. Fora do
+// circuit breaker e dos limites de chamadas, serve apenas para verificar na
+// inicialização se a SEFAZ e o handshake TLS respondem.
+func (c *sefazClient) Ping() error {
+	url, err := c.serviceURL(c.uf, sefaz.ServiceDistribuicaoDFe)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.statusTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"?wsdl", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create sefaz request: %w", err)
+	}
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+
+	resp, err := c.httpClient.Load().Do(req)
+	if err != nil {
+		return sefaz.NewTransportError(opPing, fmt.Errorf("failed to call sefaz: %w", err))
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode != http.StatusOK {
+		return &sefaz.Error{
+			Op:        opPing,
+			Retryable: resp.StatusCode >= http.StatusInternalServerError,
+			Err:       fmt.Errorf("sefaz returned http status %d", resp.StatusCode),
+		}
+	}
+	return nil
+}
+
+// post envia o envelope SOAP ao web service pelo circuit breaker. Falhas de
+// comunicação e respostas 5xx contam como falha; rejeições de negócio (cStat)
+// não. Ambas são retornadas como *sefaz.Error repetível.
+func (c *sefazClient) post(uf string, service sefaz.Service, envelope []byte, timeout time.Duration) ([]byte, error) {
+	url, err := c.serviceURL(uf, service)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		return nil, sefaz.NewTransportError("", err)
+	}
+	c.opts.RateLimiter.Wait()
+	c.opts.Throttle.Wait()
+
+	data, status, err := c.doPost(url, c.versions.SOAPAction(service), envelope, timeout)
+	if err != nil || status >= http.StatusInternalServerError {
+		c.breaker.Failure()
+		if circuit := c.breaker.Status(); circuit.State == domain.CircuitOpen {
+			c.logger.Warn("Circuit breaker da SEFAZ aberto, chamadas suspensas",
+				"falhas_consecutivas", circuit.ConsecutiveFailures,
+				"retry_at", circuit.RetryAt,
+			)
+		}
+	} else {
+		c.breaker.Success()
+	}
+	if err != nil {
+		return nil, sefaz.NewTransportError("", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, &sefaz.Error{
+			Retryable: status >= http.StatusInternalServerError,
+			Err:       fmt.Errorf("sefaz returned http status %d", status),
+		}
+	}
+
+	return data, nil
+}
+
+// doPost envia o envelope SOAP e retorna o corpo e o status HTTP da resposta.
+// O timeout cobre a chamada inteira, incluindo a leitura do corpo.
+func (c *sefazClient) doPost(url, action string, envelope []byte, timeout time.Duration) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(envelope))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create sefaz request: %w", err)
+	}
+	// SOAP 1.2 leva a ação no Content-Type; alguns gateways das SEFAZ ainda
+	// exigem também o cabeçalho SOAPAction do SOAP 1.1
+	req.Header.Set("Content-Type", fmt.Sprintf(`application/soap+xml; charset=utf-8; action="%s"`, action))
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s"`, action))
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Load().Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, 0, fmt.Errorf("failed to call sefaz: timeout after %s: %w", timeout, err)
+		}
+		return nil, 0, fmt.Errorf("failed to call sefaz: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read sefaz response: %w", err)
+	}
+
+	c.logger.Debug("Resposta recebida da SEFAZ",
+		"url", url,
+		"status", resp.StatusCode,
+		"duration", time.Since(start),
+	)
+
+	return data, resp.StatusCode, nil
+}
+
+// docSummary extrai o resumo de um documento da distribuição (resNFe ou
+// procNFe). Retorna nil sem erro para documentos que não representam NFes.
+func (c *sefazClient) docSummary(doc docZipXML) (*domain.NFeResumo, error) {
+	data, err := decodeDocZip(doc.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(doc.Schema, schemaResNFe):
+		var res resNFeXML
+		if err := xml.Unmarshal(data, &res); err != nil {
+			return nil, fmt.Errorf("invalid resNFe: %w", err)
+		}
+		dataEmissao, err := time.Parse(time.RFC3339, res.DhEmi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resNFe dhEmi %q: %w", res.DhEmi, err)
+		}
+		valorTotal, _ := domain.ParseMoney(res.VNF)
+		return &domain.NFeResumo{
+			ChaveAcesso:  res.ChNFe,
+			CNPJEmitente: res.CNPJ,
+			NomeEmitente: res.XNome,
+			DataEmissao:  dataEmissao,
+			ValorTotal:   valorTotal,
+			TipoOperacao: tipoOperacaoFromTpNF(res.TpNF),
+		}, nil
+
+	case strings.HasPrefix(doc.Schema, schemaProcNFe):
+		nfe, err := parseNFeXML(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid procNFe: %w", err)
+		}
+		return &domain.NFeResumo{
+			ChaveAcesso:  nfe.ChaveAcesso,
+			CNPJEmitente: nfe.CNPJEmitente,
+			NomeEmitente: nfe.NomeEmitente,
+			DataEmissao:  nfe.DataEmissao,
+			ValorTotal:   nfe.ValorTotal,
+			TipoOperacao: nfe.TipoOperacao,
+		}, nil
+	}
+
+	// Eventos e outros documentos não representam novas NFes
+	return nil, nil
+}
+
+// padNSU formata o NSU com os 15 dígitos exigidos pela SEFAZ
+func padNSU(nsu string) string {
+	if len(nsu) >= 15 {
+		return nsu
+	}
+	return strings.Repeat("0", 15-len(nsu)) + nsu
+}
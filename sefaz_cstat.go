@@ -0,0 +1,137 @@
+package sefaz
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// CStat representa o código de status retornado pelos web services da SEFAZ
+type CStat int
+
+const (
+	CStatAutorizada                    CStat = 100
+	CStatCancelada                     CStat = 101
+	CStatInutilizada                   CStat = 102
+	CStatLoteRecebido                  CStat = 103
+	CStatLoteProcessado                CStat = 104
+	CStatLoteEmProcessamento           CStat = 105
+	CStatServicoEmOperacao             CStat = 107
+	CStatServicoParalisadoMomentaneo   CStat = 108
+	CStatServicoParalisadoSemPrevisao  CStat = 109
+	CStatDenegada                      CStat = 110
+	CStatEPECAutorizado                CStat = 124
+	CStatLoteEventoProcessado          CStat = 128
+	CStatEventoRegistrado              CStat = 135
+	CStatEventoRegistradoNaoVinculado  CStat = 136
+	CStatNenhumDocumentoLocalizado     CStat = 137
+	CStatDocumentoLocalizado           CStat = 138
+	CStatAutorizadaForaPrazo           CStat = 150
+	CStatCanceladaForaPrazo            CStat = 151
+	CStatCanceladaPorSubstituicao      CStat = 155
+	CStatNFeNaoConsta                  CStat = 217
+	CStatDenegadaEmitenteIrregular     CStat = 301
+	CStatDenegadaDestinatarioIrregular CStat = 302
+	CStatDenegadaDestinatarioNaoHabil  CStat = 303
+	CStatDownloadForaPrazo             CStat = 632
+	CStatConsumoIndevido               CStat = 656
+)
+
+var (
+	// ErrServicoParalisado indica que o web service da SEFAZ está fora do ar
+	ErrServicoParalisado = errors.New("sefaz service unavailable")
+
+	// ErrConsumoIndevido indica que o CNPJ excedeu o limite de consultas da SEFAZ
+	ErrConsumoIndevido = errors.New("sefaz request limit exceeded (consumo indevido)")
+
+	// ErrRejeicao indica que a SEFAZ rejeitou a requisição
+	ErrRejeicao = errors.New("request rejected by sefaz")
+)
+
+// ParseCStat converte o conteúdo da tag cStat em CStat
+func ParseCStat(s string) (CStat, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cStat %q: %w", s, err)
+	}
+	return CStat(code), nil
+}
+
+// IsSuccess indica se o código representa um processamento bem sucedido
+func (c CStat) IsSuccess() bool {
+	switch c {
+	case CStatAutorizada, CStatCancelada, CStatInutilizada, CStatLoteRecebido,
+		CStatLoteProcessado, CStatLoteEmProcessamento, CStatServicoEmOperacao,
+		CStatDenegada, CStatEPECAutorizado, CStatLoteEventoProcessado,
+		CStatEventoRegistrado, CStatEventoRegistradoNaoVinculado,
+		CStatNenhumDocumentoLocalizado, CStatDocumentoLocalizado,
+		CStatAutorizadaForaPrazo, CStatCanceladaForaPrazo, CStatCanceladaPorSubstituicao,
+		CStatDenegadaEmitenteIrregular, CStatDenegadaDestinatarioIrregular,
+		CStatDenegadaDestinatarioNaoHabil:
+		return true
+	}
+	return false
+}
+
+// IsDenegada indica se o código representa uso denegado da NFe
+func (c CStat) IsDenegada() bool {
+	switch c {
+	case CStatDenegada, CStatDenegadaEmitenteIrregular,
+		CStatDenegadaDestinatarioIrregular, CStatDenegadaDestinatarioNaoHabil:
+		return true
+	}
+	return false
+}
+
+// NFeStatus retorna o status da NFe correspondente ao código do protocolo
+func (c CStat) NFeStatus() domain.NFeStatus {
+	switch {
+	case c == CStatAutorizada || c == CStatAutorizadaForaPrazo:
+		return domain.NFeStatusAutorizada
+	case c == CStatCancelada || c == CStatCanceladaForaPrazo || c == CStatCanceladaPorSubstituicao:
+		return domain.NFeStatusCancelada
+	case c.IsDenegada():
+		return domain.NFeStatusDenegada
+	case c == CStatLoteRecebido || c == CStatLoteEmProcessamento:
+		return domain.NFeStatusProcessando
+	case c == CStatEPECAutorizado:
+		// EPEC registrado; a NFe ainda será autorizada pela SEFAZ do emitente
+		return domain.NFeStatusEPEC
+	default:
+		return domain.NFeStatusRejeitada
+	}
+}
+
+// IsRetryable indica se a requisição rejeitada com o código pode ter sucesso
+// quando repetida mais tarde
+func (c CStat) IsRetryable() bool {
+	return c == CStatServicoParalisadoMomentaneo || c == CStatServicoParalisadoSemPrevisao
+}
+
+// Err retorna o erro tipado correspondente ao código, ou nil em caso de sucesso
+func (c CStat) Err() error {
+	if c.IsSuccess() {
+		return nil
+	}
+	if err := c.cause(); err != ErrRejeicao {
+		return err
+	}
+	return fmt.Errorf("%w: cStat %d", ErrRejeicao, int(c))
+}
+
+// cause retorna o erro sentinela de um código que não representa sucesso
+func (c CStat) cause() error {
+	switch {
+	case c == CStatServicoParalisadoMomentaneo || c == CStatServicoParalisadoSemPrevisao:
+		return ErrServicoParalisado
+	case c == CStatConsumoIndevido:
+		return ErrConsumoIndevido
+	case c == CStatNFeNaoConsta || c == CStatDownloadForaPrazo:
+		return domain.ErrXMLUnavailable
+	default:
+		return ErrRejeicao
+	}
+}
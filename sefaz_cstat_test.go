@@ -0,0 +1,47 @@
+package sefaz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+func TestCStatNFeStatus(t *testing.T) {
+	cases := map[CStat]domain.NFeStatus{
+		CStatAutorizada:                    domain.NFeStatusAutorizada,
+		CStatAutorizadaForaPrazo:           domain.NFeStatusAutorizada,
+		CStatCancelada:                     domain.NFeStatusCancelada,
+		CStatCanceladaForaPrazo:            domain.NFeStatusCancelada,
+		CStatDenegada:                      domain.NFeStatusDenegada,
+		CStatDenegadaEmitenteIrregular:     domain.NFeStatusDenegada,
+		CStatDenegadaDestinatarioIrregular: domain.NFeStatusDenegada,
+		CStatLoteEmProcessamento:           domain.NFeStatusProcessando,
+		CStatEPECAutorizado:                domain.NFeStatusEPEC,
+		CStat(539):                         domain.NFeStatusRejeitada,
+	}
+
+	for code, expected := range cases {
+		assert.Equal(t, expected, code.NFeStatus(), "cStat %d", code)
+	}
+}
+
+func TestCStatErr(t *testing.T) {
+	assert.NoError(t, CStatAutorizada.Err())
+	assert.NoError(t, CStatEventoRegistrado.Err())
+	assert.ErrorIs(t, CStatServicoParalisadoMomentaneo.Err(), ErrServicoParalisado)
+	assert.ErrorIs(t, CStatConsumoIndevido.Err(), ErrConsumoIndevido)
+	assert.ErrorIs(t, CStatNFeNaoConsta.Err(), domain.ErrXMLUnavailable)
+	assert.True(t, errors.Is(CStat(539).Err(), ErrRejeicao))
+}
+
+func TestParseCStat(t *testing.T) {
+	code, err := ParseCStat(" 100 ")
+	assert.NoError(t, err)
+	assert.Equal(t, CStatAutorizada, code)
+
+	_, err = ParseCStat("abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,249 @@
+package sefaz
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Service identifica um web service da SEFAZ
+type Service string
+
+const (
+	ServiceDistribuicaoDFe Service = "NFeDistribuicaoDFe"
+	ServiceRecepcaoEvento  Service = "NFeRecepcaoEvento4"
+	ServiceInutilizacao    Service = "NFeInutilizacao4"
+	// ServiceConsultaProtocolo consulta a situação atual da NFe na SEFAZ autorizadora
+	ServiceConsultaProtocolo Service = "NFeConsultaProtocolo4"
+)
+
+const (
+	// autorizadorNacional identifica os serviços atendidos pelo Ambiente Nacional
+	autorizadorNacional = "AN"
+
+	// autorizadorSVRS identifica a SEFAZ Virtual do RS, que atende as UFs sem
+	// autorizador próprio
+	autorizadorSVRS = "SVRS"
+
+	// autorizadorSVAN identifica a SEFAZ Virtual do Ambiente Nacional
+	autorizadorSVAN = "SVAN"
+
+	// wsdlNamespace é o prefixo dos namespaces dos WSDLs da NFe
+	wsdlNamespace = "http://www.portalfiscal.inf.br/nfe/wsdl/"
+)
+
+// soapOperations mapeia cada serviço para a operação do seu WSDL
+var soapOperations = map[Service]string{
+	ServiceDistribuicaoDFe:   "nfeDistDFeInteresse",
+	ServiceRecepcaoEvento:    "nfeRecepcaoEvento",
+	ServiceInutilizacao:      "nfeInutilizacaoNF",
+	ServiceConsultaProtocolo: "nfeConsultaNF",
+}
+
+// ufsSVRS são as UFs cujos serviços de autorização são atendidos pela SVRS
+var ufsSVRS = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "DF": true, "ES": true, "PB": true, "PI": true,
+	"RJ": true, "RN": true, "RO": true, "RR": true, "SC": true, "SE": true, "TO": true,
+}
+
+// ufsSVAN são as UFs cujos serviços de autorização são atendidos pela SVAN
+var ufsSVAN = map[string]bool{"MA": true, "PA": true}
+
+// Autorizador retorna o autorizador das NFes emitidas na UF: SVRS, SVAN ou a
+// própria UF, quando ela tem SEFAZ autorizadora
+func Autorizador(uf string) string {
+	uf = strings.ToUpper(uf)
+	switch {
+	case ufsSVRS[uf]:
+		return autorizadorSVRS
+	case ufsSVAN[uf]:
+		return autorizadorSVAN
+	default:
+		return uf
+	}
+}
+
+// builtinEndpoints é o registro padrão de endereços por ambiente, autorizador e serviço
+var builtinEndpoints = map[string]map[string]map[Service]string{
+	"producao": {
+		autorizadorNacional: {
+			ServiceDistribuicaoDFe: "https://www1.nfe.fazenda.gov.br/NFeDistribuicaoDFe/NFeDistribuicaoDFe.asmx",
+			ServiceRecepcaoEvento:  "https://www.nfe.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx",
+		},
+		autorizadorSVRS: {
+			ServiceInutilizacao:      "https://nfe.svrs.rs.gov.br/ws/nfeinutilizacao/nfeinutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://nfe.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx",
+		},
+		"SP": {
+			ServiceInutilizacao:      "https://nfe.fazenda.sp.gov.br/ws/nfeinutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://nfe.fazenda.sp.gov.br/ws/nfeconsultaprotocolo4.asmx",
+		},
+		"MG": {
+			ServiceInutilizacao:      "https://nfe.fazenda.mg.gov.br/nfe2/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.fazenda.mg.gov.br/nfe2/services/NFeConsultaProtocolo4",
+		},
+		"PR": {
+			ServiceInutilizacao:      "https://nfe.sefa.pr.gov.br/nfe/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefa.pr.gov.br/nfe/NFeConsultaProtocolo4",
+		},
+		"RS": {
+			ServiceInutilizacao:      "https://nfe.sefazrs.rs.gov.br/ws/nfeinutilizacao/nfeinutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://nfe.sefazrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx",
+		},
+		"AM": {
+			ServiceInutilizacao:      "https://nfe.sefaz.am.gov.br/services2/services/NfeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.am.gov.br/services2/services/NfeConsulta4",
+		},
+		"BA": {
+			ServiceInutilizacao:      "https://nfe.sefaz.ba.gov.br/webservices/NFeInutilizacao4/NFeInutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.ba.gov.br/webservices/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx",
+		},
+		"CE": {
+			ServiceInutilizacao:      "https://nfe.sefaz.ce.gov.br/nfe4/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.ce.gov.br/nfe4/services/NFeConsultaProtocolo4",
+		},
+		"GO": {
+			ServiceInutilizacao:      "https://nfe.sefaz.go.gov.br/nfe/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.go.gov.br/nfe/services/NFeConsultaProtocolo4",
+		},
+		"MS": {
+			ServiceInutilizacao:      "https://nfe.sefaz.ms.gov.br/ws/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.ms.gov.br/ws/NFeConsultaProtocolo4",
+		},
+		"MT": {
+			ServiceInutilizacao:      "https://nfe.sefaz.mt.gov.br/nfews/v2/services/NfeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.mt.gov.br/nfews/v2/services/NfeConsulta4",
+		},
+		"PE": {
+			ServiceInutilizacao:      "https://nfe.sefaz.pe.gov.br/nfe-service/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfe.sefaz.pe.gov.br/nfe-service/services/NFeConsultaProtocolo4",
+		},
+		autorizadorSVAN: {
+			ServiceInutilizacao:      "https://www.sefazvirtual.fazenda.gov.br/NFeInutilizacao4/NFeInutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://www.sefazvirtual.fazenda.gov.br/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx",
+		},
+	},
+	"homologacao": {
+		autorizadorNacional: {
+			ServiceDistribuicaoDFe: "https://hom1.nfe.fazenda.gov.br/NFeDistribuicaoDFe/NFeDistribuicaoDFe.asmx",
+			ServiceRecepcaoEvento:  "https://hom1.nfe.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx",
+		},
+		autorizadorSVRS: {
+			ServiceInutilizacao:      "https://nfe-homologacao.svrs.rs.gov.br/ws/nfeinutilizacao/nfeinutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://nfe-homologacao.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx",
+		},
+		"SP": {
+			ServiceInutilizacao:      "https://homologacao.nfe.fazenda.sp.gov.br/ws/nfeinutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://homologacao.nfe.fazenda.sp.gov.br/ws/nfeconsultaprotocolo4.asmx",
+		},
+		"MG": {
+			ServiceInutilizacao:      "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeConsultaProtocolo4",
+		},
+		"PR": {
+			ServiceInutilizacao:      "https://homologacao.nfe.sefa.pr.gov.br/nfe/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://homologacao.nfe.sefa.pr.gov.br/nfe/NFeConsultaProtocolo4",
+		},
+		"RS": {
+			ServiceInutilizacao:      "https://nfe-homologacao.sefazrs.rs.gov.br/ws/nfeinutilizacao/nfeinutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://nfe-homologacao.sefazrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx",
+		},
+		"AM": {
+			ServiceInutilizacao:      "https://homnfe.sefaz.am.gov.br/services2/services/NfeInutilizacao4",
+			ServiceConsultaProtocolo: "https://homnfe.sefaz.am.gov.br/services2/services/NfeConsulta4",
+		},
+		"BA": {
+			ServiceInutilizacao:      "https://hnfe.sefaz.ba.gov.br/webservices/NFeInutilizacao4/NFeInutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://hnfe.sefaz.ba.gov.br/webservices/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx",
+		},
+		"CE": {
+			ServiceInutilizacao:      "https://nfeh.sefaz.ce.gov.br/nfe4/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfeh.sefaz.ce.gov.br/nfe4/services/NFeConsultaProtocolo4",
+		},
+		"GO": {
+			ServiceInutilizacao:      "https://homolog.sefaz.go.gov.br/nfe/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://homolog.sefaz.go.gov.br/nfe/services/NFeConsultaProtocolo4",
+		},
+		"MS": {
+			ServiceInutilizacao:      "https://hom.nfe.sefaz.ms.gov.br/ws/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://hom.nfe.sefaz.ms.gov.br/ws/NFeConsultaProtocolo4",
+		},
+		"MT": {
+			ServiceInutilizacao:      "https://homologacao.sefaz.mt.gov.br/nfews/v2/services/NfeInutilizacao4",
+			ServiceConsultaProtocolo: "https://homologacao.sefaz.mt.gov.br/nfews/v2/services/NfeConsulta4",
+		},
+		"PE": {
+			ServiceInutilizacao:      "https://nfehomolog.sefaz.pe.gov.br/nfe-service/services/NFeInutilizacao4",
+			ServiceConsultaProtocolo: "https://nfehomolog.sefaz.pe.gov.br/nfe-service/services/NFeConsultaProtocolo4",
+		},
+		autorizadorSVAN: {
+			ServiceInutilizacao:      "https://hom.sefazvirtual.fazenda.gov.br/NFeInutilizacao4/NFeInutilizacao4.asmx",
+			ServiceConsultaProtocolo: "https://hom.sefazvirtual.fazenda.gov.br/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx",
+		},
+	},
+}
+
+// Endpoints resolve o endereço dos web services, aplicando as substituições
+// configuradas antes do registro padrão
+type Endpoints struct {
+	ambiente  string
+	overrides map[string]string
+}
+
+// NewEndpoints cria o registro de endereços do ambiente. As chaves de overrides
+// têm o formato UF:SERVICO (ex: SP:NFeDistribuicaoDFe, AN:NFeRecepcaoEvento4,
+// SVRS:NFeInutilizacao4 ou SVAN:NFeInutilizacao4).
+func NewEndpoints(ambiente string, overrides map[string]string) (*Endpoints, error) {
+	normalized := make(map[string]string, len(overrides))
+	for key, rawURL := range overrides {
+		uf, service, ok := strings.Cut(key, ":")
+		if !ok || uf == "" || service == "" {
+			return nil, fmt.Errorf("invalid endpoint override key %q, expected UF:SERVICE", key)
+		}
+
+		uf = strings.ToUpper(uf)
+		if _, known := CodigoUF(uf); !known && uf != autorizadorNacional && uf != autorizadorSVRS && uf != autorizadorSVAN {
+			return nil, fmt.Errorf("invalid endpoint override key %q: unknown uf %s", key, uf)
+		}
+
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint override url %q for %s", rawURL, key)
+		}
+
+		normalized[overrideKey(uf, Service(service))] = rawURL
+	}
+
+	return &Endpoints{ambiente: ambiente, overrides: normalized}, nil
+}
+
+// URL retorna o endereço do serviço para a UF e indica se veio de uma substituição.
+// Serviços sem endereço próprio da UF usam o do autorizador que atende a UF
+// (SVRS ou SVAN) e depois o do Ambiente Nacional. Em cada nível a substituição
+// tem precedência sobre o registro padrão, mas um nível só é consultado quando
+// o anterior não tem endereço: uma substituição do AN não desvia as UFs que
+// têm o serviço no registro padrão.
+func (e *Endpoints) URL(uf string, service Service) (string, bool, error) {
+	uf = strings.ToUpper(uf)
+
+	autorizadores := []string{uf, autorizadorNacional}
+	if autorizador := Autorizador(uf); autorizador != uf {
+		autorizadores = []string{uf, autorizador, autorizadorNacional}
+	}
+
+	for _, autorizador := range autorizadores {
+		if u, ok := e.overrides[overrideKey(autorizador, service)]; ok {
+			return u, true, nil
+		}
+		if u, ok := builtinEndpoints[e.ambiente][autorizador][service]; ok {
+			return u, false, nil
+		}
+	}
+
+	return "", false, fmt.Errorf("no endpoint for service %s in uf %s (%s)", service, uf, e.ambiente)
+}
+
+// overrideKey monta a chave normalizada de substituição
+func overrideKey(uf string, service Service) string {
+	return uf + ":" + string(service)
+}
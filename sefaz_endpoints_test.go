@@ -0,0 +1,151 @@
+package sefaz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsURL_Builtin(t *testing.T) {
+	endpoints, err := NewEndpoints("homologacao", nil)
+	require.NoError(t, err)
+
+	url, overridden, err := endpoints.URL("SP", ServiceDistribuicaoDFe)
+	assert.NoError(t, err)
+	assert.False(t, overridden)
+	assert.Equal(t, "https://hom1.nfe.fazenda.gov.br/NFeDistribuicaoDFe/NFeDistribuicaoDFe.asmx", url)
+}
+
+func TestEndpointsURL_Override(t *testing.T) {
+	endpoints, err := NewEndpoints("producao", map[string]string{
+		"an:NFeDistribuicaoDFe": "https://novo.nfe.fazenda.gov.br/NFeDistribuicaoDFe.asmx",
+		"SP:NFeRecepcaoEvento4": "https://nfe.fazenda.sp.gov.br/ws/recepcaoevento4.asmx",
+	})
+	require.NoError(t, err)
+
+	url, overridden, err := endpoints.URL("sp", ServiceDistribuicaoDFe)
+	assert.NoError(t, err)
+	assert.True(t, overridden)
+	assert.Equal(t, "https://novo.nfe.fazenda.gov.br/NFeDistribuicaoDFe.asmx", url)
+
+	url, overridden, err = endpoints.URL("SP", ServiceRecepcaoEvento)
+	assert.NoError(t, err)
+	assert.True(t, overridden)
+	assert.Equal(t, "https://nfe.fazenda.sp.gov.br/ws/recepcaoevento4.asmx", url)
+
+	url, overridden, err = endpoints.URL("RJ", ServiceRecepcaoEvento)
+	assert.NoError(t, err)
+	assert.False(t, overridden)
+	assert.Equal(t, "https://www.nfe.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx", url)
+}
+
+func TestEndpointsURL_SVRS(t *testing.T) {
+	endpoints, err := NewEndpoints("producao", nil)
+	require.NoError(t, err)
+
+	url, _, err := endpoints.URL("SC", ServiceInutilizacao)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://nfe.svrs.rs.gov.br/ws/nfeinutilizacao/nfeinutilizacao4.asmx", url)
+
+	url, _, err = endpoints.URL("SP", ServiceInutilizacao)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://nfe.fazenda.sp.gov.br/ws/nfeinutilizacao4.asmx", url)
+
+	url, _, err = endpoints.URL("BA", ServiceInutilizacao)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://nfe.sefaz.ba.gov.br/webservices/NFeInutilizacao4/NFeInutilizacao4.asmx", url)
+
+	url, _, err = endpoints.URL("MA", ServiceInutilizacao)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://www.sefazvirtual.fazenda.gov.br/NFeInutilizacao4/NFeInutilizacao4.asmx", url)
+}
+
+func TestEndpointsURL_ConsultaProtocolo(t *testing.T) {
+	endpoints, err := NewEndpoints("homologacao", nil)
+	require.NoError(t, err)
+
+	url, _, err := endpoints.URL("PA", ServiceConsultaProtocolo)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://hom.sefazvirtual.fazenda.gov.br/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx", url)
+
+	url, _, err = endpoints.URL("RJ", ServiceConsultaProtocolo)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://nfe-homologacao.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx", url)
+
+	url, _, err = endpoints.URL("MG", ServiceConsultaProtocolo)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeConsultaProtocolo4", url)
+}
+
+func TestEndpointsURL_TodasUFs(t *testing.T) {
+	for _, ambiente := range []string{"producao", "homologacao"} {
+		endpoints, err := NewEndpoints(ambiente, nil)
+		require.NoError(t, err)
+
+		for uf := range codigosUF {
+			for _, service := range []Service{ServiceInutilizacao, ServiceConsultaProtocolo} {
+				_, _, err := endpoints.URL(uf, service)
+				assert.NoError(t, err, "%s %s %s", ambiente, uf, service)
+			}
+		}
+	}
+}
+
+func TestEndpointsURL_UFEmitente(t *testing.T) {
+	endpoints, err := NewEndpoints("producao", map[string]string{
+		"SVRS:NFeDistribuicaoDFe": "https://dfe.svrs.rs.gov.br/NFeDistribuicaoDFe.asmx",
+		"SVAN:NFeDistribuicaoDFe": "https://dfe.svan.gov.br/NFeDistribuicaoDFe.asmx",
+	})
+	require.NoError(t, err)
+
+	url, overridden, err := endpoints.URL("RJ", ServiceDistribuicaoDFe)
+	assert.NoError(t, err)
+	assert.True(t, overridden)
+	assert.Equal(t, "https://dfe.svrs.rs.gov.br/NFeDistribuicaoDFe.asmx", url)
+
+	url, _, err = endpoints.URL("MA", ServiceDistribuicaoDFe)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://dfe.svan.gov.br/NFeDistribuicaoDFe.asmx", url)
+
+	// UFs com SEFAZ própria seguem para o Ambiente Nacional
+	url, overridden, err = endpoints.URL("SP", ServiceDistribuicaoDFe)
+	assert.NoError(t, err)
+	assert.False(t, overridden)
+	assert.Equal(t, "https://www1.nfe.fazenda.gov.br/NFeDistribuicaoDFe/NFeDistribuicaoDFe.asmx", url)
+}
+
+func TestEndpointsURL_OverrideNacionalNaoDesviaUF(t *testing.T) {
+	endpoints, err := NewEndpoints("producao", map[string]string{
+		"AN:NFeConsultaProtocolo4":   "https://consulta.nfe.fazenda.gov.br/NFeConsultaProtocolo4.asmx",
+		"SVRS:NFeConsultaProtocolo4": "https://novo.svrs.rs.gov.br/ws/NfeConsulta4.asmx",
+	})
+	require.NoError(t, err)
+
+	url, overridden, err := endpoints.URL("SP", ServiceConsultaProtocolo)
+	assert.NoError(t, err)
+	assert.False(t, overridden)
+	assert.Equal(t, "https://nfe.fazenda.sp.gov.br/ws/nfeconsultaprotocolo4.asmx", url)
+
+	url, overridden, err = endpoints.URL("RJ", ServiceConsultaProtocolo)
+	assert.NoError(t, err)
+	assert.True(t, overridden)
+	assert.Equal(t, "https://novo.svrs.rs.gov.br/ws/NfeConsulta4.asmx", url)
+}
+
+func TestAutorizador(t *testing.T) {
+	assert.Equal(t, "SVRS", Autorizador("sc"))
+	assert.Equal(t, "SVAN", Autorizador("MA"))
+	assert.Equal(t, "SP", Autorizador("SP"))
+}
+
+func TestNewEndpoints_InvalidOverride(t *testing.T) {
+	_, err := NewEndpoints("producao", map[string]string{"NFeDistribuicaoDFe": "https://example.com"})
+	assert.Error(t, err)
+
+	_, err = NewEndpoints("producao", map[string]string{"XX:NFeDistribuicaoDFe": "https://example.com"})
+	assert.Error(t, err)
+
+	_, err = NewEndpoints("producao", map[string]string{"SP:NFeDistribuicaoDFe": "http://example.com"})
+	assert.Error(t, err)
+}
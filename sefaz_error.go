@@ -0,0 +1,81 @@
+package sefaz
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error é o erro retornado pelas chamadas aos web services da SEFAZ. CStat e
+// XMotivo vêm da resposta quando a SEFAZ chegou a responder e ficam zerados em
+// falhas de comunicação. Err é a causa (ErrServicoParalisado, ErrRejeicao,
+// domain.ErrXMLUnavailable, erro de rede...) e continua acessível por errors.Is.
+type Error struct {
+	Op      string
+	CStat   CStat
+	XMotivo string
+	// Retryable indica que a mesma chamada pode ter sucesso se repetida mais
+	// tarde (falha de comunicação, HTTP 5xx, serviço paralisado). Consumo
+	// indevido não é repetível: novas chamadas só prolongam o bloqueio.
+	Retryable bool
+	Err       error
+}
+
+// Error implementa a interface error
+func (e *Error) Error() string {
+	msg := e.Err.Error()
+	if e.Op != "" {
+		msg = e.Op + ": " + msg
+	}
+	if e.CStat != 0 {
+		msg += fmt.Sprintf(" (cStat %d: %s)", int(e.CStat), e.XMotivo)
+	}
+	return msg
+}
+
+// Unwrap retorna a causa do erro
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewCStatError cria o erro correspondente ao cStat de uma resposta, ou nil
+// quando o código representa sucesso
+func NewCStatError(op string, cStat CStat, xMotivo string) error {
+	if cStat.IsSuccess() {
+		return nil
+	}
+	return &Error{
+		Op:        op,
+		CStat:     cStat,
+		XMotivo:   xMotivo,
+		Retryable: cStat.IsRetryable(),
+		Err:       cStat.cause(),
+	}
+}
+
+// NewTransportError cria o erro de uma falha de comunicação com a SEFAZ, que
+// pode ser repetida
+func NewTransportError(op string, err error) error {
+	return &Error{Op: op, Retryable: true, Err: err}
+}
+
+// WithOp garante que err seja um *Error da operação informada. Erros que não
+// vieram da SEFAZ (ex: resposta inválida) são considerados não repetíveis.
+func WithOp(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var serr *Error
+	if errors.As(err, &serr) {
+		if serr.Op == "" {
+			serr.Op = op
+		}
+		return err
+	}
+	return &Error{Op: op, Err: err}
+}
+
+// IsRetryable indica se err é um erro da SEFAZ que pode ser repetido
+func IsRetryable(err error) bool {
+	var serr *Error
+	return errors.As(err, &serr) && serr.Retryable
+}
@@ -0,0 +1,52 @@
+package sefaz
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+func TestNewCStatError(t *testing.T) {
+	assert.NoError(t, NewCStatError("distribuicao dfe", CStatDocumentoLocalizado, "Documento localizado"))
+
+	err := NewCStatError("distribuicao dfe", CStatServicoParalisadoMomentaneo, "Servico paralisado")
+	assert.ErrorIs(t, err, ErrServicoParalisado)
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, "distribuicao dfe: sefaz service unavailable (cStat 108: Servico paralisado)", err.Error())
+
+	var serr *Error
+	assert.True(t, errors.As(err, &serr))
+	assert.Equal(t, CStatServicoParalisadoMomentaneo, serr.CStat)
+	assert.Equal(t, "Servico paralisado", serr.XMotivo)
+
+	err = NewCStatError("distribuicao dfe", CStatConsumoIndevido, "Consumo indevido")
+	assert.ErrorIs(t, err, ErrConsumoIndevido)
+	assert.False(t, IsRetryable(err))
+
+	err = NewCStatError("download xml", CStatNFeNaoConsta, "NF-e nao consta")
+	assert.ErrorIs(t, err, domain.ErrXMLUnavailable)
+	assert.False(t, IsRetryable(err))
+
+	err = NewCStatError("download xml", CStat(539), "Rejeicao")
+	assert.ErrorIs(t, err, ErrRejeicao)
+	assert.False(t, IsRetryable(err))
+}
+
+func TestWithOp(t *testing.T) {
+	assert.NoError(t, WithOp("download xml", nil))
+
+	err := WithOp("download xml", NewTransportError("", ErrCircuitOpen))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, "download xml: "+ErrCircuitOpen.Error(), err.Error())
+
+	err = WithOp("download xml", fmt.Errorf("failed to decode response"))
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, "download xml: failed to decode response", err.Error())
+
+	assert.False(t, IsRetryable(errors.New("other")))
+}
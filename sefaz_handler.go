@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// GetSefazStatus retorna o estado do circuit breaker das chamadas à SEFAZ
+// @Summary Status da SEFAZ
+// @Description Retorna o estado do circuit breaker (closed, open ou half_open) das chamadas à SEFAZ de cada CNPJ e o pior estado entre eles
+// @Tags SEFAZ
+// @Produce json
+// @Success 200 {object} domain.SefazStatus
+// @Router /api/v1/sefaz/status [get]
+func (h *NFeHandler) GetSefazStatus(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, h.service.GetSefazStatus())
+}
@@ -0,0 +1,112 @@
+package sefaz
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// diasSemana mapeia as abreviações aceitas em janelas de manutenção
+var diasSemana = map[string]time.Weekday{
+	"dom": time.Sunday, "seg": time.Monday, "ter": time.Tuesday, "qua": time.Wednesday,
+	"qui": time.Thursday, "sex": time.Friday, "sab": time.Saturday,
+}
+
+// MaintenanceWindow é um intervalo recorrente de manutenção programada da SEFAZ,
+// em minutos desde a meia-noite no fuso loc. Uma janela cujo fim é anterior
+// ao início atravessa a meia-noite (ex: 23:30-00:30).
+type MaintenanceWindow struct {
+	start   int
+	end     int
+	weekday time.Weekday
+	daily   bool
+	loc     *time.Location
+}
+
+// MaintenanceWindows é o conjunto de janelas de manutenção configuradas
+type MaintenanceWindows []MaintenanceWindow
+
+// ParseMaintenanceWindows interpreta uma lista separada por vírgulas de janelas
+// no formato "HH:MM-HH:MM" (todos os dias) ou "dia HH:MM-HH:MM" (ex: "dom 22:00-23:59").
+// Os horários são avaliados no fuso loc, o das janelas publicadas pela SEFAZ,
+// independentemente do fuso do processo.
+func ParseMaintenanceWindows(raw string, loc *time.Location) (MaintenanceWindows, error) {
+	windows := MaintenanceWindows{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		window := MaintenanceWindow{daily: true, loc: loc}
+		interval := entry
+		if fields := strings.Fields(entry); len(fields) == 2 {
+			weekday, ok := diasSemana[strings.ToLower(fields[0])]
+			if !ok {
+				return nil, fmt.Errorf("invalid maintenance window %q: unknown weekday %q", entry, fields[0])
+			}
+			window.weekday = weekday
+			window.daily = false
+			interval = fields[1]
+		}
+
+		start, end, ok := strings.Cut(interval, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid maintenance window %q: expected HH:MM-HH:MM", entry)
+		}
+
+		var err error
+		if window.start, err = parseClock(start); err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", entry, err)
+		}
+		if window.end, err = parseClock(end); err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", entry, err)
+		}
+		if window.start == window.end {
+			return nil, fmt.Errorf("invalid maintenance window %q: start and end are equal", entry)
+		}
+
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// parseClock converte HH:MM em minutos desde a meia-noite
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Active indica se o instante t está dentro de alguma janela de manutenção
+func (w MaintenanceWindows) Active(t time.Time) bool {
+	for _, window := range w {
+		if window.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains indica se o instante t está dentro da janela
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.start < w.end {
+		return w.onDay(t.Weekday()) && minute >= w.start && minute < w.end
+	}
+
+	// Janela que atravessa a meia-noite: o trecho após a meia-noite pertence ao dia anterior
+	if minute >= w.start {
+		return w.onDay(t.Weekday())
+	}
+	return minute < w.end && w.onDay((t.Weekday()+6)%7)
+}
+
+// onDay indica se a janela começa no dia da semana informado
+func (w MaintenanceWindow) onDay(day time.Weekday) bool {
+	return w.daily || w.weekday == day
+}
@@ -0,0 +1,53 @@
+package sefaz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brasilia é o horário de Brasília (UTC-3, sem horário de verão)
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+func TestMaintenanceWindows_Daily(t *testing.T) {
+	windows, err := ParseMaintenanceWindows("02:00-03:00", brasilia)
+	require.NoError(t, err)
+
+	assert.True(t, windows.Active(time.Date(2025, 12, 10, 2, 0, 0, 0, brasilia)))
+	assert.True(t, windows.Active(time.Date(2025, 12, 10, 2, 59, 0, 0, brasilia)))
+	assert.False(t, windows.Active(time.Date(2025, 12, 10, 3, 0, 0, 0, brasilia)))
+	assert.False(t, windows.Active(time.Date(2025, 12, 10, 1, 59, 0, 0, brasilia)))
+}
+
+func TestMaintenanceWindows_Timezone(t *testing.T) {
+	// 02:00-03:00 em Brasília é 05:00-06:00 em UTC, qualquer que seja o fuso do processo
+	windows, err := ParseMaintenanceWindows("02:00-03:00", brasilia)
+	require.NoError(t, err)
+
+	assert.True(t, windows.Active(time.Date(2025, 12, 10, 5, 30, 0, 0, time.UTC)))
+	assert.False(t, windows.Active(time.Date(2025, 12, 10, 2, 30, 0, 0, time.UTC)))
+}
+
+func TestMaintenanceWindows_WeekdayAcrossMidnight(t *testing.T) {
+	// 14/12/2025 é um domingo
+	windows, err := ParseMaintenanceWindows("dom 23:00-01:00", brasilia)
+	require.NoError(t, err)
+
+	assert.True(t, windows.Active(time.Date(2025, 12, 14, 23, 30, 0, 0, brasilia)))
+	assert.True(t, windows.Active(time.Date(2025, 12, 15, 0, 30, 0, 0, brasilia)))
+	assert.False(t, windows.Active(time.Date(2025, 12, 15, 23, 30, 0, 0, brasilia)))
+	assert.False(t, windows.Active(time.Date(2025, 12, 14, 0, 30, 0, 0, brasilia)))
+}
+
+func TestParseMaintenanceWindows_Invalid(t *testing.T) {
+	for _, raw := range []string{"02:00", "xyz 02:00-03:00", "25:00-26:00", "02:00-02:00"} {
+		_, err := ParseMaintenanceWindows(raw, brasilia)
+		assert.Error(t, err, raw)
+	}
+
+	windows, err := ParseMaintenanceWindows("", brasilia)
+	assert.NoError(t, err)
+	assert.Empty(t, windows)
+}
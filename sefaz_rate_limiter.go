@@ -0,0 +1,76 @@
+package sefaz
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter limita o total de chamadas à SEFAZ por minuto com um token
+// bucket. Assim como o Throttle, uma única instância é compartilhada por todos
+// os clientes e workers, limitando a taxa agregada do processo e não a de cada
+// worker. O balde começa cheio e comporta uma rajada de um minuto de chamadas.
+type RateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	// interval é o tempo para repor um token
+	interval time.Duration
+	now      func() time.Time
+	sleep    func(time.Duration)
+
+	// tokens fica negativo quando há chamadas aguardando a reposição
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter cria um limitador de perMinute chamadas por minuto; zero
+// desativa o limite
+func NewRateLimiter(perMinute int) *RateLimiter {
+	l := &RateLimiter{
+		perMinute: perMinute,
+		now:       time.Now,
+		sleep:     time.Sleep,
+		tokens:    float64(perMinute),
+	}
+	if perMinute > 0 {
+		l.interval = time.Minute / time.Duration(perMinute)
+	}
+	return l
+}
+
+// Wait consome um token, bloqueando até a sua reposição quando o balde está
+// vazio. Cada chamada reserva o seu token antes de esperar, então chamadas
+// concorrentes são liberadas na ordem de chegada. Um RateLimiter nil não espera.
+func (l *RateLimiter) Wait() {
+	if l == nil || l.perMinute <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if capacity := float64(l.perMinute); l.tokens > capacity {
+			l.tokens = capacity
+		}
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens * float64(l.interval))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		l.sleep(wait)
+	}
+}
+
+// PerMinute retorna o limite de chamadas por minuto configurado
+func (l *RateLimiter) PerMinute() int {
+	if l == nil {
+		return 0
+	}
+	return l.perMinute
+}
@@ -0,0 +1,61 @@
+package sefaz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_BurstThenRefill(t *testing.T) {
+	now := time.Date(2025, 12, 10, 2, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	limiter := NewRateLimiter(60)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	// O balde cheio libera um minuto de chamadas sem espera
+	for i := 0; i < 60; i++ {
+		limiter.Wait()
+	}
+	assert.Empty(t, waits)
+
+	// Com o balde vazio, cada chamada aguarda a reposição de um token (1s),
+	// somando as reservas das chamadas anteriores ainda em espera
+	limiter.Wait()
+	limiter.Wait()
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+	// Após a reposição das reservas e de mais um token, a chamada é imediata
+	waits = nil
+	now = now.Add(3 * time.Second)
+	limiter.Wait()
+	assert.Empty(t, waits)
+}
+
+func TestRateLimiter_CapacityCap(t *testing.T) {
+	now := time.Date(2025, 12, 10, 2, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	limiter := NewRateLimiter(2)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	limiter.Wait()
+
+	// Um longo período ocioso não acumula mais que a capacidade do balde
+	now = now.Add(time.Hour)
+	limiter.Wait()
+	limiter.Wait()
+	limiter.Wait()
+	assert.Equal(t, []time.Duration{30 * time.Second}, waits)
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	limiter := NewRateLimiter(0)
+	limiter.sleep = func(time.Duration) { t.Fatal("disabled rate limiter must not sleep") }
+	limiter.Wait()
+
+	var nilLimiter *RateLimiter
+	nilLimiter.Wait()
+	assert.Equal(t, 0, nilLimiter.PerMinute())
+}
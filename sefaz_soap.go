@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
+	"nfe-sefaz-sync/pkg/xmlsign"
+)
+
+const (
+	// nfeNamespace é o namespace das mensagens da NFe; a versão e o namespace
+	// do WSDL de cada serviço vêm de sefaz.Versions
+	nfeNamespace = "http://www.portalfiscal.inf.br/nfe"
+
+	// modeloNFe é o modelo de documento fiscal da NFe
+	modeloNFe = "55"
+
+	// ufAmbienteNacional resolve os serviços do Ambiente Nacional, onde são
+	// registrados os eventos de manifestação do destinatário; codigoOrgaoAN é
+	// o cOrgao desses eventos
+	ufAmbienteNacional = "AN"
+	codigoOrgaoAN      = "91"
+
+	// maxEventosLote é o máximo de eventos aceito pela SEFAZ em um envEvento
+	maxEventosLote = 20
+
+	soapEnvelopeTemplate = `<?xml version="1.0" encoding="utf-8"?>` +
+		`<soap12:Envelope xmlns:soap12="http://www.w3.org/2003/05/soap-envelope">` +
+		`<soap12:Body>%s</soap12:Body></soap12:Envelope>`
+)
+
+// distDFeIntXML representa a requisição de distribuição DFe
+type distDFeIntXML struct {
+	XMLName   xml.Name      `xml:"distDFeInt"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Versao    string        `xml:"versao,attr"`
+	TpAmb     string        `xml:"tpAmb"`
+	CUFAutor  string        `xml:"cUFAutor"`
+	CNPJ      string        `xml:"CNPJ"`
+	DistNSU   *distNSUXML   `xml:"distNSU,omitempty"`
+	ConsChNFe *consChNFeXML `xml:"consChNFe,omitempty"`
+}
+
+type distNSUXML struct {
+	UltNSU string `xml:"ultNSU"`
+}
+
+type consChNFeXML struct {
+	ChNFe string `xml:"chNFe"`
+}
+
+// distDFeResponseXML representa o envelope SOAP de resposta da distribuição DFe
+type distDFeResponseXML struct {
+	Fault  *soapFaultXML    `xml:"Body>Fault"`
+	Result retDistDFeIntXML `xml:"Body>nfeDistDFeInteresseResponse>nfeDistDFeInteresseResult>retDistDFeInt"`
+}
+
+type soapFaultXML struct {
+	Reason string `xml:"Reason>Text"`
+}
+
+type retDistDFeIntXML struct {
+	CStat   string      `xml:"cStat"`
+	XMotivo string      `xml:"xMotivo"`
+	UltNSU  string      `xml:"ultNSU"`
+	MaxNSU  string      `xml:"maxNSU"`
+	Docs    []docZipXML `xml:"loteDistDFeInt>docZip"`
+}
+
+type docZipXML struct {
+	NSU     string `xml:"NSU,attr"`
+	Schema  string `xml:"schema,attr"`
+	Content string `xml:",chardata"`
+}
+
+// resNFeXML representa o resumo de NFe entregue pela distribuição DFe
+type resNFeXML struct {
+	ChNFe string `xml:"chNFe"`
+	CNPJ  string `xml:"CNPJ"`
+	XNome string `xml:"xNome"`
+	DhEmi string `xml:"dhEmi"`
+	TpNF  string `xml:"tpNF"`
+	VNF   string `xml:"vNF"`
+}
+
+// inutNFeXML representa o pedido de inutilização de numeração
+type inutNFeXML struct {
+	XMLName xml.Name   `xml:"inutNFe"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Versao  string     `xml:"versao,attr"`
+	InfInut infInutXML `xml:"infInut"`
+}
+
+type infInutXML struct {
+	ID     string `xml:"Id,attr"`
+	TpAmb  string `xml:"tpAmb"`
+	XServ  string `xml:"xServ"`
+	CUF    string `xml:"cUF"`
+	Ano    string `xml:"ano"`
+	CNPJ   string `xml:"CNPJ"`
+	Mod    string `xml:"mod"`
+	Serie  string `xml:"serie"`
+	NNFIni string `xml:"nNFIni"`
+	NNFFin string `xml:"nNFFin"`
+	XJust  string `xml:"xJust"`
+}
+
+// inutNFeResponseXML representa o envelope SOAP de resposta da inutilização
+type inutNFeResponseXML struct {
+	Fault  *soapFaultXML `xml:"Body>Fault"`
+	Result retInfInutXML `xml:"Body>nfeResultMsg>retInutNFe>infInut"`
+}
+
+type retInfInutXML struct {
+	CStat    string `xml:"cStat"`
+	XMotivo  string `xml:"xMotivo"`
+	DhRecbto string `xml:"dhRecbto"`
+	NProt    string `xml:"nProt"`
+}
+
+// consSitNFeXML representa o pedido de consulta protocolo da NFe. Ao contrário
+// da inutilização e dos eventos, o pedido não é assinado.
+type consSitNFeXML struct {
+	XMLName xml.Name `xml:"consSitNFe"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Versao  string   `xml:"versao,attr"`
+	TpAmb   string   `xml:"tpAmb"`
+	XServ   string   `xml:"xServ"`
+	ChNFe   string   `xml:"chNFe"`
+}
+
+// consSitNFeResponseXML representa o envelope SOAP de resposta da consulta protocolo
+type consSitNFeResponseXML struct {
+	Fault  *soapFaultXML    `xml:"Body>Fault"`
+	Result retConsSitNFeXML `xml:"Body>nfeResultMsg>retConsSitNFe"`
+}
+
+// retConsSitNFeXML traz a situação da NFe, o protocolo de autorização e os
+// eventos registrados, como o cancelamento
+type retConsSitNFeXML struct {
+	CStat         string             `xml:"cStat"`
+	XMotivo       string             `xml:"xMotivo"`
+	ChNFe         string             `xml:"chNFe"`
+	ProtNFe       *protNFeXML        `xml:"protNFe"`
+	ProcEventoNFe []procEventoNFeXML `xml:"procEventoNFe"`
+}
+
+// eventoXML representa um evento da NFe, assinado individualmente dentro do envEvento
+type eventoXML struct {
+	XMLName   xml.Name     `xml:"evento"`
+	Xmlns     string       `xml:"xmlns,attr"`
+	Versao    string       `xml:"versao,attr"`
+	InfEvento infEventoXML `xml:"infEvento"`
+}
+
+type infEventoXML struct {
+	ID         string       `xml:"Id,attr"`
+	COrgao     string       `xml:"cOrgao"`
+	TpAmb      string       `xml:"tpAmb"`
+	CNPJ       string       `xml:"CNPJ"`
+	ChNFe      string       `xml:"chNFe"`
+	DhEvento   string       `xml:"dhEvento"`
+	TpEvento   string       `xml:"tpEvento"`
+	NSeqEvento string       `xml:"nSeqEvento"`
+	VerEvento  string       `xml:"verEvento"`
+	DetEvento  detEventoXML `xml:"detEvento"`
+}
+
+type detEventoXML struct {
+	Versao     string `xml:"versao,attr"`
+	DescEvento string `xml:"descEvento"`
+	XJust      string `xml:"xJust,omitempty"`
+}
+
+// envEventoResponseXML representa o envelope SOAP de resposta da recepção de eventos
+type envEventoResponseXML struct {
+	Fault  *soapFaultXML   `xml:"Body>Fault"`
+	Result retEnvEventoXML `xml:"Body>nfeResultMsg>retEnvEvento"`
+}
+
+type retEnvEventoXML struct {
+	CStat     string            `xml:"cStat"`
+	XMotivo   string            `xml:"xMotivo"`
+	RetEvento []retInfEventoXML `xml:"retEvento>infEvento"`
+}
+
+type retInfEventoXML struct {
+	CStat       string `xml:"cStat"`
+	XMotivo     string `xml:"xMotivo"`
+	ChNFe       string `xml:"chNFe"`
+	DhRegEvento string `xml:"dhRegEvento"`
+	NProt       string `xml:"nProt"`
+}
+
+// procEventoNFeXML representa um evento da NFe com o registro da SEFAZ, como
+// entregue pela distribuição DFe
+type procEventoNFeXML struct {
+	Evento    eventoXML       `xml:"evento"`
+	RetEvento retInfEventoXML `xml:"retEvento>infEvento"`
+}
+
+// eventoID monta o Id do infEvento: "ID" + tpEvento + chave de acesso +
+// sequencial do evento (2 dígitos)
+func eventoID(tpEvento, chaveAcesso string, nSeqEvento int) string {
+	return fmt.Sprintf("ID%s%s%02d", tpEvento, chaveAcesso, nSeqEvento)
+}
+
+// buildEnvEventoEnvelope assina cada evento com o certificado e monta o lote
+// envEvento no envelope SOAP na versão informada
+func buildEnvEventoEnvelope(idLote string, eventos []eventoXML, cert tls.Certificate, version sefaz.ServiceVersion) ([]byte, error) {
+	var lote strings.Builder
+	for _, evento := range eventos {
+		evento.Xmlns = nfeNamespace
+		evento.Versao = version.Versao
+
+		dados, err := xml.Marshal(evento)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal evento: %w", err)
+		}
+
+		signed, err := xmlsign.SignByID(dados, evento.InfEvento.ID, cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign evento %s: %w", evento.InfEvento.ID, err)
+		}
+		lote.Write(signed)
+	}
+
+	body := fmt.Sprintf(`<nfeDadosMsg xmlns="%s"><envEvento xmlns="%s" versao="%s"><idLote>%s</idLote>%s</envEvento></nfeDadosMsg>`,
+		version.Namespace, nfeNamespace, version.Versao, idLote, lote.String())
+
+	return []byte(fmt.Sprintf(soapEnvelopeTemplate, body)), nil
+}
+
+// parseEnvEventoResponse interpreta o envelope SOAP de resposta da recepção de eventos
+func parseEnvEventoResponse(data []byte) (*retEnvEventoXML, error) {
+	var resp envEventoResponseXML
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse sefaz response: %w", err)
+	}
+	if resp.Fault != nil {
+		return nil, fmt.Errorf("sefaz soap fault: %s", resp.Fault.Reason)
+	}
+	return &resp.Result, nil
+}
+
+// inutilizacaoID monta o Id do infInut: "ID" + cUF + ano + CNPJ + modelo +
+// série (3 dígitos) + número inicial e final (9 dígitos cada)
+func inutilizacaoID(cUF, ano, cnpj string, serie, numeroInicial, numeroFinal int) string {
+	return fmt.Sprintf("ID%s%s%s%s%03d%09d%09d", cUF, ano, cnpj, modeloNFe, serie, numeroInicial, numeroFinal)
+}
+
+// buildInutNFeEnvelope assina o pedido de inutilização com o certificado e
+// monta o envelope SOAP na versão informada
+func buildInutNFeEnvelope(msg inutNFeXML, cert tls.Certificate, version sefaz.ServiceVersion) ([]byte, error) {
+	msg.Xmlns = nfeNamespace
+	msg.Versao = version.Versao
+
+	dados, err := xml.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inutNFe: %w", err)
+	}
+
+	signed, err := xmlsign.SignByID(dados, msg.InfInut.ID, cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign inutNFe: %w", err)
+	}
+
+	body := fmt.Sprintf(`<nfeDadosMsg xmlns="%s">%s</nfeDadosMsg>`, version.Namespace, signed)
+
+	return []byte(fmt.Sprintf(soapEnvelopeTemplate, body)), nil
+}
+
+// parseInutNFeResponse interpreta o envelope SOAP de resposta da inutilização
+func parseInutNFeResponse(data []byte) (*retInfInutXML, error) {
+	var resp inutNFeResponseXML
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse sefaz response: %w", err)
+	}
+	if resp.Fault != nil {
+		return nil, fmt.Errorf("sefaz soap fault: %s", resp.Fault.Reason)
+	}
+	return &resp.Result, nil
+}
+
+// buildConsSitNFeEnvelope monta o envelope SOAP da consulta protocolo na versão informada
+func buildConsSitNFeEnvelope(msg consSitNFeXML, version sefaz.ServiceVersion) ([]byte, error) {
+	msg.Xmlns = nfeNamespace
+	msg.Versao = version.Versao
+
+	dados, err := xml.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal consSitNFe: %w", err)
+	}
+
+	body := fmt.Sprintf(`<nfeDadosMsg xmlns="%s">%s</nfeDadosMsg>`, version.Namespace, dados)
+
+	return []byte(fmt.Sprintf(soapEnvelopeTemplate, body)), nil
+}
+
+// parseConsSitNFeResponse interpreta o envelope SOAP de resposta da consulta protocolo
+func parseConsSitNFeResponse(data []byte) (*retConsSitNFeXML, error) {
+	var resp consSitNFeResponseXML
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse sefaz response: %w", err)
+	}
+	if resp.Fault != nil {
+		return nil, fmt.Errorf("sefaz soap fault: %s", resp.Fault.Reason)
+	}
+	return &resp.Result, nil
+}
+
+// buildDistDFeEnvelope monta o envelope SOAP da requisição de distribuição DFe
+// na versão informada
+func buildDistDFeEnvelope(msg distDFeIntXML, version sefaz.ServiceVersion) ([]byte, error) {
+	msg.Xmlns = nfeNamespace
+	msg.Versao = version.Versao
+
+	dados, err := xml.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal distDFeInt: %w", err)
+	}
+
+	body := fmt.Sprintf(`<nfeDistDFeInteresse xmlns="%s"><nfeDadosMsg>%s</nfeDadosMsg></nfeDistDFeInteresse>`,
+		version.Namespace, dados)
+
+	return []byte(fmt.Sprintf(soapEnvelopeTemplate, body)), nil
+}
+
+// parseDistDFeResponse interpreta o envelope SOAP de resposta da distribuição DFe
+func parseDistDFeResponse(data []byte) (*retDistDFeIntXML, error) {
+	var resp distDFeResponseXML
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse sefaz response: %w", err)
+	}
+	if resp.Fault != nil {
+		return nil, fmt.Errorf("sefaz soap fault: %s", resp.Fault.Reason)
+	}
+	return &resp.Result, nil
+}
+
+// decodeDocZip decodifica o conteúdo de um docZip (base64 + gzip) e verifica
+// se o resultado é um XML bem formado antes de ser armazenado
+func decodeDocZip(content string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode docZip base64: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open docZip gzip: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress docZip: %w", err)
+	}
+
+	if err := checkWellFormed(data); err != nil {
+		return nil, fmt.Errorf("docZip content: %w", err)
+	}
+
+	return data, nil
+}
+
+// checkWellFormed percorre todo o documento e falha se ele não for um XML bem
+// formado com um único elemento raiz
+func checkWellFormed(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	depth, roots := 0, 0
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidXML, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return fmt.Errorf("%w: content outside the root element", domain.ErrInvalidXML)
+			}
+		}
+	}
+
+	if roots != 1 {
+		return fmt.Errorf("%w: expected one root element, found %d", domain.ErrInvalidXML, roots)
+	}
+	return nil
+}
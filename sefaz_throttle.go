@@ -0,0 +1,59 @@
+package sefaz
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle garante um intervalo mínimo entre chamadas consecutivas à SEFAZ,
+// que bloqueia temporariamente os CNPJs que consultam rápido demais. Uma única
+// instância é compartilhada por todos os clientes, então o intervalo vale para
+// o processo inteiro, inclusive entre os workers de download.
+type Throttle struct {
+	mu    sync.Mutex
+	delay time.Duration
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	// next é o primeiro instante em que a próxima chamada pode ser feita
+	next time.Time
+}
+
+// NewThrottle cria um limitador com o intervalo informado; zero desativa a espera
+func NewThrottle(delay time.Duration) *Throttle {
+	return &Throttle{
+		delay: delay,
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// Wait bloqueia até que a chamada possa ser feita. Cada chamada reserva o seu
+// horário antes de esperar, então chamadas concorrentes são liberadas uma a
+// uma, espaçadas pelo intervalo. Um Throttle nil não espera.
+func (t *Throttle) Wait() {
+	if t == nil || t.delay <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	now := t.now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.delay)
+	t.mu.Unlock()
+
+	if wait := at.Sub(now); wait > 0 {
+		t.sleep(wait)
+	}
+}
+
+// Delay retorna o intervalo mínimo configurado
+func (t *Throttle) Delay() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return t.delay
+}
@@ -0,0 +1,39 @@
+package sefaz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle_SpacesConsecutiveCalls(t *testing.T) {
+	now := time.Date(2025, 12, 10, 2, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	throttle := NewThrottle(500 * time.Millisecond)
+	throttle.now = func() time.Time { return now }
+	throttle.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	// A primeira chamada é imediata; as seguintes, feitas no mesmo instante,
+	// ficam espaçadas pelo intervalo
+	throttle.Wait()
+	throttle.Wait()
+	throttle.Wait()
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, waits)
+
+	// Passado o intervalo desde a última reserva, não há espera
+	waits = nil
+	now = now.Add(2 * time.Second)
+	throttle.Wait()
+	assert.Empty(t, waits)
+}
+
+func TestThrottle_Disabled(t *testing.T) {
+	throttle := NewThrottle(0)
+	throttle.sleep = func(time.Duration) { t.Fatal("disabled throttle must not sleep") }
+	throttle.Wait()
+
+	var nilThrottle *Throttle
+	nilThrottle.Wait()
+	assert.Equal(t, time.Duration(0), nilThrottle.Delay())
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxIdleConnsPerHost = 10
+	dialTimeout                = 30 * time.Second
+	tlsHandshakeTimeout        = 15 * time.Second
+	tlsSessionCacheSize        = 32
+)
+
+// tlsVersions mapeia as versões mínimas de TLS aceitas na configuração
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newSefazTransport cria o transporte HTTP autenticado com o certificado do cliente
+func newSefazTransport(cert tls.Certificate, opts SefazClientOptions) (*http.Transport, error) {
+	proxy, err := proxyFunc(opts.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	minVersion := uint16(tls.VersionTLS12)
+	if opts.MinTLSVersion != "" {
+		v, ok := tlsVersions[opts.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid sefaz minimum tls version %q", opts.MinTLSVersion)
+		}
+		minVersion = v
+	}
+
+	maxIdlePerHost := opts.MaxIdleConnsPerHost
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = defaultMaxIdleConnsPerHost
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: opts.KeepAlive,
+	}
+
+	// O transporte é criado uma única vez por cliente: as conexões TLS com o
+	// certificado do cliente ficam no pool e o cache de sessões permite retomar
+	// handshakes de novas conexões sem a negociação completa
+	return &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        maxIdlePerHost * 4,
+		MaxIdleConnsPerHost: maxIdlePerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+		TLSClientConfig: &tls.Config{
+			Certificates:       []tls.Certificate{cert},
+			MinVersion:         minVersion,
+			ClientSessionCache: tls.NewLRUClientSessionCache(tlsSessionCacheSize),
+		},
+	}, nil
+}
+
+// proxyFunc retorna a função de proxy do transporte. Sem proxy configurado
+// mantém o comportamento padrão (HTTPS_PROXY/NO_PROXY do ambiente).
+func proxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid sefaz proxy url %q", proxyURL)
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// bypassProxy indica se o host está listado em NO_PROXY (domínios, IPs ou CIDRs)
+func bypassProxy(host, noProxy string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+
+		if ip != nil {
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package sefaz
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// ServiceVersion é a versão do leiaute das mensagens de um serviço e o
+// namespace do WSDL usado no corpo do envelope SOAP e na ação SOAP
+type ServiceVersion struct {
+	Versao    string
+	Namespace string
+}
+
+// versaoPattern é o formato das versões de leiaute da NFe (ex: 4.00, 1.01)
+var versaoPattern = regexp.MustCompile(`^[0-9]\.[0-9]{2}$`)
+
+// builtinVersions são as versões em vigor de cada serviço
+var builtinVersions = map[Service]ServiceVersion{
+	ServiceDistribuicaoDFe:   {Versao: "1.01", Namespace: wsdlNamespace + string(ServiceDistribuicaoDFe)},
+	ServiceRecepcaoEvento:    {Versao: "1.00", Namespace: wsdlNamespace + string(ServiceRecepcaoEvento)},
+	ServiceInutilizacao:      {Versao: "4.00", Namespace: wsdlNamespace + string(ServiceInutilizacao)},
+	ServiceConsultaProtocolo: {Versao: "4.00", Namespace: wsdlNamespace + string(ServiceConsultaProtocolo)},
+}
+
+// Versions resolve a versão e o namespace de cada serviço, aplicando as
+// substituições configuradas antes das versões em vigor
+type Versions struct {
+	versions map[Service]ServiceVersion
+}
+
+// NewVersions cria o registro de versões. versions e namespaces são indexados
+// pelo nome do serviço (ex: NFeInutilizacao4) e substituem apenas o valor
+// informado, mantendo o outro.
+func NewVersions(versions, namespaces map[string]string) (*Versions, error) {
+	resolved := make(map[Service]ServiceVersion, len(builtinVersions))
+	for service, version := range builtinVersions {
+		resolved[service] = version
+	}
+
+	for name, versao := range versions {
+		version, ok := resolved[Service(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid service version override: unknown service %s", name)
+		}
+		if !versaoPattern.MatchString(versao) {
+			return nil, fmt.Errorf("invalid service version %q for %s, expected N.NN", versao, name)
+		}
+		version.Versao = versao
+		resolved[Service(name)] = version
+	}
+
+	for name, namespace := range namespaces {
+		version, ok := resolved[Service(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid service namespace override: unknown service %s", name)
+		}
+		u, err := url.Parse(namespace)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid service namespace %q for %s", namespace, name)
+		}
+		version.Namespace = namespace
+		resolved[Service(name)] = version
+	}
+
+	return &Versions{versions: resolved}, nil
+}
+
+// Get retorna a versão e o namespace do serviço
+func (v *Versions) Get(service Service) ServiceVersion {
+	return v.versions[service]
+}
+
+// SOAPAction retorna a ação SOAP da operação do serviço, enviada no
+// Content-Type e no cabeçalho SOAPAction
+func (v *Versions) SOAPAction(service Service) string {
+	return v.versions[service].Namespace + "/" + soapOperations[service]
+}
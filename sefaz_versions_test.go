@@ -0,0 +1,43 @@
+package sefaz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersions_Builtin(t *testing.T) {
+	versions, err := NewVersions(nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, ServiceVersion{
+		Versao:    "4.00",
+		Namespace: "http://www.portalfiscal.inf.br/nfe/wsdl/NFeInutilizacao4",
+	}, versions.Get(ServiceInutilizacao))
+	assert.Equal(t, "http://www.portalfiscal.inf.br/nfe/wsdl/NFeDistribuicaoDFe/nfeDistDFeInteresse", versions.SOAPAction(ServiceDistribuicaoDFe))
+	assert.Equal(t, "http://www.portalfiscal.inf.br/nfe/wsdl/NFeInutilizacao4/nfeInutilizacaoNF", versions.SOAPAction(ServiceInutilizacao))
+}
+
+func TestVersions_Override(t *testing.T) {
+	versions, err := NewVersions(
+		map[string]string{"NFeInutilizacao4": "4.01"},
+		map[string]string{"NFeInutilizacao4": "http://www.portalfiscal.inf.br/nfe/wsdl/NFeInutilizacao5"},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "4.01", versions.Get(ServiceInutilizacao).Versao)
+	assert.Equal(t, "http://www.portalfiscal.inf.br/nfe/wsdl/NFeInutilizacao5/nfeInutilizacaoNF", versions.SOAPAction(ServiceInutilizacao))
+	assert.Equal(t, "1.01", versions.Get(ServiceDistribuicaoDFe).Versao)
+}
+
+func TestVersions_Invalid(t *testing.T) {
+	_, err := NewVersions(map[string]string{"NFeAutorizacao4": "4.00"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewVersions(map[string]string{"NFeInutilizacao4": "4"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewVersions(nil, map[string]string{"NFeInutilizacao4": "portalfiscal"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/pkg/certificate"
+)
+
+// certExpiryWarning é a antecedência com que um certificado a vencer gera aviso
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// RunSelfCheck verifica as dependências da aplicação e registra cada resultado
+// no log. Banco, migrations, certificados e armazenamento com problema são
+// falhas; certificados perto do vencimento e a SEFAZ fora do ar são avisos.
+func (s *nfeService) RunSelfCheck() *domain.SelfCheckReport {
+	report := &domain.SelfCheckReport{CheckedAt: time.Now()}
+
+	s.checkSchema(report)
+	for _, company := range s.companies {
+		s.checkCertificate(report, company, report.CheckedAt)
+	}
+	s.checkStorage(report)
+	for _, company := range s.companies {
+		s.checkSefaz(report, company)
+	}
+
+	for _, check := range report.Checks {
+		switch check.Status {
+		case domain.SelfCheckFail:
+			s.logger.Error("Verificação de inicialização falhou", "check", check.Name, "detail", check.Detail)
+		case domain.SelfCheckWarn:
+			s.logger.Warn("Verificação de inicialização com aviso", "check", check.Name, "detail", check.Detail)
+		default:
+			s.logger.Info("Verificação de inicialização concluída", "check", check.Name)
+		}
+	}
+
+	s.selfCheck.Store(report)
+	return report
+}
+
+// GetSelfCheck retorna o resultado da última verificação de inicialização
+func (s *nfeService) GetSelfCheck() (*domain.SelfCheckReport, error) {
+	report := s.selfCheck.Load()
+	if report == nil {
+		return nil, domain.ErrSelfCheckNotRun
+	}
+	return report, nil
+}
+
+// checkSchema verifica se o banco responde e se as migrations foram aplicadas
+// sem interrupção
+func (s *nfeService) checkSchema(report *domain.SelfCheckReport) {
+	schema, err := s.schemaRepo.Current()
+	if err != nil {
+		report.Add("database", domain.SelfCheckFail, err.Error())
+		report.Add("migrations", domain.SelfCheckFail, "database unreachable")
+		return
+	}
+	report.Add("database", domain.SelfCheckOK, "")
+
+	switch {
+	case schema == nil:
+		report.Add("migrations", domain.SelfCheckFail, "no migrations applied")
+	case schema.Dirty:
+		report.Add("migrations", domain.SelfCheckFail, fmt.Sprintf("migration %d is dirty", schema.Version))
+	default:
+		report.Add("migrations", domain.SelfCheckOK, fmt.Sprintf("version %d", schema.Version))
+	}
+}
+
+// checkCertificate lê novamente o certificado do CNPJ e verifica a validade
+func (s *nfeService) checkCertificate(report *domain.SelfCheckReport, company Company, now time.Time) {
+	name := "certificate " + company.CNPJ
+
+	cert, err := certificate.LoadCertificate(company.CertPath, company.CertPassword)
+	if err != nil {
+		report.Add(name, domain.SelfCheckFail, err.Error())
+		return
+	}
+
+	leaf := cert.Leaf
+	switch {
+	case now.Before(leaf.NotBefore) || now.After(leaf.NotAfter):
+		report.Add(name, domain.SelfCheckFail, fmt.Sprintf("%v (valid from %s to %s)", domain.ErrCertificateExpired,
+			leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339)))
+	case leaf.NotAfter.Sub(now) < certExpiryWarning:
+		report.Add(name, domain.SelfCheckWarn, fmt.Sprintf("certificate expires at %s", leaf.NotAfter.Format(time.RFC3339)))
+	default:
+		report.Add(name, domain.SelfCheckOK, fmt.Sprintf("valid until %s", leaf.NotAfter.Format(time.RFC3339)))
+	}
+}
+
+// checkSefaz verifica se a SEFAZ responde ao CNPJ. A SEFAZ fora do ar não
+// impede a inicialização: a sincronização tenta novamente no próximo agendamento.
+func (s *nfeService) checkSefaz(report *domain.SelfCheckReport, company Company) {
+	name := "sefaz " + company.CNPJ
+	if err := company.Client.Ping(); err != nil {
+		report.Add(name, domain.SelfCheckWarn, err.Error())
+		return
+	}
+	report.Add(name, domain.SelfCheckOK, "")
+}
+
+// checkStorage verifica se é possível gravar no armazenamento dos XMLs
+func (s *nfeService) checkStorage(report *domain.SelfCheckReport) {
+	if err := s.xmlStorage.Check(); err != nil {
+		report.Add("storage", domain.SelfCheckFail, err.Error())
+		return
+	}
+	report.Add("storage", domain.SelfCheckOK, string(s.xmlStorage.Backend()))
+}
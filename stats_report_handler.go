@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/pkg/pdf"
+)
+
+// maxReportNameLength limita o nome do emitente para caber na coluna do relatório
+const maxReportNameLength = 45
+
+// GetStatsReport gera o relatório de estatísticas do período em PDF
+// @Summary Relatório de estatísticas
+// @Description Gera um PDF com a quantidade e o valor das NFes, a distribuição por status e os maiores emitentes do período
+// @Tags NFe
+// @Produce application/pdf
+// @Param start_date query string true "Data início (YYYY-MM-DD)"
+// @Param end_date query string true "Data fim (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/stats/report [get]
+func (h *NFeHandler) GetStatsReport(w http.ResponseWriter, r *http.Request) {
+	startDateStr := r.URL.Query().Get("start_date")
+	endDateStr := r.URL.Query().Get("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		h.sendError(w, r, http.StatusBadRequest, "start_date e end_date são obrigatórios", nil)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Formato de data inválido para start_date", err)
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "Formato de data inválido para end_date", err)
+		return
+	}
+
+	report, err := h.service.GetStatsReport(startDate, endDate)
+	if err != nil {
+		h.logger.Error("Erro ao gerar relatório de estatísticas", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao gerar relatório de estatísticas", err)
+		return
+	}
+
+	filename := fmt.Sprintf("relatorio-nfes-%s-%s.pdf", startDateStr, endDateStr)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(renderStatsReport(report)); err != nil {
+		h.logger.Error("Erro ao enviar relatório de estatísticas", "error", err)
+	}
+}
+
+// renderStatsReport monta o PDF do relatório de estatísticas
+func renderStatsReport(report *domain.NFeStatsReport) []byte {
+	stats := report.Stats
+	doc := pdf.New()
+
+	doc.Text("Relatório de NFes", 18, true)
+	doc.Text(fmt.Sprintf("Período: %s a %s", stats.Periodo.Inicio.Format("02/01/2006"), stats.Periodo.Fim.Format("02/01/2006")), 11, false)
+	doc.Text("Gerado em "+report.GeneratedAt.Format("02/01/2006 15:04"), 9, false)
+	doc.Space(12)
+
+	doc.Text("Resumo", 13, true)
+	doc.Row(11, false, pdf.Column{Text: "Quantidade de NFes"}, pdf.Column{X: 220, Text: formatInt(stats.TotalNFes)})
+	doc.Row(11, false, pdf.Column{Text: "Valor total"}, pdf.Column{X: 220, Text: formatBRL(stats.ValorTotal)})
+	doc.Space(12)
+
+	doc.Text("Por status", 13, true)
+	if len(stats.PorStatus) == 0 {
+		doc.Text("Nenhuma NFe no período", 10, false)
+	}
+	for _, s := range stats.PorStatus {
+		doc.Row(11, false, pdf.Column{Text: string(s.Status)}, pdf.Column{X: 220, Text: formatInt(s.Count)})
+	}
+	doc.Space(12)
+
+	doc.Text(fmt.Sprintf("Maiores emitentes (%d)", len(report.TopEmitentes)), 13, true)
+	doc.Row(9, true,
+		pdf.Column{Text: "CNPJ"},
+		pdf.Column{X: 100, Text: "Emitente"},
+		pdf.Column{X: 340, Text: "NFes"},
+		pdf.Column{X: 390, Text: "Valor"},
+	)
+	for _, e := range report.TopEmitentes {
+		doc.Row(9, false,
+			pdf.Column{Text: e.CNPJEmitente},
+			pdf.Column{X: 100, Text: truncate(e.NomeEmitente, maxReportNameLength)},
+			pdf.Column{X: 340, Text: formatInt(e.TotalNFes)},
+			pdf.Column{X: 390, Text: formatBRL(e.ValorTotal)},
+		)
+	}
+
+	return doc.Bytes()
+}
+
+// formatBRL formata o valor em reais no padrão brasileiro (R$ 1.234,56)
+func formatBRL(m domain.Money) string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%sR$ %s,%02d", sign, formatInt(int64(m)/100), int64(m)%100)
+}
+
+// formatInt formata um inteiro com separador de milhar (1.234)
+func formatInt(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if n < 0 {
+		return "-" + formatInt(-n)
+	}
+
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// truncate limita o texto a max caracteres, indicando o corte com reticências
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// minRetentionYears é o prazo mínimo de guarda dos documentos fiscais
+const minRetentionYears = 5
+
+// StorageRetention define a política de retenção dos XMLs armazenados
+type StorageRetention struct {
+	// Years é o prazo de guarda em anos, contado a partir do primeiro dia do ano
+	// seguinte ao da emissão. Zero desativa a limpeza.
+	Years int
+
+	// BackupPath é o diretório com a cópia de segurança dos XMLs, na mesma
+	// estrutura do armazenamento principal
+	BackupPath string
+
+	// ArchivePath, quando informado, recebe os XMLs vencidos em vez de excluí-los
+	ArchivePath string
+}
+
+// CleanupStorage arquiva ou exclui os XMLs emitidos fora do prazo de retenção.
+// Somente arquivos com cópia idêntica no backup são removidos. Em modo dry run
+// apenas lista os XMLs que seriam removidos.
+func (s *nfeService) CleanupStorage(dryRun bool) (*domain.StorageCleanupReport, error) {
+	if s.retention.Years == 0 {
+		return nil, domain.ErrRetentionDisabled
+	}
+	if s.retention.Years < minRetentionYears {
+		return nil, fmt.Errorf("retention of %d years is below the fiscal minimum of %d", s.retention.Years, minRetentionYears)
+	}
+
+	cutoff := retentionCutoff(time.Now(), s.retention.Years)
+
+	refs, err := s.repo.ListXMLReferencesBefore(cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.StorageCleanupReport{
+		DryRun:         dryRun,
+		RetentionYears: s.retention.Years,
+		Cutoff:         cutoff,
+		Candidates:     refs,
+		Archived:       []string{},
+		Deleted:        []string{},
+		NotBackedUp:    []string{},
+		Failed:         []string{},
+		CleanedAt:      time.Now(),
+	}
+
+	for _, ref := range refs {
+		backedUp, err := s.isBackedUp(ref.XMLPath)
+		if err != nil {
+			s.logger.Error("Erro ao verificar backup do XML", "chave", ref.ChaveAcesso, "error", err)
+			report.Failed = append(report.Failed, ref.ChaveAcesso)
+			continue
+		}
+		if !backedUp {
+			report.NotBackedUp = append(report.NotBackedUp, ref.ChaveAcesso)
+			continue
+		}
+		if dryRun {
+			continue
+		}
+
+		archived, err := s.removeXML(ref)
+		switch {
+		case err != nil:
+			s.logger.Error("Erro ao remover XML vencido", "chave", ref.ChaveAcesso, "error", err)
+			report.Failed = append(report.Failed, ref.ChaveAcesso)
+		case archived:
+			report.Archived = append(report.Archived, ref.ChaveAcesso)
+		default:
+			report.Deleted = append(report.Deleted, ref.ChaveAcesso)
+		}
+	}
+
+	s.logger.Info("Limpeza do armazenamento concluída",
+		"dry_run", dryRun,
+		"cutoff", cutoff.Format("2006-01-02"),
+		"candidates", len(report.Candidates),
+		"archived", len(report.Archived),
+		"deleted", len(report.Deleted),
+		"not_backed_up", len(report.NotBackedUp),
+		"failed", len(report.Failed),
+	)
+
+	return report, nil
+}
+
+// removeXML arquiva ou exclui o XML e registra a remoção no banco. Retorna true
+// quando o arquivo foi arquivado.
+func (s *nfeService) removeXML(ref domain.XMLReference) (bool, error) {
+	if s.retention.ArchivePath == "" {
+		if err := os.Remove(ref.XMLPath); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to delete xml file: %w", err)
+		}
+		return false, s.repo.MarkXMLRemoved(ref.ChaveAcesso, "")
+	}
+
+	rel, err := s.storageRelPath(ref.XMLPath)
+	if err != nil {
+		return false, err
+	}
+
+	archivePath := filepath.Join(s.retention.ArchivePath, rel)
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := moveFile(ref.XMLPath, archivePath); err != nil {
+		return false, err
+	}
+
+	return true, s.repo.MarkXMLRemoved(ref.ChaveAcesso, archivePath)
+}
+
+// isBackedUp verifica se existe no backup uma cópia idêntica do XML
+func (s *nfeService) isBackedUp(xmlPath string) (bool, error) {
+	if s.retention.BackupPath == "" {
+		return false, nil
+	}
+
+	rel, err := s.storageRelPath(xmlPath)
+	if err != nil {
+		return false, err
+	}
+
+	original, err := fileHash(xmlPath)
+	if err != nil {
+		return false, err
+	}
+
+	backup, err := fileHash(filepath.Join(s.retention.BackupPath, rel))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return bytes.Equal(original, backup), nil
+}
+
+// storageRelPath retorna o caminho do XML relativo ao armazenamento principal
+func (s *nfeService) storageRelPath(xmlPath string) (string, error) {
+	rel, err := filepath.Rel(absPath(s.xmlStoragePath), absPath(xmlPath))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("xml %s is outside the storage path", xmlPath)
+	}
+	return rel, nil
+}
+
+// retentionCutoff retorna a data antes da qual as NFes já cumpriram o prazo de
+// guarda, contado a partir do primeiro dia do exercício seguinte ao da emissão
+func retentionCutoff(now time.Time, years int) time.Time {
+	return time.Date(now.Year()-years, time.January, 1, 0, 0, 0, 0, now.Location())
+}
+
+// fileHash calcula o SHA-256 do conteúdo de um arquivo
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return h.Sum(nil), nil
+}
+
+// moveFile move um arquivo, copiando e removendo o original quando origem e
+// destino estão em sistemas de arquivos diferentes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open xml file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create archived xml: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy xml to archive: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write archived xml: %w", err)
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/pkg/logger"
+)
+
+// webhookAlerter envia alertas de sincronização para um webhook compatível
+// com o Slack (incoming webhook)
+type webhookAlerter struct {
+	url            string
+	errorThreshold int
+	client         *http.Client
+	logger         *logger.Logger
+}
+
+// alertPayload é o corpo enviado ao webhook. O Slack usa apenas text; os
+// demais campos permitem que outros consumidores tratem o alerta sem interpretar o texto.
+type alertPayload struct {
+	Text      string               `json:"text"`
+	JobID     string               `json:"job_id"`
+	Status    domain.SyncJobStatus `json:"status"`
+	Error     string               `json:"error,omitempty"`
+	NFesFound int                  `json:"nfes_found"`
+	NFesError int                  `json:"nfes_error"`
+}
+
+// NewWebhookAlerter cria o alerter de falhas de sincronização. Um alerta é
+// enviado quando o job falha ou quando o número de NFes com erro atinge
+// errorThreshold (0 desativa o alerta por quantidade de erros).
+func NewWebhookAlerter(url string, errorThreshold int, timeout time.Duration, log *logger.Logger) domain.SyncAlerter {
+	return &webhookAlerter{
+		url:            url,
+		errorThreshold: errorThreshold,
+		client:         &http.Client{Timeout: timeout},
+		logger:         log,
+	}
+}
+
+// NotifySync envia o alerta caso o job tenha falhado ou excedido o limite de erros
+func (a *webhookAlerter) NotifySync(job *domain.SyncJob) error {
+	if !a.shouldAlert(job) {
+		return nil
+	}
+
+	payload := alertPayload{
+		Text:      alertText(job),
+		JobID:     job.ID.String(),
+		Status:    job.Status,
+		Error:     job.Error,
+		NFesFound: job.NFesFound,
+		NFesError: job.NFesError,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+
+	a.logger.Info("Alerta de sincronização enviado", "job_id", job.ID, "status", job.Status)
+	return nil
+}
+
+// shouldAlert indica se o job deve gerar alerta
+func (a *webhookAlerter) shouldAlert(job *domain.SyncJob) bool {
+	if job.Status == domain.SyncJobStatusFailed {
+		return true
+	}
+	return a.errorThreshold > 0 && job.NFesError >= a.errorThreshold
+}
+
+// alertText monta a mensagem legível do alerta
+func alertText(job *domain.SyncJob) string {
+	if job.Status == domain.SyncJobStatusFailed {
+		return fmt.Sprintf(":rotating_light: Sincronização de NFes falhou (job %s): %s", job.ID, job.Error)
+	}
+	return fmt.Sprintf(":warning: Sincronização de NFes concluída com %d NFe(s) com erro e %d sincronizada(s) (job %s)",
+		job.NFesError, job.NFesFound, job.ID)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// GetSyncHealth retorna a idade da última sincronização bem-sucedida
+// @Summary Saúde da sincronização
+// @Description Retorna quando ocorreu a última sincronização bem-sucedida, há quantos segundos e o status da última execução. Com max_age, responde 503 quando a última sincronização bem-sucedida é mais antiga que o limite ou não existe.
+// @Tags Sync
+// @Produce json
+// @Param max_age query string false "Idade máxima aceita (ex: 6h, 90m)"
+// @Success 200 {object} domain.SyncHealth
+// @Failure 400 {object} ErrorResponse
+// @Failure 503 {object} domain.SyncHealth
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync/health [get]
+func (h *NFeHandler) GetSyncHealth(w http.ResponseWriter, r *http.Request) {
+	var maxAge time.Duration
+	if maxAgeStr := r.URL.Query().Get("max_age"); maxAgeStr != "" {
+		parsed, err := time.ParseDuration(maxAgeStr)
+		if err != nil || parsed <= 0 {
+			h.sendError(w, r, http.StatusBadRequest, "Valor inválido para max_age", err)
+			return
+		}
+		maxAge = parsed
+	}
+
+	health, err := h.service.GetSyncHealth()
+	if err != nil {
+		h.logger.Error("Erro ao consultar saúde da sincronização", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao consultar saúde da sincronização", err)
+		return
+	}
+
+	status := http.StatusOK
+	if maxAge > 0 && (health.AgeSeconds == nil || time.Duration(*health.AgeSeconds)*time.Second > maxAge) {
+		status = http.StatusServiceUnavailable
+	}
+
+	h.sendJSON(w, status, health)
+}
+
+// GetSyncJobErrors lista as NFes que falharam em uma sincronização
+// @Summary Erros da sincronização
+// @Description Lista cada chave de acesso que falhou no job de sincronização, com o motivo
+// @Tags Sync
+// @Produce json
+// @Param id path string true "ID do job de sincronização"
+// @Success 200 {object} domain.SyncJobErrors
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync/jobs/{id}/errors [get]
+func (h *NFeHandler) GetSyncJobErrors(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "ID de sincronização inválido", err)
+		return
+	}
+
+	jobErrors, err := h.service.GetSyncJobErrors(jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSyncJobNotFound) {
+			h.sendError(w, r, http.StatusNotFound, "Sincronização não encontrada", err)
+			return
+		}
+		h.logger.Error("Erro ao buscar erros da sincronização", "job_id", jobID, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao buscar erros da sincronização", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, jobErrors)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// GetSyncJobRetention retorna o prazo de guarda do histórico de sincronizações
+func (s *nfeService) GetSyncJobRetention() domain.SyncJobRetention {
+	retention := domain.SyncJobRetention{
+		RetentionDays: s.jobRetentionDays,
+		Enabled:       s.jobRetentionDays > 0,
+	}
+	if retention.Enabled {
+		cutoff := jobRetentionCutoff(time.Now(), s.jobRetentionDays)
+		retention.Cutoff = &cutoff
+	}
+	return retention
+}
+
+// CleanupSyncJobs remove os jobs de sincronização mais antigos que o prazo de
+// guarda, junto com os erros registrados neles. Jobs em andamento são mantidos.
+func (s *nfeService) CleanupSyncJobs() (int64, error) {
+	if s.jobRetentionDays == 0 {
+		return 0, domain.ErrJobRetentionDisabled
+	}
+
+	cutoff := jobRetentionCutoff(time.Now(), s.jobRetentionDays)
+	deleted, err := s.jobRepo.DeleteStartedBefore(cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Histórico de sincronizações limpo",
+		"retention_days", s.jobRetentionDays,
+		"cutoff", cutoff,
+		"jobs_removidos", deleted,
+	)
+	return deleted, nil
+}
+
+// jobRetentionCutoff retorna o início do dia mais antigo mantido no histórico
+func jobRetentionCutoff(now time.Time, days int) time.Time {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return start.AddDate(0, 0, -days)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+	"nfe-sefaz-sync/internal/sefaz"
+)
+
+const (
+	// maxSyncAttempts é o número máximo de tentativas de cada chamada à SEFAZ
+	// durante a sincronização, incluindo a primeira
+	maxSyncAttempts = 3
+
+	// syncRetryBackoff é a espera antes da primeira nova tentativa; as
+	// seguintes esperam proporcionalmente ao número da tentativa
+	syncRetryBackoff = 2 * time.Second
+)
+
+// retryBudget é o total de novas tentativas de uma sincronização, somando
+// todos os CNPJs e workers. Esgotado, a sincronização é encerrada. Um
+// orçamento nil desativa as novas tentativas: cada chamada é feita uma vez.
+type retryBudget struct {
+	remaining atomic.Int64
+	spent     atomic.Bool
+}
+
+// newRetryBudget cria o orçamento com o total de novas tentativas permitidas;
+// zero retorna nil
+func newRetryBudget(total int) *retryBudget {
+	if total <= 0 {
+		return nil
+	}
+	b := &retryBudget{}
+	b.remaining.Store(int64(total))
+	return b
+}
+
+// take consome uma nova tentativa. Retorna false, marcando o orçamento como
+// esgotado, quando não restam tentativas.
+func (b *retryBudget) take() bool {
+	if b.remaining.Add(-1) >= 0 {
+		return true
+	}
+	b.spent.Store(true)
+	return false
+}
+
+// exhausted indica se alguma nova tentativa foi recusada por falta de orçamento
+func (b *retryBudget) exhausted() bool {
+	return b != nil && b.spent.Load()
+}
+
+// withRetry executa call e repete as falhas repetíveis da SEFAZ (serviço
+// paralisado, falha de comunicação, HTTP 5xx) até maxSyncAttempts vezes,
+// consumindo o orçamento da sincronização. Com o orçamento esgotado, retorna
+// domain.ErrRetryBudgetExhausted junto com a última falha, sem chamar a SEFAZ.
+func (s *nfeService) withRetry(budget *retryBudget, op string, call func() error) error {
+	if budget == nil {
+		return call()
+	}
+	if budget.exhausted() {
+		return domain.ErrRetryBudgetExhausted
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || !sefaz.IsRetryable(err) || attempt == maxSyncAttempts {
+			return err
+		}
+		if !budget.take() {
+			s.logger.Warn("Orçamento de novas tentativas da sincronização esgotado", "op", op, "error", err)
+			return fmt.Errorf("%w: %v", domain.ErrRetryBudgetExhausted, err)
+		}
+
+		s.logger.Warn("Falha repetível da SEFAZ, nova tentativa",
+			"op", op,
+			"attempt", attempt+1,
+			"retries_remaining", budget.remaining.Load(),
+			"error", err,
+		)
+		time.Sleep(time.Duration(attempt) * syncRetryBackoff)
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// statusSyncPageSize é o tamanho da página usada para coletar as NFes cuja
+// situação é consultada na SEFAZ
+const statusSyncPageSize = 200
+
+// statusSyncStatuses são os status que ainda podem mudar na SEFAZ: uma NFe
+// autorizada (ou suspeita, que também é autorizada) pode ser cancelada
+var statusSyncStatuses = []domain.NFeStatus{domain.NFeStatusAutorizada, domain.NFeStatusSuspeita}
+
+// SyncStatuses consulta o protocolo de cada NFe autorizada dos últimos
+// syncLookbackDays dias e registra os cancelamentos, sem baixar os XMLs
+// novamente. Divide o limite de execuções simultâneas com SyncNFes e grava a
+// execução no histórico de sincronizações; nfes_found conta as NFes cujo
+// status foi atualizado.
+func (s *nfeService) SyncStatuses() (*domain.SyncJob, error) {
+	select {
+	case s.syncSlots <- struct{}{}:
+		defer func() { <-s.syncSlots }()
+	default:
+		s.logger.Warn("Sincronização de status recusada: limite de execuções simultâneas atingido", "max_concurrent_jobs", cap(s.syncSlots))
+		return nil, domain.ErrSyncLimitReached
+	}
+
+	job := &domain.SyncJob{
+		ID:        uuid.New(),
+		Status:    domain.SyncJobStatusRunning,
+		StartedAt: time.Now(),
+	}
+	s.saveJob(job)
+
+	nfes, err := s.statusSyncNFes(job.StartedAt.AddDate(0, 0, -syncLookbackDays))
+	if err != nil {
+		s.finishJob(job, domain.SyncJobStatusFailed, err)
+		return job, err
+	}
+
+	s.logger.Info("Consultando situação das NFes na SEFAZ", "job_id", job.ID, "nfes", len(nfes))
+
+	// Qualquer certificado válido é aceito na consulta protocolo; usa o do
+	// CNPJ principal
+	company := s.companies[0]
+	budget := newRetryBudget(s.maxTotalRetries)
+	var canceladas []domain.Cancelamento
+	var stopErr error
+	for i := range nfes {
+		if budget.exhausted() {
+			stopErr = fmt.Errorf("status sync stopped: %w", domain.ErrRetryBudgetExhausted)
+			break
+		}
+
+		cancelamento, err := s.consultarStatus(company, &nfes[i], budget)
+		if err != nil {
+			s.logger.Error("Erro ao consultar situação da NFe", "job_id", job.ID, "chave", nfes[i].ChaveAcesso, "error", err)
+			s.saveJobError(job, company.CNPJ, nfes[i].ChaveAcesso, err)
+			job.NFesError++
+			continue
+		}
+		if cancelamento != nil {
+			canceladas = append(canceladas, *cancelamento)
+		}
+	}
+
+	// Os cancelamentos encontrados são gravados juntos, cada um com a data e a
+	// justificativa do evento, mesmo quando a execução é interrompida pelo
+	// orçamento de novas tentativas
+	if len(canceladas) > 0 {
+		err := s.repo.WithTx(func(tx domain.RepoTx) error {
+			updated, err := tx.CancelBatch(canceladas)
+			job.NFesFound = int(updated)
+			return err
+		})
+		if err != nil {
+			stopErr = errors.Join(stopErr, fmt.Errorf("failed to record cancellations: %w", err))
+		}
+	}
+	if stopErr != nil {
+		s.finishJob(job, domain.SyncJobStatusFailed, stopErr)
+		return job, stopErr
+	}
+
+	s.finishJob(job, domain.SyncJobStatusCompleted, nil)
+
+	s.logger.Info("Sincronização de status finalizada",
+		"job_id", job.ID,
+		"nfes_updated", job.NFesFound,
+		"nfes_error", job.NFesError,
+	)
+
+	return job, nil
+}
+
+// statusSyncNFes lista as NFes a consultar, apenas com as colunas da grade. A
+// lista é coletada inteira antes da consulta para que as páginas não dependam
+// do resultado das consultas.
+func (s *nfeService) statusSyncNFes(startDate time.Time) ([]domain.NFe, error) {
+	filter := domain.NFeFilter{
+		Statuses:   statusSyncStatuses,
+		StartDate:  &startDate,
+		Projection: domain.NFeProjectionSummary,
+		Limit:      statusSyncPageSize,
+		Page:       1,
+	}
+
+	var all []domain.NFe
+	for {
+		nfes, total, err := s.repo.FindByFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, nfes...)
+
+		if int64(filter.Page*filter.Limit) >= total || len(nfes) == 0 {
+			return all, nil
+		}
+		filter.Page++
+	}
+}
+
+// consultarStatus consulta o protocolo de uma NFe e retorna o cancelamento
+// informado pela SEFAZ, ou nil quando ela não está cancelada. Outras
+// divergências de status são apenas registradas no log.
+func (s *nfeService) consultarStatus(company Company, nfe *domain.NFe, budget *retryBudget) (*domain.Cancelamento, error) {
+	var protocolo *domain.ProtocoloNFe
+	err := s.withRetry(budget, "consultar protocolo", func() error {
+		var err error
+		protocolo, err = company.Client.ConsultarProtocolo(nfe.ChaveAcesso)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if protocolo.StatusMatches(nfe.Status) {
+		return nil, nil
+	}
+	if protocolo.Status != domain.NFeStatusCancelada {
+		s.logger.Warn("Status da NFe diverge da SEFAZ",
+			"chave", nfe.ChaveAcesso,
+			"status", nfe.Status,
+			"status_sefaz", protocolo.Status,
+			"cstat", protocolo.CStat,
+		)
+		return nil, nil
+	}
+
+	s.logger.Info("Cancelamento da NFe encontrado pela consulta protocolo", "chave", nfe.ChaveAcesso, "data_cancelamento", protocolo.DataCancelamento)
+
+	return &domain.Cancelamento{
+		ChaveAcesso: nfe.ChaveAcesso,
+		Data:        protocolo.DataCancelamento,
+		Motivo:      protocolo.MotivoCancelamento,
+	}, nil
+}
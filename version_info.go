@@ -0,0 +1,16 @@
+package service
+
+import (
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// GetVersionInfo retorna a versão do binário em execução e a última migration
+// aplicada ao banco
+func (s *nfeService) GetVersionInfo() (*domain.VersionInfo, error) {
+	schema, err := s.schemaRepo.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.VersionInfo{BuildInfo: s.build, Schema: schema}, nil
+}
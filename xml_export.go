@@ -0,0 +1,110 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+const (
+	// xmlExportPageSize é o tamanho da página usada para percorrer as NFes na exportação
+	xmlExportPageSize = 100
+
+	// xmlExportErrorsFile é o manifesto com as NFes que ficaram de fora do ZIP
+	xmlExportErrorsFile = "_errors.txt"
+)
+
+// ExportXMLs gera um ZIP com os XMLs armazenados das NFes que atendem ao filtro.
+// Uma NFe sem XML ou cujo arquivo não pode ser lido não interrompe a exportação:
+// sua chave é listada no manifesto _errors.txt e as demais são entregues.
+func (s *nfeService) ExportXMLs(filter domain.NFeFilter) (*domain.XMLExport, error) {
+	filter.Limit = xmlExportPageSize
+	filter.Page = 1
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	export := &domain.XMLExport{Missing: []domain.XMLExportError{}}
+
+	for {
+		nfes, total, err := s.repo.FindByFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		// O limite é verificado na primeira página, antes de ler qualquer XML
+		if filter.Page == 1 && s.maxExportRows > 0 && total > int64(s.maxExportRows) {
+			return nil, fmt.Errorf("%w: %d nfes, limit %d", domain.ErrExportTooLarge, total, s.maxExportRows)
+		}
+
+		for i := range nfes {
+			data, err := s.readStoredXML(&nfes[i])
+			if err != nil {
+				s.logger.Warn("XML não incluído na exportação", "chave", nfes[i].ChaveAcesso, "error", err)
+				export.Missing = append(export.Missing, domain.XMLExportError{
+					ChaveAcesso: nfes[i].ChaveAcesso,
+					Reason:      err.Error(),
+				})
+				continue
+			}
+
+			if err := writeZipEntry(zw, nfes[i].ChaveAcesso+".xml", data); err != nil {
+				return nil, err
+			}
+			export.Included++
+		}
+
+		if int64(filter.Page*filter.Limit) >= total || len(nfes) == 0 {
+			break
+		}
+		filter.Page++
+	}
+
+	if len(export.Missing) > 0 {
+		if err := writeZipEntry(zw, xmlExportErrorsFile, xmlExportManifest(export.Missing)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish zip: %w", err)
+	}
+	export.Data = buf.Bytes()
+
+	s.logger.Info("Exportação de XMLs concluída", "incluidas", export.Included, "ausentes", len(export.Missing))
+
+	return export, nil
+}
+
+// readStoredXML lê o XML armazenado da NFe
+func (s *nfeService) readStoredXML(nfe *domain.NFe) ([]byte, error) {
+	if nfe.XMLPath == "" {
+		return nil, domain.ErrXMLNotStored
+	}
+	return s.xmlStorage.Read(nfe.XMLPath)
+}
+
+// writeZipEntry adiciona um arquivo ao ZIP
+func writeZipEntry(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry %s: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write zip entry %s: %w", name, err)
+	}
+	return nil
+}
+
+// xmlExportManifest monta o _errors.txt, uma linha por NFe ausente com a chave e o motivo
+func xmlExportManifest(missing []domain.XMLExportError) []byte {
+	var sb strings.Builder
+	for _, m := range missing {
+		fmt.Fprintf(&sb, "%s\t%s\n", m.ChaveAcesso, m.Reason)
+	}
+	return []byte(sb.String())
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// ExportXMLs exporta em um ZIP os XMLs das NFes do período
+// @Summary Exportar XMLs
+// @Description Gera um ZIP com os XMLs armazenados das NFes do período. NFes sem XML ou com o arquivo ausente não interrompem a exportação: suas chaves são listadas, com o motivo, no arquivo _errors.txt dentro do ZIP, e a quantidade é informada no header X-Export-Missing.
+// @Tags NFe
+// @Produce application/zip
+// @Param start_date query string true "Data início (YYYY-MM-DD)"
+// @Param end_date query string true "Data fim (YYYY-MM-DD)"
+// @Param status query string false "Status da NFe"
+// @Param origem query string false "Origem da NFe (emitida, recebida ou importada)"
+// @Param cnpj_emitente query string false "CNPJ do emitente"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nfe/export [get]
+func (h *NFeHandler) ExportXMLs(w http.ResponseWriter, r *http.Request) {
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "start_date obrigatório no formato YYYY-MM-DD", err)
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "end_date obrigatório no formato YYYY-MM-DD", err)
+		return
+	}
+
+	filter := domain.NFeFilter{
+		CNPJEmitente: r.URL.Query().Get("cnpj_emitente"),
+		Status:       domain.NFeStatus(r.URL.Query().Get("status")),
+		Origem:       domain.NFeOrigem(r.URL.Query().Get("origem")),
+		StartDate:    &startDate,
+		EndDate:      &endDate,
+	}
+
+	export, err := h.service.ExportXMLs(filter)
+	if err != nil {
+		if isValidationError(err) {
+			h.sendError(w, r, http.StatusBadRequest, "Filtro inválido", err)
+			return
+		}
+		if errors.Is(err, domain.ErrExportTooLarge) {
+			h.sendError(w, r, http.StatusRequestEntityTooLarge, "Exportação excede o limite de NFes, reduza o período", err)
+			return
+		}
+		h.logger.Error("Erro ao exportar XMLs", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "Erro ao exportar XMLs", err)
+		return
+	}
+
+	filename := "nfes_" + startDate.Format("20060102") + "_" + endDate.Format("20060102") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Header().Set("Content-Length", strconv.Itoa(len(export.Data)))
+	w.Header().Set("X-Export-Missing", strconv.Itoa(len(export.Missing)))
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(export.Data)
+}
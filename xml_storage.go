@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"nfe-sefaz-sync/internal/domain"
+)
+
+// diskXMLStorage implementa domain.XMLStorage gravando os XMLs em arquivos
+// organizados por ano/mês. A referência é o caminho do arquivo.
+type diskXMLStorage struct {
+	root    string
+	shardBy domain.StorageShardBy
+}
+
+// NewDiskXMLStorage cria o armazenamento dos XMLs no diretório root
+func NewDiskXMLStorage(root string, shardBy domain.StorageShardBy) domain.XMLStorage {
+	return &diskXMLStorage{root: root, shardBy: shardBy}
+}
+
+// Backend retorna domain.XMLStorageDisk
+func (d *diskXMLStorage) Backend() domain.XMLStorageBackend {
+	return domain.XMLStorageDisk
+}
+
+// Save grava o XML no diretório do ano/mês da emissão ou do recebimento. No
+// recebimento, uma NFe que já possui XML é regravada no caminho atual, para não
+// deixar cópias antigas em outros meses.
+func (d *diskXMLStorage) Save(chave string, dataEmissao time.Time, currentRef string, data []byte) (string, error) {
+	var dir string
+	switch {
+	case d.shardBy == domain.ShardByRecebimento && currentRef != "":
+		dir = filepath.Dir(currentRef)
+	case d.shardBy == domain.ShardByRecebimento:
+		now := time.Now()
+		dir = filepath.Join(d.root, now.Format("2006"), now.Format("01"))
+	default:
+		dir = filepath.Join(d.root, dataEmissao.Format("2006"), dataEmissao.Format("01"))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create xml directory: %w", err)
+	}
+
+	path := filepath.Join(dir, chave+".xml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write xml file: %w", err)
+	}
+
+	return path, nil
+}
+
+// Read lê o arquivo XML
+func (d *diskXMLStorage) Read(ref string) ([]byte, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read xml file: %w", err)
+	}
+	return data, nil
+}
+
+// Exists indica se o arquivo XML existe
+func (d *diskXMLStorage) Exists(ref string) (bool, error) {
+	if _, err := os.Stat(ref); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat xml file %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// Check grava e remove um arquivo temporário no diretório de armazenamento
+func (d *diskXMLStorage) Check() error {
+	f, err := os.CreateTemp(d.root, ".selfcheck-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}